@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/config"
+)
+
+// runEval implements the "eval" subcommand:
+//
+//	server eval [-repo ID] [-label TAG] [-k N] [-rerank] [-expand] [-lexical-weight W] set.yaml...
+//
+// Each golden set is run through the RAG pipeline, stored, and compared with the previous run.
+func runEval(ctx context.Context, args []string, cfg *config.Config, evalService *service.EvalService, index *domain.EmbeddingIndex) error {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	repoID := fs.String("repo", "", "repository ID (overrides repo_id in the set)")
+	label := fs.String("label", "", "tag stored with the run, e.g. chunk-256")
+	k := fs.Int("k", 0, "recall cutoff (overrides k in the set)")
+	snapshotID := fs.String("snapshot", "", "snapshot to query (default: latest)")
+	rerank := fs.Bool("rerank", cfg.RerankEnabled, "enable the reranking stage")
+	expand := fs.Bool("expand", false, "enable query expansion")
+	lexicalWeight := fs.Float64("lexical-weight", -1, "hybrid lexical weight in [0,1] (default: service default)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return fmt.Errorf("usage: eval [flags] set.yaml...")
+	}
+
+	opts := service.EvalOptions{
+		Query: service.QueryOptions{
+			SnapshotID: *snapshotID,
+			Rerank:     rerank,
+			Expand:     *expand,
+		},
+		Label: *label,
+		Config: map[string]string{
+			"embed_model":  index.Model,
+			"embed_dim":    strconv.Itoa(index.Dimension),
+			"chat_model":   cfg.OllamaChatModel,
+			"judge_model":  cfg.ModelForStrategy("judge"),
+			"rerank":       strconv.FormatBool(*rerank),
+			"rerank_model": cfg.ModelForStrategy("rerank"),
+			"expand":       strconv.FormatBool(*expand),
+		},
+	}
+	if *lexicalWeight >= 0 {
+		opts.Query.LexicalWeight = lexicalWeight
+		opts.Config["lexical_weight"] = strconv.FormatFloat(*lexicalWeight, 'f', 2, 64)
+	}
+
+	for _, file := range fs.Args() {
+		set, err := service.LoadEvalSet(file)
+		if err != nil {
+			return err
+		}
+		if *repoID != "" {
+			set.RepoID = *repoID
+		}
+		if *k > 0 {
+			set.K = *k
+		}
+
+		run, err := evalService.Run(ctx, set, opts)
+		if err != nil {
+			return err
+		}
+		previous, err := evalService.PreviousRun(ctx, run)
+		if err != nil {
+			return err
+		}
+		printEvalRun(run, previous)
+	}
+	return nil
+}
+
+// printEvalRun writes per-question scores and the run summary, with deltas against the previous run.
+func printEvalRun(run, previous *domain.EvalRun) {
+	fmt.Printf("\n%s (repo %s, run %s, label %q)\n", run.SetName, run.RepoID, run.ID, run.Label)
+	fmt.Printf("%-20s %8s %6s %8s %6s\n", "question", "recall", "rr", "faithful", "facts")
+	for _, r := range run.Results {
+		line := fmt.Sprintf("%-20s %8.2f %6.2f %8.2f %6.2f", r.CaseID, r.Recall, r.ReciprocalRank, r.Faithfulness, r.FactRecall)
+		if r.Error != "" {
+			line += "  error: " + r.Error
+		}
+		fmt.Println(line)
+	}
+
+	metric := func(name string, value float64, prev func(*domain.EvalRun) float64) {
+		if previous == nil {
+			fmt.Printf("%-14s %.3f\n", name, value)
+			return
+		}
+		fmt.Printf("%-14s %.3f (%+.3f vs %s)\n", name, value, value-prev(previous), previous.CreatedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Println()
+	metric(fmt.Sprintf("recall@%d", run.K), run.RecallAtK, func(r *domain.EvalRun) float64 { return r.RecallAtK })
+	metric("mrr", run.MRR, func(r *domain.EvalRun) float64 { return r.MRR })
+	metric("faithfulness", run.Faithfulness, func(r *domain.EvalRun) float64 { return r.Faithfulness })
+	metric("fact recall", run.FactRecall, func(r *domain.EvalRun) float64 { return r.FactRecall })
+}
@@ -1,21 +1,28 @@
 package main
 
 import (
+	"context"
 	"log/slog"
 	"os"
 	"time"
+	_ "time/tzdata" // schedule timezones must resolve on hosts without a zoneinfo database
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/ai"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/analysis"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/auth"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/chunker"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/forge"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/notify"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/vcs"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/webhook"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/handler"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/mcp"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
 	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/config"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/secretbox"
 	"github.com/gofiber/fiber/v3"
 	"github.com/gofiber/fiber/v3/middleware/cors"
 	fiberlogger "github.com/gofiber/fiber/v3/middleware/logger"
@@ -31,6 +38,10 @@ func main() {
 
 	// ── Configuration ────────────────────────────────────────────────────
 	cfg := config.Load()
+	if err := cfg.Validate(); err != nil {
+		slog.Error("invalid configuration", "error", err)
+		os.Exit(1)
+	}
 
 	slog.Info("🚀 Starting CodeLens AI",
 		"port", cfg.Port,
@@ -47,7 +58,14 @@ func main() {
 	}
 	defer pgStore.Close()
 
-	vectorStore := store.NewVectorStore(pgStore, cfg.EmbeddingDimension)
+	vectorStore := store.NewVectorStore(pgStore, cfg.EmbeddingDimension, store.VectorIndexConfig{
+		Type:           cfg.VectorIndexType,
+		M:              cfg.HNSWM,
+		EfConstruction: cfg.HNSWEfConstruction,
+		EfSearch:       cfg.HNSWEfSearch,
+		Lists:          cfg.IVFFlatLists,
+		Probes:         cfg.IVFFlatProbes,
+	})
 
 	// ── Adapters ─────────────────────────────────────────────────────────
 	googleAuth := auth.NewGoogleProvider(cfg.GoogleClientID, cfg.GoogleClientSecret, cfg.GoogleRedirectURL)
@@ -57,6 +75,16 @@ func main() {
 		"google": googleAuth,
 		"github": githubAuth,
 	}
+	// Further forges offer login (and repository import) once their OAuth app is configured
+	if cfg.GitLabClientID != "" {
+		providers["gitlab"] = auth.NewGitLabProvider(cfg.GitLabURL, cfg.GitLabClientID, cfg.GitLabClientSecret, cfg.GitLabRedirectURL)
+	}
+	if cfg.GiteaClientID != "" {
+		providers["gitea"] = auth.NewGiteaProvider(cfg.GiteaURL, cfg.GiteaClientID, cfg.GiteaClientSecret, cfg.GiteaRedirectURL)
+	}
+	if cfg.BitbucketClientID != "" {
+		providers["bitbucket"] = auth.NewBitbucketProvider(cfg.BitbucketClientID, cfg.BitbucketClientSecret)
+	}
 
 	ollamaAI := ai.NewOllamaProvider(
 		ai.OllamaEndpointConfig{
@@ -70,7 +98,51 @@ func main() {
 			Token:   cfg.OllamaChatToken,
 		},
 	)
-	gitVCS := vcs.NewGitProvider()
+	gitVCS := vcs.NewGitProvider(vcs.SSHConfig{KnownHostsFile: cfg.SSHKnownHostsFile, HostKeyPolicy: cfg.SSHHostKeyPolicy})
+
+	// Notification channels; email only when an SMTP server is configured
+	notifiers := port.NotifierRegistry{
+		"webhook": notify.NewWebhookNotifier(),
+		"slack":   notify.NewSlackNotifier(),
+	}
+	if cfg.SMTPHost != "" {
+		notifiers["email"] = notify.NewSMTPNotifier(notify.SMTPConfig{
+			Host:     cfg.SMTPHost,
+			Port:     cfg.SMTPPort,
+			Username: cfg.SMTPUsername,
+			Password: cfg.SMTPPassword,
+			From:     cfg.SMTPFrom,
+		})
+	}
+
+	// Forge APIs repositories are imported from, keyed like the auth providers, reviews
+	// are posted to, keyed like the webhook parsers, and the trackers findings are
+	// opened in as issues
+	githubClient := forge.NewGitHubClient()
+	gitlabClient := forge.NewGitLabClient(cfg.GitLabURL)
+	giteaClient := forge.NewGiteaClient(cfg.GiteaURL)
+	forgeProviders := port.ForgeProviderRegistry{
+		"github":    githubClient,
+		"gitlab":    gitlabClient,
+		"gitea":     giteaClient,
+		"bitbucket": forge.NewBitbucketClient(),
+	}
+	forgeClients := port.ForgeClientRegistry{
+		"github": githubClient,
+		"gitlab": gitlabClient,
+		"gitea":  giteaClient,
+	}
+	issueTrackers := port.IssueTrackerRegistry{
+		"github": githubClient,
+		"gitlab": gitlabClient,
+		"jira":   forge.NewJiraClient(),
+	}
+
+	// Code chunkers for RAG indexing, keyed by detected language
+	chunkers := port.ChunkerRegistry{
+		port.DefaultChunker: chunker.NewLineChunker(512),
+		"go":                chunker.NewGoChunker(512),
+	}
 
 	// Helper: create AI provider with per-strategy model override
 	aiForStrategy := func(strategy string) *ai.OllamaProvider {
@@ -101,9 +173,102 @@ func main() {
 
 	// ── Services ─────────────────────────────────────────────────────────
 	authService := service.NewAuthService(providers, pgStore, cfg)
-	repoService := service.NewRepoService(pgStore, gitVCS, cfg.CloneBasePath)
+
+	// SSH keys repos are cloned with over SSH and issue tracker tokens are stored
+	// encrypted, so both are managed only when SECRET_KEY is set
+	var sshKeyService *service.SSHKeyService
+	var secretBox *secretbox.Box
+	if cfg.SecretKey != "" {
+		box, err := secretbox.New(cfg.SecretKey)
+		if err != nil {
+			slog.Error("failed to set up secret encryption", "error", err)
+			os.Exit(1)
+		}
+		secretBox = box
+		sshKeyService = service.NewSSHKeyService(pgStore, box)
+	}
+
+	credentialService := service.NewCredentialService(pgStore, sshKeyService, forgeProviders)
+	repoService := service.NewRepoService(pgStore, gitVCS, cfg.CloneBasePath, credentialService, service.ClonePolicy{
+		QuotaBytes: int64(cfg.CloneQuotaMB) << 20,
+		IdleAfter:  time.Duration(cfg.CloneIdleHours) * time.Hour,
+		Interval:   time.Duration(cfg.CloneJanitorInterval) * time.Minute,
+	})
+	go repoService.ScrubRemotes(context.Background())
 	analysisService := service.NewAnalysisService(engine)
-	ragService := service.NewRAGService(ollamaAI, vectorStore)
+	notificationService := service.NewNotificationService(pgStore, notifiers, cfg.NotifyScoreDrop)
+	issueService := service.NewIssueService(pgStore, issueTrackers, credentialService, secretBox)
+	if n, err := issueService.SealStoredTokens(context.Background()); err != nil {
+		slog.Error("failed to encrypt stored tracker tokens", "error", err)
+	} else if n > 0 {
+		slog.Info("stored tracker tokens encrypted", "trackers", n)
+	}
+	reviewService := service.NewReviewService(pgStore, gitVCS, aiForStrategy("review"), notificationService, forgeClients, credentialService, cfg.ReviewPostComments)
+
+	// Embedding index tracking: queries and indexing use the active index's model,
+	// which can differ from OLLAMA_EMBED_MODEL until a migration switches over
+	// Embeddings are cached by sha256(model, text) so re-indexing skips unchanged chunks
+	cacheMetrics := &ai.CacheMetrics{}
+	embeddingService := service.NewEmbeddingService(vectorStore, func(model string) port.AIProvider {
+		provider := ai.NewOllamaProvider(
+			ai.OllamaEndpointConfig{
+				BaseURL: cfg.OllamaEmbedURL,
+				Model:   model,
+				Token:   cfg.OllamaEmbedToken,
+			},
+			ai.OllamaEndpointConfig{
+				BaseURL: cfg.OllamaChatURL,
+				Model:   cfg.OllamaChatModel,
+				Token:   cfg.OllamaChatToken,
+			},
+		)
+		if !cfg.EmbeddingCache {
+			return provider
+		}
+		return ai.NewCachingProvider(provider, model, pgStore, cacheMetrics)
+	})
+	if _, err := embeddingService.Init(context.Background(), cfg.OllamaEmbedModel); err != nil {
+		slog.Error("failed to load embedding index", "error", err)
+		os.Exit(1)
+	}
+
+	ragService := service.NewRAGService(ollamaAI, embeddingService, vectorStore, pgStore, chunkers, service.RerankConfig{
+		Reranker:   ai.NewLLMReranker(aiForStrategy("rerank")),
+		Enabled:    cfg.RerankEnabled,
+		Candidates: cfg.RerankCandidates,
+		TopN:       cfg.RerankTopN,
+	}, service.IndexPipelineConfig{
+		Workers:    cfg.IndexWorkers,
+		BatchSize:  cfg.IndexBatchSize,
+		BatchChars: cfg.IndexBatchChars,
+		WriteBatch: cfg.IndexWriteBatch,
+	})
+
+	// ── CLI: golden-set evaluation (`server eval set.yaml...`) ───────────
+	if len(os.Args) > 1 && os.Args[1] == "eval" {
+		evalService := service.NewEvalService(ragService, aiForStrategy("judge"), pgStore)
+		if err := runEval(context.Background(), os.Args[2:], cfg, evalService, vectorStore.ActiveIndex()); err != nil {
+			slog.Error("evaluation failed", "error", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// ── Background: prune embeddings of superseded snapshots ─────────────
+	go ragService.RunIndexGC(context.Background(), service.RetentionPolicy{
+		KeepSnapshots: cfg.IndexGCKeepSnapshots,
+		MinAge:        time.Duration(cfg.IndexGCMinAgeHours) * time.Hour,
+		Interval:      time.Duration(cfg.IndexGCInterval) * time.Minute,
+	})
+
+	// ── Background: evict idle clones and enforce clone quotas ───────────
+	go repoService.RunCloneJanitor(context.Background())
+
+	// ── Background: send and retry queued notifications ──────────────────
+	go notificationService.RunDispatcher(context.Background(), time.Duration(cfg.NotifyDispatchSecs)*time.Second)
+
+	// ── Background: sync the state of issues opened from findings ────────
+	go issueService.RunSync(context.Background(), time.Duration(cfg.IssueSyncMinutes)*time.Minute)
 
 	// ── Fiber App ────────────────────────────────────────────────────────
 	app := fiber.New(fiber.Config{
@@ -124,6 +289,21 @@ func main() {
 	// Audit middleware (logs all requests)
 	app.Use(middleware.AuditMiddleware(pgStore))
 
+	// ── Jobs: analyses start the same way from the API, schedules and webhooks ──
+	jobTracker := handler.NewJobTracker()
+	notificationHandler := handler.NewNotificationHandler(notificationService, pgStore)
+	jobTracker.OnFinish(notificationHandler.OnJobFinished)
+	repoHandler := handler.NewRepoHandler(repoService, pgStore, gitVCS, forgeProviders)
+	indexHandler := handler.NewIndexHandler(ragService, repoService, pgStore, gitVCS, jobTracker)
+	analysisHandler := handler.NewAnalysisHandler(analysisService, repoService, pgStore, jobTracker, ollamaAI, indexHandler)
+	startAnalysis := func(repoID string, strategies []string) (string, error) {
+		job, err := analysisHandler.StartAnalysis(repoID, strategies)
+		if err != nil {
+			return "", err
+		}
+		return job.JobID, nil
+	}
+
 	// ── Public Routes ────────────────────────────────────────────────────
 	authHandler := handler.NewAuthHandler(authService, cfg.FrontendURL)
 	authHandler.Register(app)
@@ -137,6 +317,16 @@ func main() {
 		})
 	})
 
+	// Forge webhooks: authenticated by the signature of each repo's own secret, so
+	// registered ahead of the JWT group
+	webhookService := service.NewWebhookService(pgStore, repoService, reviewService, startAnalysis, repoHandler.OnSync, cfg.WebhookPushStrategies)
+	webhookHandler := handler.NewWebhookHandler(port.WebhookParserRegistry{
+		"github": webhook.NewGitHubParser(),
+		"gitlab": webhook.NewGitLabParser(),
+		"gitea":  webhook.NewGiteaParser(),
+	}, webhookService)
+	webhookHandler.Register(app)
+
 	// ── Protected Routes ─────────────────────────────────────────────────
 	jwtMiddleware := middleware.JWTMiddleware(middleware.JWTConfig{
 		Secret:    cfg.JWTSecret,
@@ -146,17 +336,44 @@ func main() {
 
 	api := app.Group("/api/v1", jwtMiddleware)
 
-	jobTracker := handler.NewJobTracker()
-
-	repoHandler := handler.NewRepoHandler(repoService, pgStore, gitVCS)
 	repoHandler.Register(api)
+	go repoService.RunSyncer(context.Background(), time.Duration(cfg.RepoSyncCheckSecs)*time.Second, repoHandler.OnSync)
 
-	analysisHandler := handler.NewAnalysisHandler(analysisService, pgStore, jobTracker, ollamaAI, ragService)
+	indexHandler.Register(api)
 	analysisHandler.Register(api)
 
+	if sshKeyService != nil {
+		sshKeyHandler := handler.NewSSHKeyHandler(sshKeyService, pgStore)
+		sshKeyHandler.Register(api)
+	}
+
+	reviewHandler := handler.NewReviewHandler(reviewService, pgStore)
+	reviewHandler.Register(api)
+
+	issueHandler := handler.NewIssueHandler(issueService, pgStore)
+	issueHandler.Register(api)
+
+	scheduleService := service.NewScheduleService(pgStore, repoService, gitVCS, analysisService, startAnalysis, repoHandler.OnSync)
+	jobTracker.OnFinish(func(job handler.JobStatus) {
+		if job.Kind != handler.JobKindAnalysis {
+			return
+		}
+		runErr := job.Error
+		if job.Status != "complete" && runErr == "" {
+			runErr = "analysis " + job.Status
+		}
+		// Callbacks run on the job's goroutine: record the outcome off it
+		go scheduleService.FinishRun(context.Background(), job.ID, runErr)
+	})
+	scheduleHandler := handler.NewScheduleHandler(scheduleService, pgStore)
+	scheduleHandler.Register(api)
+	go scheduleService.Run(context.Background(), time.Duration(cfg.SchedulerCheckSecs)*time.Second)
+
 	jobsHandler := handler.NewJobsHandler(jobTracker)
 	jobsHandler.Register(api)
 
+	notificationHandler.Register(api)
+
 	reportsHandler := handler.NewReportsHandler(pgStore, vectorStore)
 	reportsHandler.Register(api)
 
@@ -166,6 +383,12 @@ func main() {
 	ragHandler := handler.NewRAGHandler(ragService)
 	ragHandler.Register(api)
 
+	searchHandler := handler.NewSearchHandler(ragService, pgStore)
+	searchHandler.Register(api)
+
+	adminHandler := handler.NewAdminHandler(embeddingService, pgStore, cacheMetrics)
+	adminHandler.Register(api)
+
 	auditHandler := handler.NewAuditHandler(pgStore)
 	auditHandler.Register(api)
 
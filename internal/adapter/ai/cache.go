@@ -0,0 +1,125 @@
+package ai
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"sync/atomic"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// CacheMetrics counts embedding cache lookups. One instance can be shared by
+// several CachingProviders to report totals across models.
+type CacheMetrics struct {
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+// CacheStats is a point-in-time copy of CacheMetrics.
+type CacheStats struct {
+	Hits    int64   `json:"hits"`
+	Misses  int64   `json:"misses"`
+	Errors  int64   `json:"errors"` // cache reads/writes that failed (the model was used instead)
+	HitRate float64 `json:"hit_rate"`
+}
+
+// Stats returns the current counters.
+func (m *CacheMetrics) Stats() CacheStats {
+	s := CacheStats{Hits: m.hits.Load(), Misses: m.misses.Load(), Errors: m.errors.Load()}
+	if total := s.Hits + s.Misses; total > 0 {
+		s.HitRate = float64(s.Hits) / float64(total)
+	}
+	return s
+}
+
+// CachingProvider decorates a port.AIProvider with a content-addressed embedding cache:
+// Embed and EmbedBatch only call the wrapped provider for texts not embedded before by
+// the same model. Chat calls pass through unchanged. Cache failures are logged and
+// fall back to the wrapped provider.
+type CachingProvider struct {
+	port.AIProvider
+	model   string // embedding model, part of every cache key
+	cache   port.EmbeddingCache
+	metrics *CacheMetrics
+}
+
+// NewCachingProvider wraps inner, whose embeddings come from model, with cache.
+func NewCachingProvider(inner port.AIProvider, model string, cache port.EmbeddingCache, metrics *CacheMetrics) *CachingProvider {
+	return &CachingProvider{AIProvider: inner, model: model, cache: cache, metrics: metrics}
+}
+
+// cacheKey is the hex sha256 of the model name and the text, NUL-separated.
+func (p *CachingProvider) cacheKey(text string) string {
+	h := sha256.New()
+	h.Write([]byte(p.model))
+	h.Write([]byte{0})
+	h.Write([]byte(text))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Embed returns the cached vector for text or embeds and caches it.
+func (p *CachingProvider) Embed(ctx context.Context, text string) ([]float32, error) {
+	vectors, err := p.EmbedBatch(ctx, []string{text})
+	if err != nil {
+		return nil, err
+	}
+	return vectors[0], nil
+}
+
+// EmbedBatch embeds only the texts missing from the cache (each distinct text once)
+// and returns vectors in input order.
+func (p *CachingProvider) EmbedBatch(ctx context.Context, texts []string) ([][]float32, error) {
+	keys := make([]string, len(texts))
+	for i, t := range texts {
+		keys[i] = p.cacheKey(t)
+	}
+
+	cached, err := p.cache.GetCachedEmbeddings(ctx, keys)
+	if err != nil {
+		p.metrics.errors.Add(1)
+		slog.Warn("embedding cache read failed", "model", p.model, "error", err)
+		cached = map[string][]float32{}
+	}
+
+	var missTexts, missKeys []string
+	pending := map[string]bool{}
+	for i, key := range keys {
+		if _, ok := cached[key]; ok || pending[key] {
+			continue
+		}
+		pending[key] = true
+		missKeys = append(missKeys, key)
+		missTexts = append(missTexts, texts[i])
+	}
+	p.metrics.hits.Add(int64(len(texts) - len(missTexts)))
+	p.metrics.misses.Add(int64(len(missTexts)))
+
+	if len(missTexts) > 0 {
+		vectors, err := p.AIProvider.EmbedBatch(ctx, missTexts)
+		if err != nil {
+			return nil, err
+		}
+		if len(vectors) != len(missTexts) {
+			return nil, fmt.Errorf("embed batch: got %d vectors for %d texts", len(vectors), len(missTexts))
+		}
+		fresh := make(map[string][]float32, len(vectors))
+		for i, vec := range vectors {
+			fresh[missKeys[i]] = vec
+			cached[missKeys[i]] = vec
+		}
+		if err := p.cache.PutCachedEmbeddings(ctx, p.model, fresh); err != nil {
+			p.metrics.errors.Add(1)
+			slog.Warn("embedding cache write failed", "model", p.model, "error", err)
+		}
+	}
+
+	out := make([][]float32, len(texts))
+	for i, key := range keys {
+		out[i] = cached[key]
+	}
+	return out, nil
+}
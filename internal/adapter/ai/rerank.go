@@ -0,0 +1,102 @@
+package ai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// rerankBatchSize is how many documents are judged in a single chat call.
+const rerankBatchSize = 10
+
+// rerankDocChars truncates each document shown to the judge.
+const rerankDocChars = 1500
+
+// thinkRe matches the <think>…</think> blocks emitted by reasoning models (qwen3, deepseek-r1).
+var thinkRe = regexp.MustCompile(`(?s)<think>.*?</think>`)
+
+// StripThinking removes the <think>…</think> blocks of reasoning models from a response.
+func StripThinking(response string) string {
+	return thinkRe.ReplaceAllString(response, "")
+}
+
+// LLMReranker implements port.Reranker by asking a chat model served by Ollama
+// to grade each document's relevance to the query (LLM-as-judge).
+// Any Ollama model can be used, including dedicated reranker models.
+type LLMReranker struct {
+	ai port.AIProvider
+}
+
+// NewLLMReranker creates a reranker that judges relevance with the given provider's chat model.
+func NewLLMReranker(ai port.AIProvider) *LLMReranker {
+	return &LLMReranker{ai: ai}
+}
+
+// Rerank scores documents in batches and returns one score in [0, 1] per document.
+func (r *LLMReranker) Rerank(ctx context.Context, query string, documents []string) ([]float64, error) {
+	scores := make([]float64, 0, len(documents))
+	for start := 0; start < len(documents); start += rerankBatchSize {
+		end := start + rerankBatchSize
+		if end > len(documents) {
+			end = len(documents)
+		}
+		batch, err := r.scoreBatch(ctx, query, documents[start:end])
+		if err != nil {
+			return nil, err
+		}
+		scores = append(scores, batch...)
+	}
+	return scores, nil
+}
+
+// scoreBatch asks the model for a JSON array of 0–10 grades, one per document.
+func (r *LLMReranker) scoreBatch(ctx context.Context, query string, documents []string) ([]float64, error) {
+	systemPrompt := `You are a search relevance judge for a code search engine.
+For each numbered document, grade how useful it is for answering the query, from 0 (irrelevant) to 10 (directly answers it).
+Respond with ONLY a JSON array of numbers, one per document, in document order. Example for 3 documents: [7, 0, 3]`
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Query: %s\n\n", query)
+	for i, doc := range documents {
+		if len(doc) > rerankDocChars {
+			doc = doc[:rerankDocChars] + "\n..."
+		}
+		fmt.Fprintf(&sb, "--- Document %d ---\n%s\n\n", i+1, doc)
+	}
+	fmt.Fprintf(&sb, "Return a JSON array of exactly %d grades.", len(documents))
+
+	response, err := r.ai.Chat(ctx, systemPrompt, sb.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("rerank: %w", err)
+	}
+
+	response = StripThinking(response)
+	start, end := strings.Index(response, "["), strings.LastIndex(response, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("rerank: no JSON array in response")
+	}
+
+	var grades []float64
+	if err := json.Unmarshal([]byte(response[start:end+1]), &grades); err != nil {
+		return nil, fmt.Errorf("rerank decode: %w", err)
+	}
+	if len(grades) != len(documents) {
+		return nil, fmt.Errorf("rerank: got %d grades for %d documents", len(grades), len(documents))
+	}
+
+	for i, g := range grades {
+		switch {
+		case g < 0:
+			grades[i] = 0
+		case g > 10:
+			grades[i] = 1
+		default:
+			grades[i] = g / 10
+		}
+	}
+	return grades, nil
+}
@@ -0,0 +1,22 @@
+package ai
+
+import "testing"
+
+func TestStripThinking(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"no reasoning", "no reasoning"},
+		{"<think>weigh it</think>{\"scores\":[1]}", "{\"scores\":[1]}"},
+		{"<think>\nline one\nline two\n</think>\nanswer", "\nanswer"},
+		{"<think>a</think>keep<think>b</think> this", "keep this"},
+		{"<think></think>empty", "empty"},
+		{"<think>unclosed reasoning", "<think>unclosed reasoning"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := StripThinking(tt.in); got != tt.want {
+			t.Errorf("StripThinking(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+const (
+	bitbucketAuthURL    = "https://bitbucket.org/site/oauth2/authorize"
+	bitbucketTokenURL   = "https://bitbucket.org/site/oauth2/access_token"
+	bitbucketProfileURL = "https://api.bitbucket.org/2.0/user"
+	bitbucketEmailsURL  = "https://api.bitbucket.org/2.0/user/emails"
+)
+
+// BitbucketProvider implements port.AuthProvider for Bitbucket Cloud OAuth. The callback
+// URL and scopes (account, email, repository) are set on the OAuth consumer.
+type BitbucketProvider struct {
+	clientID     string
+	clientSecret string
+	httpClient   *http.Client
+}
+
+// NewBitbucketProvider creates a new Bitbucket OAuth provider.
+func NewBitbucketProvider(clientID, clientSecret string) *BitbucketProvider {
+	return &BitbucketProvider{
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		httpClient:   &http.Client{},
+	}
+}
+
+// ProviderName returns "bitbucket".
+func (b *BitbucketProvider) ProviderName() string {
+	return "bitbucket"
+}
+
+// AuthURL returns the Bitbucket OAuth consent screen URL.
+func (b *BitbucketProvider) AuthURL(state string) string {
+	params := url.Values{
+		"client_id":     {b.clientID},
+		"response_type": {"code"},
+		"state":         {state},
+	}
+	return fmt.Sprintf("%s?%s", bitbucketAuthURL, params.Encode())
+}
+
+// ExchangeCode exchanges an authorization code for tokens. Bitbucket access tokens
+// expire after two hours.
+func (b *BitbucketProvider) ExchangeCode(ctx context.Context, code string) (*domain.TokenPair, error) {
+	tokens, err := exchangeCode(ctx, b.httpClient, bitbucketTokenURL, url.Values{"code": {code}}, b.clientID, b.clientSecret)
+	if err != nil {
+		return nil, fmt.Errorf("bitbucket: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetUserProfile fetches the Bitbucket user profile and primary email using an access token.
+func (b *BitbucketProvider) GetUserProfile(ctx context.Context, accessToken string) (*domain.User, error) {
+	var profile struct {
+		UUID        string `json:"uuid"`
+		Username    string `json:"username"`
+		DisplayName string `json:"display_name"`
+		Links       struct {
+			Avatar struct {
+				Href string `json:"href"`
+			} `json:"avatar"`
+		} `json:"links"`
+	}
+	if err := getJSON(ctx, b.httpClient, bitbucketProfileURL, accessToken, &profile); err != nil {
+		return nil, fmt.Errorf("bitbucket: %w", err)
+	}
+
+	var emails struct {
+		Values []struct {
+			Email       string `json:"email"`
+			IsPrimary   bool   `json:"is_primary"`
+			IsConfirmed bool   `json:"is_confirmed"`
+		} `json:"values"`
+	}
+	email := ""
+	if err := getJSON(ctx, b.httpClient, bitbucketEmailsURL, accessToken, &emails); err == nil {
+		for _, e := range emails.Values {
+			if e.IsPrimary && e.IsConfirmed {
+				email = e.Email
+			}
+		}
+	}
+
+	name := profile.DisplayName
+	if name == "" {
+		name = profile.Username
+	}
+
+	return &domain.User{
+		Email:      email,
+		Name:       name,
+		AvatarURL:  profile.Links.Avatar.Href,
+		Provider:   "bitbucket",
+		ProviderID: profile.UUID,
+	}, nil
+}
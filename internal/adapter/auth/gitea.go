@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GiteaProvider implements port.AuthProvider for Gitea (and Forgejo) OAuth.
+type GiteaProvider struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	httpClient   *http.Client
+}
+
+// NewGiteaProvider creates a new Gitea OAuth provider for the instance at baseURL.
+func NewGiteaProvider(baseURL, clientID, clientSecret, redirectURL string) *GiteaProvider {
+	return &GiteaProvider{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		httpClient:   &http.Client{},
+	}
+}
+
+// ProviderName returns "gitea".
+func (g *GiteaProvider) ProviderName() string {
+	return "gitea"
+}
+
+// AuthURL returns the Gitea OAuth consent screen URL. No scope is requested, which
+// Gitea grants as full access to the user's repositories.
+func (g *GiteaProvider) AuthURL(state string) string {
+	params := url.Values{
+		"client_id":     {g.clientID},
+		"redirect_uri":  {g.redirectURL},
+		"response_type": {"code"},
+		"state":         {state},
+	}
+	return fmt.Sprintf("%s/login/oauth/authorize?%s", g.baseURL, params.Encode())
+}
+
+// ExchangeCode exchanges an authorization code for tokens.
+func (g *GiteaProvider) ExchangeCode(ctx context.Context, code string) (*domain.TokenPair, error) {
+	tokens, err := exchangeCode(ctx, g.httpClient, g.baseURL+"/login/oauth/access_token", url.Values{
+		"client_id":     {g.clientID},
+		"client_secret": {g.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {g.redirectURL},
+	}, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("gitea: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetUserProfile fetches the Gitea user profile using an access token.
+func (g *GiteaProvider) GetUserProfile(ctx context.Context, accessToken string) (*domain.User, error) {
+	var profile struct {
+		ID        int    `json:"id"`
+		Login     string `json:"login"`
+		FullName  string `json:"full_name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, g.httpClient, g.baseURL+"/api/v1/user", accessToken, &profile); err != nil {
+		return nil, fmt.Errorf("gitea: %w", err)
+	}
+
+	name := profile.FullName
+	if name == "" {
+		name = profile.Login
+	}
+
+	return &domain.User{
+		Email:      profile.Email,
+		Name:       name,
+		AvatarURL:  profile.AvatarURL,
+		Provider:   "gitea",
+		ProviderID: fmt.Sprintf("%d", profile.ID),
+	}, nil
+}
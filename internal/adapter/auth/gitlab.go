@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GitLabProvider implements port.AuthProvider for GitLab OAuth, on gitlab.com or a
+// self-hosted instance.
+type GitLabProvider struct {
+	baseURL      string
+	clientID     string
+	clientSecret string
+	redirectURL  string
+	httpClient   *http.Client
+}
+
+// NewGitLabProvider creates a new GitLab OAuth provider for the instance at baseURL.
+func NewGitLabProvider(baseURL, clientID, clientSecret, redirectURL string) *GitLabProvider {
+	return &GitLabProvider{
+		baseURL:      strings.TrimRight(baseURL, "/"),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		redirectURL:  redirectURL,
+		httpClient:   &http.Client{},
+	}
+}
+
+// ProviderName returns "gitlab".
+func (g *GitLabProvider) ProviderName() string {
+	return "gitlab"
+}
+
+// AuthURL returns the GitLab OAuth consent screen URL. The api scope covers listing
+// and cloning projects as well as posting review comments and issues.
+func (g *GitLabProvider) AuthURL(state string) string {
+	params := url.Values{
+		"client_id":     {g.clientID},
+		"redirect_uri":  {g.redirectURL},
+		"response_type": {"code"},
+		"scope":         {"read_user api"},
+		"state":         {state},
+	}
+	return fmt.Sprintf("%s/oauth/authorize?%s", g.baseURL, params.Encode())
+}
+
+// ExchangeCode exchanges an authorization code for tokens.
+func (g *GitLabProvider) ExchangeCode(ctx context.Context, code string) (*domain.TokenPair, error) {
+	tokens, err := exchangeCode(ctx, g.httpClient, g.baseURL+"/oauth/token", url.Values{
+		"client_id":     {g.clientID},
+		"client_secret": {g.clientSecret},
+		"code":          {code},
+		"redirect_uri":  {g.redirectURL},
+	}, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+	return tokens, nil
+}
+
+// GetUserProfile fetches the GitLab user profile using an access token.
+func (g *GitLabProvider) GetUserProfile(ctx context.Context, accessToken string) (*domain.User, error) {
+	var profile struct {
+		ID        int    `json:"id"`
+		Username  string `json:"username"`
+		Name      string `json:"name"`
+		Email     string `json:"email"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := getJSON(ctx, g.httpClient, g.baseURL+"/api/v4/user", accessToken, &profile); err != nil {
+		return nil, fmt.Errorf("gitlab: %w", err)
+	}
+
+	name := profile.Name
+	if name == "" {
+		name = profile.Username
+	}
+
+	return &domain.User{
+		Email:      profile.Email,
+		Name:       name,
+		AvatarURL:  profile.AvatarURL,
+		Provider:   "gitlab",
+		ProviderID: fmt.Sprintf("%d", profile.ID),
+	}, nil
+}
@@ -0,0 +1,137 @@
+package chunker
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// GoChunker implements port.Chunker for Go using go/parser.
+// It emits one chunk per function, method, type and const/var block (doc comments included),
+// plus a header chunk for the package clause and imports.
+// Declarations longer than maxWords are split into line windows that keep their symbol metadata.
+type GoChunker struct {
+	maxWords int
+}
+
+// NewGoChunker creates a Go chunker that splits declarations larger than maxWords words.
+func NewGoChunker(maxWords int) *GoChunker {
+	return &GoChunker{maxWords: maxWords}
+}
+
+// Chunk parses a Go file and returns one chunk per top-level declaration.
+func (g *GoChunker) Chunk(filePath string, content string) ([]port.CodeChunk, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, filePath, content, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, fmt.Errorf("parse go file: %w", err)
+	}
+
+	lines := strings.Split(content, "\n")
+	var chunks []port.CodeChunk
+
+	add := func(from, to ast.Node, doc *ast.CommentGroup, template port.CodeChunk) {
+		start := fset.Position(from.Pos()).Line
+		if doc != nil {
+			start = fset.Position(doc.Pos()).Line
+		}
+		end := fset.Position(to.End()).Line
+		if start < 1 || end > len(lines) || start > end {
+			return
+		}
+		chunks = append(chunks, g.split(lines[start-1:end], start, template)...)
+	}
+
+	// Header: package clause and imports
+	header := port.CodeChunk{Symbol: file.Name.Name, Kind: domain.SymbolKindFile}
+	var lastImport ast.Node = file.Name
+	for _, imp := range file.Imports {
+		lastImport = imp
+	}
+	add(file.Name, lastImport, file.Doc, header)
+
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			c := port.CodeChunk{Symbol: d.Name.Name, Kind: domain.SymbolKindFunc}
+			if d.Recv != nil && len(d.Recv.List) > 0 {
+				c.Kind = domain.SymbolKindMethod
+				c.Parent = receiverName(d.Recv.List[0].Type)
+			}
+			add(d, d, d.Doc, c)
+
+		case *ast.GenDecl:
+			switch d.Tok {
+			case token.IMPORT:
+				// Already covered by the header chunk
+			case token.TYPE:
+				for _, spec := range d.Specs {
+					ts := spec.(*ast.TypeSpec)
+					c := port.CodeChunk{Symbol: ts.Name.Name, Kind: domain.SymbolKindType}
+					if d.Lparen.IsValid() {
+						add(ts, ts, ts.Doc, c)
+					} else {
+						add(d, d, d.Doc, c)
+					}
+				}
+			case token.CONST, token.VAR:
+				kind := domain.SymbolKindVar
+				if d.Tok == token.CONST {
+					kind = domain.SymbolKindConst
+				}
+				add(d, d, d.Doc, port.CodeChunk{Symbol: specNames(d.Specs), Kind: kind})
+			}
+		}
+	}
+
+	return chunks, nil
+}
+
+// split returns lines as one chunk, or several line windows if it exceeds maxWords.
+func (g *GoChunker) split(lines []string, firstLine int, template port.CodeChunk) []port.CodeChunk {
+	words := 0
+	for _, l := range lines {
+		words += len(strings.Fields(l))
+	}
+	if words <= g.maxWords {
+		c := template
+		c.Content = strings.Join(lines, "\n")
+		c.StartLine = firstLine
+		c.EndLine = firstLine + len(lines) - 1
+		return []port.CodeChunk{c}
+	}
+	return splitLines(lines, firstLine, g.maxWords, template)
+}
+
+// receiverName extracts the type name from a method receiver (e.g. *Store[T] → Store).
+func receiverName(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.StarExpr:
+		return receiverName(t.X)
+	case *ast.IndexExpr:
+		return receiverName(t.X)
+	case *ast.IndexListExpr:
+		return receiverName(t.X)
+	case *ast.Ident:
+		return t.Name
+	}
+	return ""
+}
+
+// specNames joins the names declared in a const/var block (e.g. "ErrA,ErrB").
+func specNames(specs []ast.Spec) string {
+	var names []string
+	for _, spec := range specs {
+		if vs, ok := spec.(*ast.ValueSpec); ok {
+			for _, n := range vs.Names {
+				names = append(names, n.Name)
+			}
+		}
+	}
+	return strings.Join(names, ",")
+}
@@ -0,0 +1,115 @@
+package chunker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+const goSource = `// Package store keeps things.
+package store
+
+import (
+	"errors"
+	"sync"
+)
+
+// Errors returned by Store.
+var (
+	ErrMissing = errors.New("missing")
+	ErrClosed  = errors.New("closed")
+)
+
+const limit = 10
+
+// Store holds items.
+type Store[T any] struct {
+	mu    sync.Mutex
+	items []T
+}
+
+type (
+	// Key names an item.
+	Key string
+	ID  int
+)
+
+// Get returns the item at i.
+func (s *Store[T]) Get(i int) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var zero T
+	if i >= len(s.items) {
+		return zero, ErrMissing
+	}
+	return s.items[i], nil
+}
+
+func helper() {}
+`
+
+func TestGoChunkerRanges(t *testing.T) {
+	chunks, err := NewGoChunker(200).Chunk("store.go", goSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []struct {
+		symbol, parent, kind string
+		start, end           int
+	}{
+		{"store", "", domain.SymbolKindFile, 1, 6}, // through the last import spec
+		{"ErrMissing,ErrClosed", "", domain.SymbolKindVar, 9, 13},
+		{"limit", "", domain.SymbolKindConst, 15, 15},
+		{"Store", "", domain.SymbolKindType, 17, 21},
+		{"Key", "", domain.SymbolKindType, 24, 25},
+		{"ID", "", domain.SymbolKindType, 26, 26},
+		{"Get", "Store", domain.SymbolKindMethod, 29, 38},
+		{"helper", "", domain.SymbolKindFunc, 40, 40},
+	}
+	if len(chunks) != len(want) {
+		t.Fatalf("got %d chunks, want %d: %+v", len(chunks), len(want), chunks)
+	}
+	lines := strings.Split(goSource, "\n")
+	for i, w := range want {
+		c := chunks[i]
+		if c.Symbol != w.symbol || c.Parent != w.parent || c.Kind != w.kind || c.StartLine != w.start || c.EndLine != w.end {
+			t.Errorf("chunk %d = %s/%s %s %d-%d, want %s/%s %s %d-%d", i,
+				c.Parent, c.Symbol, c.Kind, c.StartLine, c.EndLine, w.parent, w.symbol, w.kind, w.start, w.end)
+			continue
+		}
+		if want := strings.Join(lines[w.start-1:w.end], "\n"); c.Content != want {
+			t.Errorf("chunk %s content = %q, want %q", w.symbol, c.Content, want)
+		}
+	}
+}
+
+func TestGoChunkerSplitsLargeDeclarations(t *testing.T) {
+	chunks, err := NewGoChunker(12).Chunk("store.go", goSource)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var get []int
+	for _, c := range chunks {
+		if c.Symbol != "Get" {
+			continue
+		}
+		if c.Kind != domain.SymbolKindMethod || c.Parent != "Store" {
+			t.Errorf("window %d-%d lost its symbol metadata: %+v", c.StartLine, c.EndLine, c)
+		}
+		get = append(get, c.StartLine, c.EndLine)
+	}
+	if len(get) < 4 {
+		t.Fatalf("Get was not split: %v", get)
+	}
+	if get[0] != 29 || get[len(get)-1] != 38 {
+		t.Errorf("windows span %d-%d, want 29-38", get[0], get[len(get)-1])
+	}
+}
+
+func TestGoChunkerSyntaxError(t *testing.T) {
+	if _, err := NewGoChunker(200).Chunk("bad.go", "package x\nfunc {"); err == nil {
+		t.Error("Chunk accepted invalid Go")
+	}
+}
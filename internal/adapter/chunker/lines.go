@@ -0,0 +1,71 @@
+package chunker
+
+import (
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// overlapLines is how many trailing lines each window repeats at the start of the next one.
+const overlapLines = 3
+
+// LineChunker implements port.Chunker with overlapping windows of whole lines.
+// It is the fallback for languages without a structural chunker.
+type LineChunker struct {
+	maxWords int
+}
+
+// NewLineChunker creates a chunker that emits windows of roughly maxWords words.
+func NewLineChunker(maxWords int) *LineChunker {
+	return &LineChunker{maxWords: maxWords}
+}
+
+// Chunk splits content into overlapping line windows.
+func (l *LineChunker) Chunk(filePath string, content string) ([]port.CodeChunk, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, nil
+	}
+	template := port.CodeChunk{Kind: domain.SymbolKindBlock}
+	return splitLines(strings.Split(content, "\n"), 1, l.maxWords, template), nil
+}
+
+// splitLines cuts lines into overlapping windows of approximately maxWords words.
+// firstLine is the 1-based line number of lines[0]; every window copies template's
+// symbol metadata and gets its own line range.
+func splitLines(lines []string, firstLine, maxWords int, template port.CodeChunk) []port.CodeChunk {
+	var chunks []port.CodeChunk
+	start := 0 // index into lines of the current window's first line
+	currentLen := 0
+
+	emit := func(end int) {
+		c := template
+		c.Content = strings.Join(lines[start:end], "\n")
+		c.StartLine = firstLine + start
+		c.EndLine = firstLine + end - 1
+		chunks = append(chunks, c)
+	}
+
+	for i, line := range lines {
+		wordCount := len(strings.Fields(line))
+		if currentLen+wordCount > maxWords && i > start {
+			emit(i)
+			// Keep the last few lines for overlap
+			next := i - overlapLines
+			if next <= start {
+				next = start + 1
+			}
+			start = next
+			currentLen = 0
+			for _, l := range lines[start:i] {
+				currentLen += len(strings.Fields(l))
+			}
+		}
+		currentLen += wordCount
+	}
+
+	if start < len(lines) {
+		emit(len(lines))
+	}
+	return chunks
+}
@@ -0,0 +1,57 @@
+package chunker
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+func TestLineChunkerRanges(t *testing.T) {
+	// Ten lines of two words each
+	var lines []string
+	for i := 1; i <= 10; i++ {
+		lines = append(lines, "word word")
+	}
+	content := strings.Join(lines, "\n")
+
+	tests := []struct {
+		name     string
+		maxWords int
+		want     [][2]int
+	}{
+		{"single window", 100, [][2]int{{1, 10}}},
+		{"overlapping windows", 8, [][2]int{{1, 4}, {2, 5}, {3, 6}, {4, 7}, {5, 8}, {6, 9}, {7, 10}}},
+		{"wider windows", 12, [][2]int{{1, 6}, {4, 9}, {7, 10}}},
+		{"line larger than limit", 1, [][2]int{{1, 1}, {2, 2}, {3, 3}, {4, 4}, {5, 5}, {6, 6}, {7, 7}, {8, 8}, {9, 9}, {10, 10}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks, err := NewLineChunker(tt.maxWords).Chunk("notes.txt", content)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got [][2]int
+			for _, c := range chunks {
+				got = append(got, [2]int{c.StartLine, c.EndLine})
+				if c.Kind != domain.SymbolKindBlock {
+					t.Errorf("chunk %d-%d kind = %q", c.StartLine, c.EndLine, c.Kind)
+				}
+				if want := strings.Join(lines[c.StartLine-1:c.EndLine], "\n"); c.Content != want {
+					t.Errorf("chunk %d-%d content = %q, want %q", c.StartLine, c.EndLine, c.Content, want)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ranges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLineChunkerBlank(t *testing.T) {
+	chunks, err := NewLineChunker(10).Chunk("empty.txt", " \n\t\n")
+	if err != nil || chunks != nil {
+		t.Errorf("Chunk(blank) = %v, %v; want nil, nil", chunks, err)
+	}
+}
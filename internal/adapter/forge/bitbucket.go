@@ -0,0 +1,111 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// bitbucketAPI is the API of Bitbucket Cloud.
+const bitbucketAPI = "https://api.bitbucket.org/2.0"
+
+// BitbucketClient lists and clones Bitbucket Cloud repositories.
+type BitbucketClient struct {
+	api *apiClient
+}
+
+// NewBitbucketClient creates a Bitbucket Cloud client.
+func NewBitbucketClient() *BitbucketClient {
+	return &BitbucketClient{api: newAPIClient("", func(token string) (string, string) {
+		return "Authorization", "Bearer " + token
+	})}
+}
+
+type bitbucketRepo struct {
+	UUID        string `json:"uuid"`
+	Name        string `json:"name"`
+	FullName    string `json:"full_name"`
+	Description string `json:"description"`
+	IsPrivate   bool   `json:"is_private"`
+	Language    string `json:"language"`
+	UpdatedOn   string `json:"updated_on"`
+	MainBranch  *struct {
+		Name string `json:"name"`
+	} `json:"mainbranch"`
+	Links struct {
+		HTML struct {
+			Href string `json:"href"`
+		} `json:"html"`
+		Clone []struct {
+			Name string `json:"name"` // https, ssh
+			Href string `json:"href"`
+		} `json:"clone"`
+	} `json:"links"`
+}
+
+// defaultBranch returns the repository's main branch, if it has one.
+func (r *bitbucketRepo) defaultBranch() string {
+	if r.MainBranch == nil {
+		return ""
+	}
+	return r.MainBranch.Name
+}
+
+// Matches reports whether a repository is hosted on bitbucket.org.
+func (b *BitbucketClient) Matches(repoURL string) bool {
+	return sameHost("https://bitbucket.org", repoURL)
+}
+
+// ListRepos lists the repositories the token's user is a member of.
+func (b *BitbucketClient) ListRepos(ctx context.Context, token string, page, perPage int) ([]domain.ForgeRepo, error) {
+	var resp struct {
+		Values []bitbucketRepo `json:"values"`
+	}
+	endpoint := fmt.Sprintf("%s/repositories?role=member&sort=-updated_on&pagelen=%d&page=%d", bitbucketAPI, min(perPage, 100), page)
+	if err := b.api.do(ctx, http.MethodGet, endpoint, token, nil, &resp); err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.ForgeRepo, len(resp.Values))
+	for i, r := range resp.Values {
+		clone := ""
+		for _, l := range r.Links.Clone {
+			if l.Name == "https" {
+				// Bitbucket puts the user name in clone links; credentials are added when cloning
+				clone, _ = cloneURL(l.Href)
+			}
+		}
+		out[i] = domain.ForgeRepo{
+			ID: r.UUID, Forge: "bitbucket", Name: r.Name, FullName: r.FullName, Description: r.Description,
+			HTMLURL: r.Links.HTML.Href, CloneURL: clone, DefaultBranch: r.defaultBranch(), Private: r.IsPrivate,
+			Language: r.Language, UpdatedAt: r.UpdatedOn,
+		}
+	}
+	return out, nil
+}
+
+// CloneURL returns a repository URL without credentials.
+func (b *BitbucketClient) CloneURL(repoURL string) (string, error) {
+	return cloneURL(repoURL)
+}
+
+// TokenAuth authenticates HTTPS clones as x-token-auth, which Bitbucket expects for OAuth tokens.
+func (b *BitbucketClient) TokenAuth(repoURL, token string) *domain.GitAuth {
+	return tokenAuth(repoURL, "x-token-auth", token)
+}
+
+// DefaultBranch returns the main branch of a Bitbucket repository.
+func (b *BitbucketClient) DefaultBranch(ctx context.Context, token, repoURL string) (string, error) {
+	_, path, err := repoLocation(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var repo bitbucketRepo
+	if err := b.api.do(ctx, http.MethodGet, bitbucketAPI+"/repositories/"+strings.ToLower(path), token, nil, &repo); err != nil {
+		return "", err
+	}
+	return repo.defaultBranch(), nil
+}
@@ -0,0 +1,235 @@
+// Package forge talks to the REST APIs of Git forges (GitHub, GitLab, Gitea) and issue
+// trackers (Jira).
+package forge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/publicnet"
+)
+
+const (
+	perPage  = 100 // items requested per page of a list
+	maxPages = 20  // pages read at most from a list
+)
+
+// apiClient sends authenticated JSON requests to a forge API. Hosts come from repo URLs
+// and tracker configs, which users supply, so requests only reach public addresses; the
+// instance the server is configured with is the exception, as it may be internal.
+type apiClient struct {
+	http         *http.Client // public addresses only
+	instance     *http.Client
+	instanceHost string                              // host[:port] of the configured instance, if any
+	authHeader   func(token string) (string, string) // header name and value carrying the token
+}
+
+func newAPIClient(instanceURL string, authHeader func(token string) (string, string)) *apiClient {
+	c := &apiClient{
+		http:       publicnet.NewClient(30 * time.Second),
+		instance:   &http.Client{Timeout: 30 * time.Second},
+		authHeader: authHeader,
+	}
+	if u, err := url.Parse(instanceURL); err == nil {
+		c.instanceHost = strings.ToLower(u.Host)
+	}
+	return c
+}
+
+// clientFor returns the HTTP client allowed to reach endpoint.
+func (c *apiClient) clientFor(endpoint string) *http.Client {
+	if u, err := url.Parse(endpoint); err == nil && c.instanceHost != "" && strings.ToLower(u.Host) == c.instanceHost {
+		return c.instance
+	}
+	return c.http
+}
+
+// do sends a request with in (if any) as the JSON body and decodes the response into
+// out (if any). Any non-2xx response is an error carrying the status code only: the
+// response body of a user-supplied host is never reported.
+func (c *apiClient) do(ctx context.Context, method, endpoint, token string, in, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "CodeLens")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if token != "" {
+		req.Header.Set(c.authHeader(token))
+	}
+
+	resp, err := c.clientFor(endpoint).Do(req)
+	if err != nil {
+		if errors.Is(err, publicnet.ErrPrivate) {
+			return fmt.Errorf("%s %s: %w", method, redactURL(endpoint), publicnet.ErrPrivate) // without the resolved address
+		}
+		return fmt.Errorf("%s %s: %w", method, redactURL(endpoint), err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s: status %d", method, redactURL(endpoint), resp.StatusCode)
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
+
+// getPages reads a paginated list, following "page" until a short page. sizeParam is
+// the name of the page size parameter.
+func getPages[T any](ctx context.Context, c *apiClient, endpoint, sizeParam, token string) ([]T, error) {
+	sep := "?"
+	if strings.Contains(endpoint, "?") {
+		sep = "&"
+	}
+
+	var all []T
+	for page := 1; page <= maxPages; page++ {
+		var items []T
+		pageURL := endpoint + sep + sizeParam + "=" + strconv.Itoa(perPage) + "&page=" + strconv.Itoa(page)
+		if err := c.do(ctx, http.MethodGet, pageURL, token, nil, &items); err != nil {
+			return nil, err
+		}
+		all = append(all, items...)
+		if len(items) < perPage {
+			break
+		}
+	}
+	return all, nil
+}
+
+// repoLocation splits the URL of a repository into the base URL of its forge
+// ("https://host") and its path ("owner/name", or "group/subgroup/name"). HTTPS, SSH and
+// scp-like URLs are accepted; SSH remotes are assumed to be served over HTTPS.
+func repoLocation(raw string) (string, string, error) {
+	s := strings.TrimSpace(raw)
+	scheme, ssh := "https", false
+	if before, rest, ok := strings.Cut(s, "://"); ok {
+		switch before {
+		case "http":
+			scheme = "http"
+		case "ssh", "git+ssh":
+			ssh = true
+		}
+		s = rest
+	} else if colon := strings.Index(s, ":"); colon > 0 && !strings.Contains(s[:colon], "/") {
+		s, ssh = s[:colon]+"/"+s[colon+1:], true // scp-like: git@host:owner/name
+	}
+
+	host, path, ok := strings.Cut(s, "/")
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	if ssh {
+		host, _, _ = strings.Cut(host, ":") // an SSH port, not the web one
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if !ok || host == "" || !strings.Contains(path, "/") {
+		return "", "", fmt.Errorf("unsupported repository URL %q", redactURL(raw))
+	}
+	return scheme + "://" + host, path, nil
+}
+
+// IssueRepoURL returns the URL of the repository issues are opened in: the config's
+// project (on its base URL, or the repo's host) when set, the repo itself otherwise. It
+// also decides which host a repo owner's forge token may be sent to.
+func IssueRepoURL(cfg *domain.IssueTrackerConfig, repoURL string) (string, error) {
+	if cfg.Project == "" {
+		return repoURL, nil
+	}
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	if base == "" {
+		repoBase, _, err := repoLocation(repoURL)
+		if err != nil {
+			return "", err
+		}
+		base = repoBase
+	}
+	return base + "/" + strings.Trim(cfg.Project, "/"), nil
+}
+
+// validateBaseURL checks that an optional base URL is an absolute http(s) URL.
+func validateBaseURL(raw string) error {
+	if raw == "" {
+		return nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("base_url must be an http(s) URL")
+	}
+	return nil
+}
+
+// sameHost reports whether a repository URL is hosted on the forge at baseURL.
+func sameHost(baseURL, repoURL string) bool {
+	repoBase, _, err := repoLocation(repoURL)
+	if err != nil {
+		return false
+	}
+	a, errA := url.Parse(baseURL)
+	b, errB := url.Parse(repoBase)
+	return errA == nil && errB == nil && a.Hostname() != "" && strings.EqualFold(a.Hostname(), b.Hostname())
+}
+
+// cloneURL implements ForgeProvider.CloneURL.
+func cloneURL(repoURL string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return repoURL, nil // SSH and scp-like URLs authenticate with keys
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("unsupported repository URL %q", redactURL(repoURL))
+	}
+	u.User = nil
+	return u.String(), nil
+}
+
+// tokenAuth implements ForgeProvider.TokenAuth for forges that accept a token as the
+// password of username over HTTPS. The token is only sent to the repository's host.
+func tokenAuth(repoURL, username, token string) *domain.GitAuth {
+	u, err := url.Parse(strings.TrimSpace(repoURL))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || token == "" {
+		return nil
+	}
+	return &domain.GitAuth{TokenURL: u.Scheme + "://" + u.Host + "/", TokenUser: username, Token: token}
+}
+
+// redactURL drops any credentials from a URL so that it can be logged.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// commentID formats a numeric comment ID as the opaque ID of a domain.ForgeComment.
+func commentID(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
@@ -0,0 +1,207 @@
+package forge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/publicnet"
+)
+
+// fixtureServer serves recorded API responses from testdata, keyed by request path,
+// and checks that every request carries wantAuth.
+func fixtureServer(t *testing.T, wantAuth string, routes map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != wantAuth {
+			t.Errorf("%s %s: Authorization = %q, want %q", r.Method, r.URL.Path, got, wantAuth)
+		}
+		fixture, ok := routes[r.URL.Path]
+		if !ok || r.Method != http.MethodGet {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL)
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHubCommentsAndCurrentUser(t *testing.T) {
+	srv := fixtureServer(t, "Bearer gh-token", map[string]string{
+		"/api/v3/user": "github_user.json",
+		"/api/v3/repos/acme/app/issues/7/comments": "github_issue_comments.json",
+		"/api/v3/repos/acme/app/pulls/7/comments":  "github_review_comments.json",
+	})
+	g := NewGitHubClient()
+	g.api.http = srv.Client() // stands in for a public GitHub Enterprise host
+	pr := &domain.ForgePullRequest{RepoURL: srv.URL + "/acme/app.git", Number: 7}
+
+	login, err := g.CurrentUser(context.Background(), "gh-token", pr.RepoURL)
+	if err != nil || login != "codelens-bot" {
+		t.Fatalf("CurrentUser = %q, %v", login, err)
+	}
+
+	comments, err := g.Comments(context.Background(), "gh-token", pr)
+	if err != nil {
+		t.Fatalf("Comments: %v", err)
+	}
+	want := []domain.ForgeComment{
+		{ID: "1820001", Author: "codelens-bot", Body: "<!-- codelens:summary -->\n## CodeLens review\n\nReviewed `abc1234` against `def5678`: no findings.\n"},
+		{ID: "1820002", Author: "octocat", Body: "Looks good to me."},
+		{ID: "9140001", Author: "codelens-bot", Path: "main.go", Line: 10, Body: "<!-- codelens:finding:039f39127465d154 -->\n🟠 **high: SQL injection**\n"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments =\n%+v\nwant\n%+v", comments, want)
+	}
+}
+
+func TestGitLabCommentsAndCurrentUser(t *testing.T) {
+	srv := fixtureServer(t, "Bearer gl-token", map[string]string{
+		"/api/v4/user": "gitlab_user.json",
+		"/api/v4/projects/group/sub/app/merge_requests/7/discussions": "gitlab_discussions.json",
+	})
+	g := NewGitLabClient(srv.URL)
+	pr := &domain.ForgePullRequest{RepoURL: srv.URL + "/group/sub/app", Number: 7}
+
+	login, err := g.CurrentUser(context.Background(), "gl-token", pr.RepoURL)
+	if err != nil || login != "codelens-bot" {
+		t.Fatalf("CurrentUser = %q, %v", login, err)
+	}
+
+	comments, err := g.Comments(context.Background(), "gl-token", pr)
+	if err != nil {
+		t.Fatalf("Comments: %v", err)
+	}
+	// System notes are skipped and only the first note of a discussion is its comment
+	want := []domain.ForgeComment{
+		{ID: "87805b7c09016a7058e91bdbe7b29d1f284a39e6/1128", Author: "codelens-bot", Body: "<!-- codelens:summary -->\n## CodeLens review\n"},
+		{ID: "e2b2f3c5d1a5a3f7b8c9d0e1f2a3b4c5d6e7f8a9/1130", Author: "codelens-bot", Path: "main.go", Line: 10, Body: "<!-- codelens:finding:039f39127465d154 -->\n🟠 **high: SQL injection**\n"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments =\n%+v\nwant\n%+v", comments, want)
+	}
+}
+
+func TestGiteaCommentsAndCurrentUser(t *testing.T) {
+	srv := fixtureServer(t, "token gt-token", map[string]string{
+		"/api/v1/user": "gitea_user.json",
+		"/api/v1/repos/acme/app/issues/7/comments":           "gitea_issue_comments.json",
+		"/api/v1/repos/acme/app/pulls/7/reviews":             "gitea_reviews.json",
+		"/api/v1/repos/acme/app/pulls/7/reviews/41/comments": "gitea_review_comments.json",
+	})
+	g := NewGiteaClient(srv.URL)
+	pr := &domain.ForgePullRequest{RepoURL: srv.URL + "/acme/app", Number: 7}
+
+	login, err := g.CurrentUser(context.Background(), "gt-token", pr.RepoURL)
+	if err != nil || login != "codelens-bot" {
+		t.Fatalf("CurrentUser = %q, %v", login, err)
+	}
+
+	// Review 42 has no comments and is not fetched
+	comments, err := g.Comments(context.Background(), "gt-token", pr)
+	if err != nil {
+		t.Fatalf("Comments: %v", err)
+	}
+	want := []domain.ForgeComment{
+		{ID: "301", Author: "codelens-bot", Body: "<!-- codelens:summary -->\n## CodeLens review\n"},
+		{ID: "302", Author: "codelens-bot", Path: "main.go", Line: 10, Body: "<!-- codelens:finding:039f39127465d154 -->\n🟠 **high: SQL injection**\n"},
+	}
+	if !reflect.DeepEqual(comments, want) {
+		t.Errorf("Comments =\n%+v\nwant\n%+v", comments, want)
+	}
+}
+
+func TestMatches(t *testing.T) {
+	gh, gl := NewGitHubClient(), NewGitLabClient("https://gitlab.example.com/")
+	tests := []struct {
+		forge interface{ Matches(string) bool }
+		url   string
+		want  bool
+	}{
+		{gh, "https://github.com/acme/app", true},
+		{gh, "git@github.com:acme/app.git", true},
+		{gh, "https://github.com.evil.example/acme/app", false},
+		{gh, "https://ghe.example.com/acme/app", false},
+		{gl, "https://gitlab.example.com/group/app", true},
+		{gl, "https://gitlab.com/group/app", false},
+	}
+	for _, tt := range tests {
+		if got := tt.forge.Matches(tt.url); got != tt.want {
+			t.Errorf("%T.Matches(%q) = %v, want %v", tt.forge, tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestAPIClientRefusesPrivateHosts(t *testing.T) {
+	reached := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+	defer srv.Close()
+
+	// The GitLab instance is configured elsewhere, so the loopback repo host is user-supplied
+	clients := map[string]interface {
+		CurrentUser(context.Context, string, string) (string, error)
+	}{
+		"github": NewGitHubClient(),
+		"gitlab": NewGitLabClient("https://gitlab.example.com"),
+		"gitea":  NewGiteaClient("https://gitea.example.com"),
+	}
+	for name, c := range clients {
+		if _, err := c.CurrentUser(context.Background(), "token", srv.URL+"/acme/app"); !errors.Is(err, publicnet.ErrPrivate) {
+			t.Errorf("%s: CurrentUser = %v, want ErrPrivate", name, err)
+		}
+	}
+
+	_, err := NewJiraClient().CreateIssue(context.Background(), &domain.IssueTrackerConfig{BaseURL: srv.URL, Project: "APP", Token: "t"}, "", &domain.IssueDraft{Title: "x"})
+	if !errors.Is(err, publicnet.ErrPrivate) {
+		t.Errorf("jira: CreateIssue = %v, want ErrPrivate", err)
+	}
+	if reached {
+		t.Error("a request reached the loopback server")
+	}
+}
+
+func TestAPIErrorOmitsResponseBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte("instance-metadata-secret"))
+	}))
+	defer srv.Close()
+
+	g := NewGitLabClient(srv.URL)
+	_, err := g.CurrentUser(context.Background(), "token", srv.URL+"/acme/app")
+	if err == nil || !strings.Contains(err.Error(), "status 403") || strings.Contains(err.Error(), "secret") {
+		t.Errorf("CurrentUser = %v, want the status code only", err)
+	}
+}
+
+func TestIssueRepoURL(t *testing.T) {
+	repoURL := "https://github.com/acme/app"
+	tests := []struct {
+		cfg  domain.IssueTrackerConfig
+		want string
+	}{
+		{domain.IssueTrackerConfig{}, repoURL},
+		{domain.IssueTrackerConfig{Project: "acme/issues"}, "https://github.com/acme/issues"}, // on the repo's host
+		{domain.IssueTrackerConfig{BaseURL: "https://ghe.example.com"}, repoURL},
+		{domain.IssueTrackerConfig{BaseURL: "https://ghe.example.com/", Project: "/acme/issues"}, "https://ghe.example.com/acme/issues"},
+	}
+	for _, tt := range tests {
+		if got, err := IssueRepoURL(&tt.cfg, repoURL); err != nil || got != tt.want {
+			t.Errorf("IssueRepoURL(%+v) = %q, %v, want %q", tt.cfg, got, err, tt.want)
+		}
+	}
+}
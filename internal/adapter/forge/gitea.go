@@ -0,0 +1,191 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GiteaClient comments on Gitea (and Forgejo) pull requests. Inline comments are posted
+// as single-comment reviews; both kinds are edited through the issue comments API.
+type GiteaClient struct {
+	api     *apiClient
+	baseURL string // instance repositories are listed from
+}
+
+// NewGiteaClient creates a Gitea client. Repositories are listed from the instance at
+// baseURL; comments go to the host of each repo.
+func NewGiteaClient(baseURL string) *GiteaClient {
+	return &GiteaClient{
+		api: newAPIClient(baseURL, func(token string) (string, string) {
+			return "Authorization", "token " + token
+		}),
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+type giteaUser struct {
+	Login string `json:"login"`
+}
+
+type giteaComment struct {
+	ID       int64     `json:"id"`
+	User     giteaUser `json:"user"`
+	Body     string    `json:"body"`
+	Path     string    `json:"path"`
+	Position int       `json:"position"`
+}
+
+type giteaReview struct {
+	ID            int64 `json:"id"`
+	CommentsCount int   `json:"comments_count"`
+}
+
+// repoAPI returns the API URL of a repository.
+func (g *GiteaClient) repoAPI(repoURL string) (string, error) {
+	base, path, err := repoLocation(repoURL)
+	if err != nil {
+		return "", err
+	}
+	return base + "/api/v1/repos/" + path, nil
+}
+
+// Comments returns the issue comments of a pull request and the comments of its reviews.
+func (g *GiteaClient) Comments(ctx context.Context, token string, pr *domain.ForgePullRequest) ([]domain.ForgeComment, error) {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+	issueComments, err := getPages[giteaComment](ctx, g.api, fmt.Sprintf("%s/issues/%d/comments", repoAPI, pr.Number), "limit", token)
+	if err != nil {
+		return nil, err
+	}
+	comments := make([]domain.ForgeComment, 0, len(issueComments))
+	for _, c := range issueComments {
+		comments = append(comments, domain.ForgeComment{ID: commentID(c.ID), Author: c.User.Login, Body: c.Body})
+	}
+
+	reviews, err := getPages[giteaReview](ctx, g.api, fmt.Sprintf("%s/pulls/%d/reviews", repoAPI, pr.Number), "limit", token)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range reviews {
+		if r.CommentsCount == 0 {
+			continue
+		}
+		var reviewComments []giteaComment
+		if err := g.api.do(ctx, http.MethodGet, fmt.Sprintf("%s/pulls/%d/reviews/%d/comments", repoAPI, pr.Number, r.ID), token, nil, &reviewComments); err != nil {
+			return nil, err
+		}
+		for _, c := range reviewComments {
+			comments = append(comments, domain.ForgeComment{ID: commentID(c.ID), Author: c.User.Login, Path: c.Path, Line: c.Position, Body: c.Body})
+		}
+	}
+	return comments, nil
+}
+
+// CreateComment adds an issue comment, or a review with one comment on the head revision.
+func (g *GiteaClient) CreateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+	if c.Path == "" {
+		return g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", repoAPI, pr.Number), token,
+			map[string]any{"body": c.Body}, nil)
+	}
+	return g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/pulls/%d/reviews", repoAPI, pr.Number), token, map[string]any{
+		"commit_id": pr.HeadSHA,
+		"event":     "COMMENT",
+		"comments": []map[string]any{
+			{"path": c.Path, "body": c.Body, "new_position": c.Line},
+		},
+	}, nil)
+}
+
+// UpdateComment edits a comment.
+func (g *GiteaClient) UpdateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+	return g.api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/issues/comments/%s", repoAPI, c.ID), token,
+		map[string]any{"body": c.Body}, nil)
+}
+
+// CurrentUser returns the login of the token's user on the repository's instance.
+func (g *GiteaClient) CurrentUser(ctx context.Context, token, repoURL string) (string, error) {
+	base, _, err := repoLocation(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var user giteaUser
+	if err := g.api.do(ctx, http.MethodGet, base+"/api/v1/user", token, nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+type giteaRepo struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+	Language      string `json:"language"`
+	Stars         int    `json:"stars_count"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// Matches reports whether a repository is hosted on the configured instance.
+func (g *GiteaClient) Matches(repoURL string) bool {
+	return sameHost(g.baseURL, repoURL)
+}
+
+// ListRepos lists the repositories the token's user owns or collaborates on.
+func (g *GiteaClient) ListRepos(ctx context.Context, token string, page, perPage int) ([]domain.ForgeRepo, error) {
+	var repos []giteaRepo
+	endpoint := fmt.Sprintf("%s/api/v1/user/repos?limit=%d&page=%d", g.baseURL, perPage, page)
+	if err := g.api.do(ctx, http.MethodGet, endpoint, token, nil, &repos); err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.ForgeRepo, len(repos))
+	for i, r := range repos {
+		out[i] = domain.ForgeRepo{
+			ID: commentID(r.ID), Forge: "gitea", Name: r.Name, FullName: r.FullName, Description: r.Description,
+			HTMLURL: r.HTMLURL, CloneURL: r.CloneURL, DefaultBranch: r.DefaultBranch, Private: r.Private,
+			Language: r.Language, Stars: r.Stars, UpdatedAt: r.UpdatedAt,
+		}
+	}
+	return out, nil
+}
+
+// CloneURL returns a repository URL without credentials.
+func (g *GiteaClient) CloneURL(repoURL string) (string, error) {
+	return cloneURL(repoURL)
+}
+
+// TokenAuth authenticates HTTPS clones as oauth2; Gitea accepts any user name with a token.
+func (g *GiteaClient) TokenAuth(repoURL, token string) *domain.GitAuth {
+	return tokenAuth(repoURL, "oauth2", token)
+}
+
+// DefaultBranch returns the default branch of a Gitea repository.
+func (g *GiteaClient) DefaultBranch(ctx context.Context, token, repoURL string) (string, error) {
+	repoAPI, err := g.repoAPI(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var repo giteaRepo
+	if err := g.api.do(ctx, http.MethodGet, repoAPI, token, nil, &repo); err != nil {
+		return "", err
+	}
+	return repo.DefaultBranch, nil
+}
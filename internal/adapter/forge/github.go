@@ -0,0 +1,244 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GitHubClient comments on GitHub pull requests and opens GitHub issues. Discussion
+// comments are issue comments and inline comments are pull request review comments.
+type GitHubClient struct {
+	api *apiClient
+}
+
+// NewGitHubClient creates a GitHub client. GitHub Enterprise hosts are reached at /api/v3.
+func NewGitHubClient() *GitHubClient {
+	return &GitHubClient{api: newAPIClient("", func(token string) (string, string) {
+		return "Authorization", "Bearer " + token
+	})}
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubComment struct {
+	ID   int64      `json:"id"`
+	User githubUser `json:"user"`
+	Body string     `json:"body"`
+	Path string     `json:"path"`
+	Line int        `json:"line"`
+}
+
+// apiRoot returns the API root of the host of a repository, and the repository's path.
+func (g *GitHubClient) apiRoot(repoURL string) (string, string, error) {
+	base, path, err := repoLocation(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	if strings.EqualFold(base, "https://github.com") {
+		return githubAPI, path, nil
+	}
+	return base + "/api/v3", path, nil
+}
+
+// repoAPI returns the API URL of a repository.
+func (g *GitHubClient) repoAPI(repoURL string) (string, error) {
+	root, path, err := g.apiRoot(repoURL)
+	if err != nil {
+		return "", err
+	}
+	return root + "/repos/" + path, nil
+}
+
+// Comments returns the issue comments and review comments of a pull request.
+func (g *GitHubClient) Comments(ctx context.Context, token string, pr *domain.ForgePullRequest) ([]domain.ForgeComment, error) {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return nil, err
+	}
+	issueComments, err := getPages[githubComment](ctx, g.api, fmt.Sprintf("%s/issues/%d/comments", repoAPI, pr.Number), "per_page", token)
+	if err != nil {
+		return nil, err
+	}
+	reviewComments, err := getPages[githubComment](ctx, g.api, fmt.Sprintf("%s/pulls/%d/comments", repoAPI, pr.Number), "per_page", token)
+	if err != nil {
+		return nil, err
+	}
+
+	comments := make([]domain.ForgeComment, 0, len(issueComments)+len(reviewComments))
+	for _, c := range issueComments {
+		comments = append(comments, domain.ForgeComment{ID: commentID(c.ID), Author: c.User.Login, Body: c.Body})
+	}
+	for _, c := range reviewComments {
+		comments = append(comments, domain.ForgeComment{ID: commentID(c.ID), Author: c.User.Login, Path: c.Path, Line: c.Line, Body: c.Body})
+	}
+	return comments, nil
+}
+
+// CreateComment adds an issue comment, or a review comment on the head revision.
+func (g *GitHubClient) CreateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+	if c.Path == "" {
+		return g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/issues/%d/comments", repoAPI, pr.Number), token,
+			map[string]any{"body": c.Body}, nil)
+	}
+	return g.api.do(ctx, http.MethodPost, fmt.Sprintf("%s/pulls/%d/comments", repoAPI, pr.Number), token, map[string]any{
+		"body":      c.Body,
+		"commit_id": pr.HeadSHA,
+		"path":      c.Path,
+		"line":      c.Line,
+		"side":      "RIGHT",
+	}, nil)
+}
+
+// UpdateComment edits an issue comment or a review comment.
+func (g *GitHubClient) UpdateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	repoAPI, err := g.repoAPI(pr.RepoURL)
+	if err != nil {
+		return err
+	}
+	kind := "issues"
+	if c.Path != "" {
+		kind = "pulls"
+	}
+	return g.api.do(ctx, http.MethodPatch, fmt.Sprintf("%s/%s/comments/%s", repoAPI, kind, c.ID), token,
+		map[string]any{"body": c.Body}, nil)
+}
+
+// CurrentUser returns the login of the token's user.
+func (g *GitHubClient) CurrentUser(ctx context.Context, token, repoURL string) (string, error) {
+	root, _, err := g.apiRoot(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var user githubUser
+	if err := g.api.do(ctx, http.MethodGet, root+"/user", token, nil, &user); err != nil {
+		return "", err
+	}
+	return user.Login, nil
+}
+
+type githubIssue struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	State   string `json:"state"`
+}
+
+// Validate checks a GitHub tracker config.
+func (g *GitHubClient) Validate(cfg *domain.IssueTrackerConfig) error {
+	return validateBaseURL(cfg.BaseURL)
+}
+
+// CreateIssue opens a GitHub issue. Labels that do not exist yet are created.
+func (g *GitHubClient) CreateIssue(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL string, draft *domain.IssueDraft) (*domain.IssueLink, error) {
+	target, err := IssueRepoURL(cfg, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	repoAPI, err := g.repoAPI(target)
+	if err != nil {
+		return nil, err
+	}
+	var issue githubIssue
+	if err := g.api.do(ctx, http.MethodPost, repoAPI+"/issues", cfg.Token, map[string]any{
+		"title":  draft.Title,
+		"body":   draft.Body,
+		"labels": draft.Labels,
+	}, &issue); err != nil {
+		return nil, err
+	}
+	return &domain.IssueLink{Tracker: domain.IssueTrackerGitHub, Key: strconv.Itoa(issue.Number), URL: issue.HTMLURL, State: domain.IssueStateOpen}, nil
+}
+
+// IssueState returns the state of a GitHub issue.
+func (g *GitHubClient) IssueState(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL, key string) (string, error) {
+	target, err := IssueRepoURL(cfg, repoURL)
+	if err != nil {
+		return "", err
+	}
+	repoAPI, err := g.repoAPI(target)
+	if err != nil {
+		return "", err
+	}
+	var issue githubIssue
+	if err := g.api.do(ctx, http.MethodGet, repoAPI+"/issues/"+key, cfg.Token, nil, &issue); err != nil {
+		return "", err
+	}
+	if issue.State == "closed" {
+		return domain.IssueStateClosed, nil
+	}
+	return domain.IssueStateOpen, nil
+}
+
+// githubAPI is the API of github.com, the only host repositories are listed from.
+const githubAPI = "https://api.github.com"
+
+type githubRepo struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"`
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+	Language      string `json:"language"`
+	Stars         int    `json:"stargazers_count"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+// Matches reports whether a repository is hosted on github.com.
+func (g *GitHubClient) Matches(repoURL string) bool {
+	return sameHost("https://github.com", repoURL)
+}
+
+// ListRepos lists the repositories of the token's user, including those of their organizations.
+func (g *GitHubClient) ListRepos(ctx context.Context, token string, page, perPage int) ([]domain.ForgeRepo, error) {
+	var repos []githubRepo
+	endpoint := fmt.Sprintf("%s/user/repos?visibility=all&sort=updated&per_page=%d&page=%d", githubAPI, perPage, page)
+	if err := g.api.do(ctx, http.MethodGet, endpoint, token, nil, &repos); err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.ForgeRepo, len(repos))
+	for i, r := range repos {
+		out[i] = domain.ForgeRepo{
+			ID: commentID(r.ID), Forge: "github", Name: r.Name, FullName: r.FullName, Description: r.Description,
+			HTMLURL: r.HTMLURL, CloneURL: r.CloneURL, DefaultBranch: r.DefaultBranch, Private: r.Private,
+			Language: r.Language, Stars: r.Stars, UpdatedAt: r.UpdatedAt,
+		}
+	}
+	return out, nil
+}
+
+// CloneURL returns a repository URL without credentials.
+func (g *GitHubClient) CloneURL(repoURL string) (string, error) {
+	return cloneURL(repoURL)
+}
+
+// TokenAuth authenticates HTTPS clones as x-access-token, which works for OAuth and app tokens.
+func (g *GitHubClient) TokenAuth(repoURL, token string) *domain.GitAuth {
+	return tokenAuth(repoURL, "x-access-token", token)
+}
+
+// DefaultBranch returns the default branch of a GitHub repository.
+func (g *GitHubClient) DefaultBranch(ctx context.Context, token, repoURL string) (string, error) {
+	repoAPI, err := g.repoAPI(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var repo githubRepo
+	if err := g.api.do(ctx, http.MethodGet, repoAPI, token, nil, &repo); err != nil {
+		return "", err
+	}
+	return repo.DefaultBranch, nil
+}
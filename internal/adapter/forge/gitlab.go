@@ -0,0 +1,265 @@
+package forge
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GitLabClient comments on GitLab merge requests and opens GitLab issues. Every comment
+// is the first note of a discussion; inline comments are discussions positioned on the
+// merge request's diff.
+type GitLabClient struct {
+	api     *apiClient
+	baseURL string // instance repositories are listed from
+}
+
+// NewGitLabClient creates a GitLab client. Repositories are listed from the instance at
+// baseURL (gitlab.com or self-hosted); comments and issues go to the host of each repo.
+func NewGitLabClient(baseURL string) *GitLabClient {
+	return &GitLabClient{
+		api: newAPIClient(baseURL, func(token string) (string, string) {
+			return "Authorization", "Bearer " + token
+		}),
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}
+}
+
+type gitlabUser struct {
+	Username string `json:"username"`
+}
+
+type gitlabDiscussion struct {
+	ID    string `json:"id"`
+	Notes []struct {
+		ID       int64      `json:"id"`
+		Author   gitlabUser `json:"author"`
+		Body     string     `json:"body"`
+		System   bool       `json:"system"`
+		Position *struct {
+			NewPath string `json:"new_path"`
+			NewLine int    `json:"new_line"`
+		} `json:"position"`
+	} `json:"notes"`
+}
+
+// projectAPI returns the API URL of a project.
+func (g *GitLabClient) projectAPI(repoURL string) (string, error) {
+	base, path, err := repoLocation(repoURL)
+	if err != nil {
+		return "", err
+	}
+	return base + "/api/v4/projects/" + url.PathEscape(path), nil
+}
+
+// mergeRequestAPI returns the API URL of the merge request.
+func (g *GitLabClient) mergeRequestAPI(pr *domain.ForgePullRequest) (string, error) {
+	projectAPI, err := g.projectAPI(pr.RepoURL)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/merge_requests/%d", projectAPI, pr.Number), nil
+}
+
+// Comments returns the first note of every discussion on a merge request. IDs have the
+// form "discussionID/noteID".
+func (g *GitLabClient) Comments(ctx context.Context, token string, pr *domain.ForgePullRequest) ([]domain.ForgeComment, error) {
+	mrAPI, err := g.mergeRequestAPI(pr)
+	if err != nil {
+		return nil, err
+	}
+	discussions, err := getPages[gitlabDiscussion](ctx, g.api, mrAPI+"/discussions", "per_page", token)
+	if err != nil {
+		return nil, err
+	}
+
+	var comments []domain.ForgeComment
+	for _, d := range discussions {
+		if len(d.Notes) == 0 || d.Notes[0].System {
+			continue
+		}
+		note := d.Notes[0]
+		c := domain.ForgeComment{ID: d.ID + "/" + commentID(note.ID), Author: note.Author.Username, Body: note.Body}
+		if note.Position != nil {
+			c.Path, c.Line = note.Position.NewPath, note.Position.NewLine
+		}
+		comments = append(comments, c)
+	}
+	return comments, nil
+}
+
+// CreateComment starts a discussion, positioned on the merge request's current diff when
+// the comment is inline.
+func (g *GitLabClient) CreateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	mrAPI, err := g.mergeRequestAPI(pr)
+	if err != nil {
+		return err
+	}
+	body := map[string]any{"body": c.Body}
+	if c.Path != "" {
+		// Positions must name the diff GitLab computed, not just the commits we diffed
+		var mr struct {
+			DiffRefs struct {
+				BaseSHA  string `json:"base_sha"`
+				StartSHA string `json:"start_sha"`
+				HeadSHA  string `json:"head_sha"`
+			} `json:"diff_refs"`
+		}
+		if err := g.api.do(ctx, http.MethodGet, mrAPI, token, nil, &mr); err != nil {
+			return err
+		}
+		body["position"] = map[string]any{
+			"position_type": "text",
+			"base_sha":      mr.DiffRefs.BaseSHA,
+			"start_sha":     mr.DiffRefs.StartSHA,
+			"head_sha":      mr.DiffRefs.HeadSHA,
+			"new_path":      c.Path,
+			"new_line":      c.Line,
+		}
+	}
+	return g.api.do(ctx, http.MethodPost, mrAPI+"/discussions", token, body, nil)
+}
+
+// UpdateComment edits the note of a discussion.
+func (g *GitLabClient) UpdateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error {
+	mrAPI, err := g.mergeRequestAPI(pr)
+	if err != nil {
+		return err
+	}
+	discussion, note, ok := strings.Cut(c.ID, "/")
+	if !ok {
+		return fmt.Errorf("invalid GitLab comment ID %q", c.ID)
+	}
+	return g.api.do(ctx, http.MethodPut, fmt.Sprintf("%s/discussions/%s/notes/%s", mrAPI, discussion, note), token,
+		map[string]any{"body": c.Body}, nil)
+}
+
+// CurrentUser returns the username of the token's user on the repository's instance.
+func (g *GitLabClient) CurrentUser(ctx context.Context, token, repoURL string) (string, error) {
+	base, _, err := repoLocation(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var user gitlabUser
+	if err := g.api.do(ctx, http.MethodGet, base+"/api/v4/user", token, nil, &user); err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+type gitlabIssue struct {
+	IID    int    `json:"iid"`
+	WebURL string `json:"web_url"`
+	State  string `json:"state"` // opened, closed
+}
+
+// Validate checks a GitLab tracker config.
+func (g *GitLabClient) Validate(cfg *domain.IssueTrackerConfig) error {
+	return validateBaseURL(cfg.BaseURL)
+}
+
+// CreateIssue opens a GitLab issue. Labels that do not exist yet are created.
+func (g *GitLabClient) CreateIssue(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL string, draft *domain.IssueDraft) (*domain.IssueLink, error) {
+	target, err := IssueRepoURL(cfg, repoURL)
+	if err != nil {
+		return nil, err
+	}
+	projectAPI, err := g.projectAPI(target)
+	if err != nil {
+		return nil, err
+	}
+	var issue gitlabIssue
+	if err := g.api.do(ctx, http.MethodPost, projectAPI+"/issues", cfg.Token, map[string]any{
+		"title":       draft.Title,
+		"description": draft.Body,
+		"labels":      strings.Join(draft.Labels, ","),
+	}, &issue); err != nil {
+		return nil, err
+	}
+	return &domain.IssueLink{Tracker: domain.IssueTrackerGitLab, Key: strconv.Itoa(issue.IID), URL: issue.WebURL, State: domain.IssueStateOpen}, nil
+}
+
+// IssueState returns the state of a GitLab issue.
+func (g *GitLabClient) IssueState(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL, key string) (string, error) {
+	target, err := IssueRepoURL(cfg, repoURL)
+	if err != nil {
+		return "", err
+	}
+	projectAPI, err := g.projectAPI(target)
+	if err != nil {
+		return "", err
+	}
+	var issue gitlabIssue
+	if err := g.api.do(ctx, http.MethodGet, projectAPI+"/issues/"+key, cfg.Token, nil, &issue); err != nil {
+		return "", err
+	}
+	if issue.State == "closed" {
+		return domain.IssueStateClosed, nil
+	}
+	return domain.IssueStateOpen, nil
+}
+
+type gitlabProject struct {
+	ID                int64  `json:"id"`
+	Name              string `json:"name"`
+	PathWithNamespace string `json:"path_with_namespace"`
+	Description       string `json:"description"`
+	WebURL            string `json:"web_url"`
+	HTTPURLToRepo     string `json:"http_url_to_repo"`
+	DefaultBranch     string `json:"default_branch"`
+	Visibility        string `json:"visibility"` // private, internal, public
+	StarCount         int    `json:"star_count"`
+	LastActivityAt    string `json:"last_activity_at"`
+}
+
+// Matches reports whether a repository is hosted on the configured instance.
+func (g *GitLabClient) Matches(repoURL string) bool {
+	return sameHost(g.baseURL, repoURL)
+}
+
+// ListRepos lists the projects the token's user is a member of.
+func (g *GitLabClient) ListRepos(ctx context.Context, token string, page, perPage int) ([]domain.ForgeRepo, error) {
+	var projects []gitlabProject
+	endpoint := fmt.Sprintf("%s/api/v4/projects?membership=true&order_by=last_activity_at&per_page=%d&page=%d", g.baseURL, perPage, page)
+	if err := g.api.do(ctx, http.MethodGet, endpoint, token, nil, &projects); err != nil {
+		return nil, err
+	}
+
+	out := make([]domain.ForgeRepo, len(projects))
+	for i, p := range projects {
+		out[i] = domain.ForgeRepo{
+			ID: commentID(p.ID), Forge: "gitlab", Name: p.Name, FullName: p.PathWithNamespace, Description: p.Description,
+			HTMLURL: p.WebURL, CloneURL: p.HTTPURLToRepo, DefaultBranch: p.DefaultBranch, Private: p.Visibility != "public",
+			Stars: p.StarCount, UpdatedAt: p.LastActivityAt,
+		}
+	}
+	return out, nil
+}
+
+// CloneURL returns a repository URL without credentials.
+func (g *GitLabClient) CloneURL(repoURL string) (string, error) {
+	return cloneURL(repoURL)
+}
+
+// TokenAuth authenticates HTTPS clones as oauth2, which GitLab expects for OAuth tokens.
+func (g *GitLabClient) TokenAuth(repoURL, token string) *domain.GitAuth {
+	return tokenAuth(repoURL, "oauth2", token)
+}
+
+// DefaultBranch returns the default branch of a GitLab project.
+func (g *GitLabClient) DefaultBranch(ctx context.Context, token, repoURL string) (string, error) {
+	projectAPI, err := g.projectAPI(repoURL)
+	if err != nil {
+		return "", err
+	}
+	var project gitlabProject
+	if err := g.api.do(ctx, http.MethodGet, projectAPI, token, nil, &project); err != nil {
+		return "", err
+	}
+	return project.DefaultBranch, nil
+}
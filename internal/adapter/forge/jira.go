@@ -0,0 +1,104 @@
+package forge
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// defaultJiraIssueType is used when the tracker config does not name one.
+const defaultJiraIssueType = "Bug"
+
+// JiraClient opens issues through the Jira REST API (v2), which Jira Cloud, Jira
+// Server/Data Center and compatible trackers serve.
+type JiraClient struct {
+	api *apiClient
+}
+
+// NewJiraClient creates a Jira client. Configs with a username authenticate with Basic
+// auth (Jira Cloud: account email and API token), others with a bearer token (Jira
+// Server personal access token).
+func NewJiraClient() *JiraClient {
+	return &JiraClient{api: newAPIClient("", func(credentials string) (string, string) {
+		if _, _, basic := strings.Cut(credentials, ":"); basic {
+			return "Authorization", "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+		}
+		return "Authorization", "Bearer " + credentials
+	})}
+}
+
+// credentials returns the token passed to the auth header func.
+func (j *JiraClient) credentials(cfg *domain.IssueTrackerConfig) string {
+	if cfg.Username != "" {
+		return cfg.Username + ":" + cfg.Token
+	}
+	return cfg.Token
+}
+
+// Validate checks that a Jira config names the site, the project and a token: Jira has
+// no access to the repo owner's forge token.
+func (j *JiraClient) Validate(cfg *domain.IssueTrackerConfig) error {
+	if cfg.BaseURL == "" || cfg.Project == "" {
+		return fmt.Errorf("jira needs base_url and project (the project key)")
+	}
+	if cfg.Token == "" {
+		return fmt.Errorf("jira needs a token")
+	}
+	return validateBaseURL(cfg.BaseURL)
+}
+
+// CreateIssue creates a Jira issue in the configured project. The repoURL is not used.
+func (j *JiraClient) CreateIssue(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL string, draft *domain.IssueDraft) (*domain.IssueLink, error) {
+	issueType := cfg.IssueType
+	if issueType == "" {
+		issueType = defaultJiraIssueType
+	}
+	// Jira labels cannot contain spaces
+	labels := make([]string, len(draft.Labels))
+	for i, l := range draft.Labels {
+		labels[i] = strings.ReplaceAll(l, " ", "-")
+	}
+
+	base := strings.TrimRight(cfg.BaseURL, "/")
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := j.api.do(ctx, http.MethodPost, base+"/rest/api/2/issue", j.credentials(cfg), map[string]any{
+		"fields": map[string]any{
+			"project":     map[string]string{"key": cfg.Project},
+			"summary":     draft.Title,
+			"description": draft.Body,
+			"issuetype":   map[string]string{"name": issueType},
+			"labels":      labels,
+		},
+	}, &created); err != nil {
+		return nil, err
+	}
+	return &domain.IssueLink{Tracker: domain.IssueTrackerJira, Key: created.Key, URL: base + "/browse/" + created.Key, State: domain.IssueStateOpen}, nil
+}
+
+// IssueState maps a Jira issue's status category to open or closed: only "done" closes.
+func (j *JiraClient) IssueState(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL, key string) (string, error) {
+	var issue struct {
+		Fields struct {
+			Status struct {
+				StatusCategory struct {
+					Key string `json:"key"`
+				} `json:"statusCategory"`
+			} `json:"status"`
+		} `json:"fields"`
+	}
+	endpoint := strings.TrimRight(cfg.BaseURL, "/") + "/rest/api/2/issue/" + url.PathEscape(key) + "?fields=status"
+	if err := j.api.do(ctx, http.MethodGet, endpoint, j.credentials(cfg), nil, &issue); err != nil {
+		return "", err
+	}
+	if issue.Fields.Status.StatusCategory.Key == "done" {
+		return domain.IssueStateClosed, nil
+	}
+	return domain.IssueStateOpen, nil
+}
@@ -0,0 +1,57 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/publicnet"
+)
+
+// SlackNotifier posts events to Slack-compatible incoming webhooks. The attachment
+// format is understood by Slack, Mattermost and Rocket.Chat alike.
+type SlackNotifier struct {
+	client *http.Client
+}
+
+// NewSlackNotifier creates a Slack-compatible incoming webhook notifier.
+func NewSlackNotifier() *SlackNotifier {
+	return &SlackNotifier{client: publicnet.NewClient(15 * time.Second)}
+}
+
+// Validate checks that target is a public http(s) URL.
+func (n *SlackNotifier) Validate(target string) error {
+	return publicnet.ValidateURL(target)
+}
+
+// eventColors maps event types to attachment side-bar colors.
+var eventColors = map[string]string{
+	domain.EventJobComplete:     "#2eb67d",
+	domain.EventJobFailed:       "#e01e5a",
+	domain.EventScoreRegression: "#ecb22e",
+	domain.EventCriticalFinding: "#e01e5a",
+}
+
+// Send posts the event as a message attachment.
+func (n *SlackNotifier) Send(ctx context.Context, sub *domain.NotificationSubscription, evt *domain.NotificationEvent) error {
+	title := fmt.Sprintf("[%s] %s", evt.RepoName, evt.Title)
+	body, err := json.Marshal(map[string]any{
+		"text": title,
+		"attachments": []map[string]any{{
+			"fallback":   title + ": " + evt.Message,
+			"color":      eventColors[evt.Type],
+			"title":      evt.Title,
+			"title_link": evt.URL,
+			"text":       evt.Message,
+			"footer":     "CodeLens · " + evt.Type,
+			"ts":         evt.OccurredAt.Unix(),
+		}},
+	})
+	if err != nil {
+		return fmt.Errorf("encode message: %w", err)
+	}
+	return postJSON(ctx, n.client, sub.Target, body, nil)
+}
@@ -0,0 +1,93 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// SMTPConfig holds the outgoing mail server settings.
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string // empty = no authentication
+	Password string
+	From     string
+}
+
+// SMTPNotifier emails events as plain text. STARTTLS is used when the server offers it.
+type SMTPNotifier struct {
+	cfg SMTPConfig
+}
+
+// NewSMTPNotifier creates an email notifier.
+func NewSMTPNotifier(cfg SMTPConfig) *SMTPNotifier {
+	return &SMTPNotifier{cfg: cfg}
+}
+
+// Validate checks that target is a single email address.
+func (n *SMTPNotifier) Validate(target string) error {
+	addr, err := mail.ParseAddress(target)
+	if err != nil || addr.Address != strings.TrimSpace(target) {
+		return fmt.Errorf("target must be an email address")
+	}
+	return nil
+}
+
+// Send emails the event to the subscription's address.
+func (n *SMTPNotifier) Send(ctx context.Context, sub *domain.NotificationSubscription, evt *domain.NotificationEvent) error {
+	if err := n.Validate(sub.Target); err != nil {
+		return err
+	}
+
+	subject := fmt.Sprintf("[CodeLens] %s: %s", evt.RepoName, evt.Title)
+	var body strings.Builder
+	body.WriteString(evt.Message + "\r\n")
+	if evt.URL != "" {
+		body.WriteString("\r\n" + evt.URL + "\r\n")
+	}
+	fmt.Fprintf(&body, "\r\n--\r\nCodeLens · %s · %s\r\n", evt.Type, evt.OccurredAt.UTC().Format(time.RFC1123))
+
+	msg := strings.Join([]string{
+		"From: " + n.cfg.From,
+		"To: " + sub.Target,
+		"Subject: " + mime.QEncoding.Encode("utf-8", stripNewlines(subject)),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=UTF-8",
+		"Content-Transfer-Encoding: 8bit",
+		"",
+		strings.ReplaceAll(strings.ReplaceAll(body.String(), "\r\n", "\n"), "\n", "\r\n"),
+	}, "\r\n")
+
+	var auth smtp.Auth
+	if n.cfg.Username != "" {
+		auth = smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+	}
+	addr := net.JoinHostPort(n.cfg.Host, fmt.Sprint(n.cfg.Port))
+
+	// net/smtp has no context support; bound the send by the context's deadline instead
+	done := make(chan error, 1)
+	go func() { done <- smtp.SendMail(addr, auth, n.cfg.From, []string{sub.Target}, []byte(msg)) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// stripNewlines keeps user-controlled text from injecting mail headers.
+func stripNewlines(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
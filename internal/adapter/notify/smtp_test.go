@@ -0,0 +1,168 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"mime"
+	"net"
+	"net/mail"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// smtpMessage is a mail received by the SMTP stand-in.
+type smtpMessage struct {
+	from string
+	to   []string
+	data string
+}
+
+// startSMTP runs a minimal SMTP server on a loopback port that accepts every mail
+// (no TLS, no auth) and sends what it receives on the returned channel.
+func startSMTP(t *testing.T) (host string, port int, received <-chan smtpMessage) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	ch := make(chan smtpMessage, 1)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go serveSMTP(conn, ch)
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ch
+}
+
+func serveSMTP(conn net.Conn, ch chan<- smtpMessage) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { _, _ = conn.Write([]byte(line + "\r\n")) }
+
+	var msg smtpMessage
+	reply("220 stand-in ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimRight(line, "\r\n")
+		switch verb := strings.ToUpper(strings.SplitN(cmd, " ", 2)[0]); verb {
+		case "EHLO", "HELO":
+			reply("250 stand-in")
+		case "MAIL":
+			msg.from = strings.Trim(strings.TrimPrefix(cmd[len("MAIL "):], "FROM:"), "<>")
+			reply("250 OK")
+		case "RCPT":
+			msg.to = append(msg.to, strings.Trim(strings.TrimPrefix(cmd[len("RCPT "):], "TO:"), "<>"))
+			reply("250 OK")
+		case "DATA":
+			reply("354 end with .")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(strings.TrimPrefix(l, "."))
+			}
+			msg.data = data.String()
+			reply("250 queued")
+			ch <- msg
+		case "QUIT":
+			reply("221 bye")
+			return
+		default:
+			reply("250 OK")
+		}
+	}
+}
+
+func TestSMTPSendDeliversMail(t *testing.T) {
+	host, port, received := startSMTP(t)
+	n := NewSMTPNotifier(SMTPConfig{Host: host, Port: port, From: "codelens@example.com"})
+
+	evt := testEvent()
+	evt.Title = "Analysis complete\r\nBcc: victim@example.com"
+	sub := &domain.NotificationSubscription{Target: "dev@example.com"}
+	if err := n.Send(context.Background(), sub, evt); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got smtpMessage
+	select {
+	case got = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+	if got.from != "codelens@example.com" || len(got.to) != 1 || got.to[0] != "dev@example.com" {
+		t.Errorf("envelope from %q to %q", got.from, got.to)
+	}
+
+	m, err := mail.ReadMessage(strings.NewReader(got.data))
+	if err != nil {
+		t.Fatalf("parse mail: %v", err)
+	}
+	if m.Header.Get("Bcc") != "" {
+		t.Error("title injected a Bcc header")
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(m.Header.Get("Subject"))
+	if err != nil {
+		t.Fatalf("decode subject: %v", err)
+	}
+	if subject != "[CodeLens] codelens: Analysis complete  Bcc: victim@example.com" {
+		t.Errorf("subject = %q", subject)
+	}
+	if m.Header.Get("To") != "dev@example.com" || m.Header.Get("Content-Type") != "text/plain; charset=UTF-8" {
+		t.Errorf("headers = %v", m.Header)
+	}
+	body := make([]byte, 1024)
+	nb, _ := m.Body.Read(body)
+	if text := string(body[:nb]); !strings.Contains(text, "3 strategies ran\r\n") || !strings.Contains(text, evt.URL) {
+		t.Errorf("body = %q", text)
+	}
+}
+
+func TestSMTPSendReportsServerDown(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	n := NewSMTPNotifier(SMTPConfig{Host: "127.0.0.1", Port: port, From: "codelens@example.com"})
+	err = n.Send(context.Background(), &domain.NotificationSubscription{Target: "dev@example.com"}, testEvent())
+	if err == nil || !strings.Contains(err.Error(), strconv.Itoa(port)) {
+		t.Errorf("Send to a closed port = %v", err)
+	}
+}
+
+func TestSMTPValidate(t *testing.T) {
+	n := NewSMTPNotifier(SMTPConfig{})
+	for _, target := range []string{"dev@example.com"} {
+		if err := n.Validate(target); err != nil {
+			t.Errorf("Validate(%q) = %v", target, err)
+		}
+	}
+	for _, target := range []string{"", "not an address", "Dev <dev@example.com>", "a@example.com, b@example.com", "dev@example.com\r\nBcc: x@example.com"} {
+		if err := n.Validate(target); err == nil {
+			t.Errorf("Validate(%q) accepted", target)
+		}
+	}
+}
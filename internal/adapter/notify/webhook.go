@@ -0,0 +1,78 @@
+// Package notify delivers notification events to webhooks, chat incoming webhooks and email.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/publicnet"
+)
+
+// WebhookNotifier POSTs events as JSON to a URL. When the subscription has a secret,
+// the body is signed in X-CodeLens-Signature ("sha256=" + hex HMAC-SHA256).
+type WebhookNotifier struct {
+	client *http.Client
+}
+
+// NewWebhookNotifier creates a generic JSON webhook notifier.
+func NewWebhookNotifier() *WebhookNotifier {
+	return &WebhookNotifier{client: publicnet.NewClient(15 * time.Second)}
+}
+
+// Validate checks that target is a public http(s) URL.
+func (n *WebhookNotifier) Validate(target string) error {
+	return publicnet.ValidateURL(target)
+}
+
+// Send posts the event.
+func (n *WebhookNotifier) Send(ctx context.Context, sub *domain.NotificationSubscription, evt *domain.NotificationEvent) error {
+	body, err := json.Marshal(evt)
+	if err != nil {
+		return fmt.Errorf("encode event: %w", err)
+	}
+	headers := map[string]string{"X-CodeLens-Event": evt.Type}
+	if sub.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(sub.Secret))
+		mac.Write(body)
+		headers["X-CodeLens-Signature"] = "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	}
+	return postJSON(ctx, n.client, sub.Target, body, headers)
+}
+
+// postJSON posts body and treats any non-2xx response as an error. Errors carry the
+// status code only: the response body of a user-supplied target is never reported.
+func postJSON(ctx context.Context, client *http.Client, target string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "CodeLens-Notifier")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if errors.Is(err, publicnet.ErrPrivate) {
+			return fmt.Errorf("post: %w", publicnet.ErrPrivate) // without the resolved address
+		}
+		return fmt.Errorf("post: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // lets the connection be reused
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("post: status %d", resp.StatusCode)
+	}
+	return nil
+}
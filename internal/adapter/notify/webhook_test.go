@@ -0,0 +1,184 @@
+package notify
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/publicnet"
+)
+
+func testEvent() *domain.NotificationEvent {
+	return &domain.NotificationEvent{
+		Type:       domain.EventJobComplete,
+		RepoID:     "repo-1",
+		RepoName:   "codelens",
+		Title:      "Analysis complete",
+		Message:    "3 strategies ran",
+		URL:        "https://codelens.example/repos/repo-1",
+		OccurredAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+}
+
+// recorder is an httptest handler that keeps the last request and answers status.
+type recorder struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+func (r *recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.header = req.Header.Clone()
+	r.body, _ = io.ReadAll(req.Body)
+	w.WriteHeader(r.status)
+	_, _ = w.Write([]byte("internal details: db=10.0.0.5 token=s3cr3t"))
+}
+
+func TestWebhookSendSignsBody(t *testing.T) {
+	rec := &recorder{status: http.StatusNoContent}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	n := &WebhookNotifier{client: srv.Client()}
+	sub := &domain.NotificationSubscription{Target: srv.URL, Secret: "hook-secret"}
+	if err := n.Send(context.Background(), sub, testEvent()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var got domain.NotificationEvent
+	if err := json.Unmarshal(rec.body, &got); err != nil {
+		t.Fatalf("body is not an event: %v", err)
+	}
+	if got.Type != domain.EventJobComplete || got.RepoName != "codelens" || got.Title != "Analysis complete" {
+		t.Errorf("event = %+v", got)
+	}
+	if h := rec.header.Get("X-CodeLens-Event"); h != domain.EventJobComplete {
+		t.Errorf("X-CodeLens-Event = %q", h)
+	}
+	mac := hmac.New(sha256.New, []byte("hook-secret"))
+	mac.Write(rec.body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); rec.header.Get("X-CodeLens-Signature") != want {
+		t.Errorf("X-CodeLens-Signature = %q, want %q", rec.header.Get("X-CodeLens-Signature"), want)
+	}
+}
+
+func TestWebhookSendWithoutSecretIsUnsigned(t *testing.T) {
+	rec := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	n := &WebhookNotifier{client: srv.Client()}
+	if err := n.Send(context.Background(), &domain.NotificationSubscription{Target: srv.URL}, testEvent()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if h := rec.header.Get("X-CodeLens-Signature"); h != "" {
+		t.Errorf("unsigned subscription sent X-CodeLens-Signature %q", h)
+	}
+}
+
+func TestPostErrorReportsStatusOnly(t *testing.T) {
+	srv := httptest.NewServer(&recorder{status: http.StatusInternalServerError})
+	defer srv.Close()
+
+	n := &WebhookNotifier{client: srv.Client()}
+	err := n.Send(context.Background(), &domain.NotificationSubscription{Target: srv.URL}, testEvent())
+	if err == nil {
+		t.Fatal("Send succeeded on a 500")
+	}
+	if !strings.Contains(err.Error(), "status 500") {
+		t.Errorf("error %q lacks the status code", err)
+	}
+	if strings.Contains(err.Error(), "internal details") || strings.Contains(err.Error(), "s3cr3t") {
+		t.Errorf("error %q leaks the response body", err)
+	}
+}
+
+func TestSlackSendPostsAttachment(t *testing.T) {
+	rec := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	n := &SlackNotifier{client: srv.Client()}
+	if err := n.Send(context.Background(), &domain.NotificationSubscription{Target: srv.URL}, testEvent()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	var msg struct {
+		Text        string `json:"text"`
+		Attachments []struct {
+			Color     string `json:"color"`
+			Title     string `json:"title"`
+			TitleLink string `json:"title_link"`
+			Text      string `json:"text"`
+			Ts        int64  `json:"ts"`
+		} `json:"attachments"`
+	}
+	if err := json.Unmarshal(rec.body, &msg); err != nil {
+		t.Fatalf("body is not a message: %v", err)
+	}
+	if msg.Text != "[codelens] Analysis complete" || len(msg.Attachments) != 1 {
+		t.Fatalf("message = %+v", msg)
+	}
+	a := msg.Attachments[0]
+	if a.Color != "#2eb67d" || a.TitleLink != "https://codelens.example/repos/repo-1" || a.Text != "3 strategies ran" || a.Ts != testEvent().OccurredAt.Unix() {
+		t.Errorf("attachment = %+v", a)
+	}
+}
+
+func TestPublicClientRefusesLoopback(t *testing.T) {
+	rec := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(rec)
+	defer srv.Close()
+
+	// httptest listens on 127.0.0.1; a name resolving there is refused alike
+	for _, target := range []string{srv.URL, strings.Replace(srv.URL, "127.0.0.1", "localhost", 1)} {
+		err := NewWebhookNotifier().Send(context.Background(), &domain.NotificationSubscription{Target: target}, testEvent())
+		if !errors.Is(err, publicnet.ErrPrivate) {
+			t.Errorf("Send to %s = %v, want publicnet.ErrPrivate", target, err)
+		}
+	}
+	if rec.body != nil {
+		t.Error("request reached the loopback server")
+	}
+}
+
+func TestPublicClientRefusesRedirectToPrivate(t *testing.T) {
+	internal := &recorder{status: http.StatusOK}
+	srv := httptest.NewServer(internal)
+	defer srv.Close()
+
+	// A public-looking first hop is simulated by dialing the redirector directly; the
+	// redirect to the internal server must then be refused by the dial hook.
+	redirector := httptest.NewServer(http.RedirectHandler(srv.URL, http.StatusTemporaryRedirect))
+	defer redirector.Close()
+
+	client := publicnet.NewClient(15 * time.Second)
+	transport := client.Transport.(*http.Transport)
+	direct := (&net.Dialer{}).DialContext
+	publicDial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if addr == redirector.Listener.Addr().String() {
+			return direct(ctx, network, addr)
+		}
+		return publicDial(ctx, network, addr)
+	}
+
+	err := postJSON(context.Background(), client, redirector.URL, []byte("{}"), nil)
+	if !errors.Is(err, publicnet.ErrPrivate) {
+		t.Errorf("redirect to a private address = %v, want publicnet.ErrPrivate", err)
+	}
+	if internal.body != nil {
+		t.Error("request reached the internal server")
+	}
+}
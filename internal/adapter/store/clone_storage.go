@@ -0,0 +1,119 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// --- Clone Disk Usage & Eviction ---
+
+// DeleteRepo deletes a repo. Its snapshots, embeddings, analysis results, reviews,
+// schedules, subscriptions and deploy keys go with it (ON DELETE CASCADE).
+func (s *PostgresStore) DeleteRepo(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM repos WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("delete repo: %w", err)
+	}
+	return nil
+}
+
+// TouchRepo records that a repo's clone was just used.
+func (s *PostgresStore) TouchRepo(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE repos SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("touch repo: %w", err)
+	}
+	return nil
+}
+
+// SetRepoDiskUsage records the size of a repo's clone.
+func (s *PostgresStore) SetRepoDiskUsage(ctx context.Context, id string, bytes int64) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE repos SET disk_bytes = $1 WHERE id = $2`, bytes, id); err != nil {
+		return fmt.Errorf("set repo disk usage: %w", err)
+	}
+	return nil
+}
+
+// EvictRepo marks a repo's clone as evicted: its working copy no longer counts
+// against the owner's quota. Only ready repos are evicted; it reports whether this
+// one was.
+func (s *PostgresStore) EvictRepo(ctx context.Context, id string) (bool, error) {
+	query := `UPDATE repos SET status = $1, local_path = '', disk_bytes = 0 WHERE id = $2 AND status = $3`
+	res, err := s.db.ExecContext(ctx, query, domain.RepoStatusEvicted, id, domain.RepoStatusReady)
+	if err != nil {
+		return false, fmt.Errorf("evict repo: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// RepoPathShared reports whether a repo other than id uses localPath as its clone, as
+// repos of the same name could before clone directories included the repo ID.
+func (s *PostgresStore) RepoPathShared(ctx context.Context, localPath, id string) (bool, error) {
+	var shared bool
+	query := `SELECT EXISTS (SELECT 1 FROM repos WHERE local_path = $1 AND id <> $2)`
+	if err := s.db.QueryRowContext(ctx, query, localPath, id).Scan(&shared); err != nil {
+		return false, fmt.Errorf("repo path shared: %w", err)
+	}
+	return shared, nil
+}
+
+// UserCloneUsage returns the disk space taken by a user's clones.
+func (s *PostgresStore) UserCloneUsage(ctx context.Context, userID string) (int64, error) {
+	var used int64
+	query := `SELECT COALESCE(SUM(disk_bytes), 0) FROM repos WHERE user_id = $1 AND status = $2`
+	if err := s.db.QueryRowContext(ctx, query, userID, domain.RepoStatusReady).Scan(&used); err != nil {
+		return 0, fmt.Errorf("user clone usage: %w", err)
+	}
+	return used, nil
+}
+
+// ListUsersOverCloneQuota returns the users whose clones take more than quota bytes.
+func (s *PostgresStore) ListUsersOverCloneQuota(ctx context.Context, quota int64) ([]string, error) {
+	query := `SELECT user_id FROM repos WHERE status = $1 GROUP BY user_id HAVING SUM(disk_bytes) > $2`
+	rows, err := s.db.QueryContext(ctx, query, domain.RepoStatusReady, quota)
+	if err != nil {
+		return nil, fmt.Errorf("list users over clone quota: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan user id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// ListIdleRepos returns the ready repos last used before the given time, least
+// recently used first: a user's when userID is set, everyone's otherwise.
+func (s *PostgresStore) ListIdleRepos(ctx context.Context, userID string, before time.Time) ([]domain.Repo, error) {
+	query := `SELECT ` + repoColumns + ` FROM repos
+	          WHERE status = $1 AND last_used_at < $2 AND (user_id = NULLIF($3, '')::uuid OR $3 = '')
+	          ORDER BY last_used_at`
+
+	rows, err := s.db.QueryContext(ctx, query, domain.RepoStatusReady, before, userID)
+	if err != nil {
+		return nil, fmt.Errorf("list idle repos: %w", err)
+	}
+	defer rows.Close()
+	return scanRepos(rows)
+}
+
+// ListUnmeasuredClones returns the ready repos whose clone size was never recorded,
+// such as clones made before sizes were.
+func (s *PostgresStore) ListUnmeasuredClones(ctx context.Context) ([]domain.Repo, error) {
+	query := `SELECT ` + repoColumns + ` FROM repos
+	          WHERE status = $1 AND disk_bytes = 0 AND local_path <> ''`
+
+	rows, err := s.db.QueryContext(ctx, query, domain.RepoStatusReady)
+	if err != nil {
+		return nil, fmt.Errorf("list unmeasured clones: %w", err)
+	}
+	defer rows.Close()
+	return scanRepos(rows)
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// --- Embedding Cache ---
+
+// GetCachedEmbeddings implements port.EmbeddingCache.
+func (s *PostgresStore) GetCachedEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error) {
+	found := make(map[string][]float32, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT key, vector FROM embedding_cache WHERE key = ANY($1)`, pq.Array(keys))
+	if err != nil {
+		return nil, fmt.Errorf("get cached embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key string
+		var vec pq.Float32Array
+		if err := rows.Scan(&key, &vec); err != nil {
+			return nil, fmt.Errorf("scan cached embedding: %w", err)
+		}
+		found[key] = vec
+	}
+	return found, rows.Err()
+}
+
+// PutCachedEmbeddings implements port.EmbeddingCache.
+func (s *PostgresStore) PutCachedEmbeddings(ctx context.Context, model string, entries map[string][]float32) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO embedding_cache (key, model, vector) VALUES ($1, $2, $3)
+	                                     ON CONFLICT (key) DO NOTHING`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for key, vec := range entries {
+		if _, err := stmt.ExecContext(ctx, key, model, pq.Array(vec)); err != nil {
+			return fmt.Errorf("put cached embedding: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// CountCachedEmbeddings returns the number of cached vectors per model.
+func (s *PostgresStore) CountCachedEmbeddings(ctx context.Context) (map[string]int64, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT model, COUNT(*) FROM embedding_cache GROUP BY model`)
+	if err != nil {
+		return nil, fmt.Errorf("count cached embeddings: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var model string
+		var n int64
+		if err := rows.Scan(&model, &n); err != nil {
+			return nil, fmt.Errorf("scan cache count: %w", err)
+		}
+		counts[model] = n
+	}
+	return counts, rows.Err()
+}
@@ -0,0 +1,274 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/lib/pq"
+)
+
+const embeddingIndexColumns = `id, model, dimension, status, total_chunks, embedded_chunks, error, created_at, activated_at`
+
+// ActiveIndex returns the embedding index served by searches, or nil before EnsureActiveIndex.
+func (v *VectorStore) ActiveIndex() *domain.EmbeddingIndex {
+	return v.active.Load()
+}
+
+// activeIndex is ActiveIndex for internal callers that need one.
+func (v *VectorStore) activeIndex() (*domain.EmbeddingIndex, error) {
+	idx := v.active.Load()
+	if idx == nil {
+		return nil, port.ErrNoActiveIndex
+	}
+	return idx, nil
+}
+
+// checkDimension rejects vectors that do not match an index's dimension.
+func checkDimension(idx *domain.EmbeddingIndex, vec []float32) error {
+	if len(vec) != idx.Dimension {
+		return fmt.Errorf("%w: got %d, index %s (%s) expects %d",
+			port.ErrDimensionMismatch, len(vec), idx.ID, idx.Model, idx.Dimension)
+	}
+	return nil
+}
+
+// EnsureActiveIndex loads the active embedding index, creating one for model at the
+// store's configured dimension on first start. Embeddings written before indexes
+// were tracked are adopted by it; their actual dimension wins over the configured one
+// so that mismatched inserts are rejected instead of mixed in.
+// Indexes left "building" by an interrupted migration are marked failed.
+func (v *VectorStore) EnsureActiveIndex(ctx context.Context, model string) (*domain.EmbeddingIndex, error) {
+	db := v.store.db
+
+	if _, err := db.ExecContext(ctx, `DELETE FROM embeddings WHERE index_id IN (SELECT id FROM embedding_indexes WHERE status = $1)`,
+		domain.EmbeddingIndexBuilding); err != nil {
+		return nil, fmt.Errorf("clean interrupted index: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, `UPDATE embedding_indexes SET status = $1, error = 'interrupted' WHERE status = $2`,
+		domain.EmbeddingIndexFailed, domain.EmbeddingIndexBuilding); err != nil {
+		return nil, fmt.Errorf("mark interrupted index: %w", err)
+	}
+
+	idx, err := v.getIndex(ctx, `WHERE status = $1`, domain.EmbeddingIndexActive)
+	if err == nil {
+		v.active.Store(idx)
+		return idx, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("load active index: %w", err)
+	}
+
+	dimension := v.dimension
+	var legacyDim int
+	err = db.QueryRowContext(ctx, `SELECT vector_dims(vector) FROM embeddings WHERE index_id IS NULL AND vector IS NOT NULL LIMIT 1`).Scan(&legacyDim)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("inspect existing embeddings: %w", err)
+	}
+	if legacyDim > 0 && legacyDim != dimension {
+		dimension = legacyDim
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	idx, err = scanEmbeddingIndex(tx.QueryRowContext(ctx, `
+		INSERT INTO embedding_indexes (model, dimension, status, activated_at)
+		VALUES ($1, $2, $3, NOW())
+		RETURNING `+embeddingIndexColumns, model, dimension, domain.EmbeddingIndexActive))
+	if err != nil {
+		return nil, fmt.Errorf("create active index: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE embeddings SET index_id = $1 WHERE index_id IS NULL`, idx.ID)
+	if err != nil {
+		return nil, fmt.Errorf("adopt existing embeddings: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	adopted, _ := res.RowsAffected()
+	idx.EmbeddedChunks = int(adopted)
+	v.active.Store(idx)
+	return idx, nil
+}
+
+// CreateEmbeddingIndex registers a new index in the building state.
+func (v *VectorStore) CreateEmbeddingIndex(ctx context.Context, model string, dimension int) (*domain.EmbeddingIndex, error) {
+	if dimension > maxIndexedDimension {
+		return nil, fmt.Errorf("%w: %d exceeds the %d dimensions pgvector can index", port.ErrDimensionMismatch, dimension, maxIndexedDimension)
+	}
+	idx, err := scanEmbeddingIndex(v.store.db.QueryRowContext(ctx, `
+		INSERT INTO embedding_indexes (model, dimension, status)
+		VALUES ($1, $2, $3)
+		RETURNING `+embeddingIndexColumns, model, dimension, domain.EmbeddingIndexBuilding))
+	if err != nil {
+		return nil, fmt.Errorf("create embedding index: %w", err)
+	}
+	return idx, nil
+}
+
+// ListEmbeddingIndexes returns all embedding indexes, newest first.
+func (v *VectorStore) ListEmbeddingIndexes(ctx context.Context) ([]domain.EmbeddingIndex, error) {
+	rows, err := v.store.db.QueryContext(ctx, `SELECT `+embeddingIndexColumns+` FROM embedding_indexes ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("list embedding indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []domain.EmbeddingIndex
+	for rows.Next() {
+		idx, err := scanEmbeddingIndex(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan embedding index: %w", err)
+		}
+		indexes = append(indexes, *idx)
+	}
+	return indexes, rows.Err()
+}
+
+// CountIndexEmbeddings returns the number of chunks stored in an index.
+func (v *VectorStore) CountIndexEmbeddings(ctx context.Context, indexID string) (int, error) {
+	var n int
+	err := v.store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings WHERE index_id = $1`, indexID).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count index embeddings: %w", err)
+	}
+	return n, nil
+}
+
+// UpdateEmbeddingIndexProgress records how far a building index has got.
+func (v *VectorStore) UpdateEmbeddingIndexProgress(ctx context.Context, indexID string, total, embedded int) error {
+	_, err := v.store.db.ExecContext(ctx, `UPDATE embedding_indexes SET total_chunks = $1, embedded_chunks = $2 WHERE id = $3`,
+		total, embedded, indexID)
+	return err
+}
+
+// FailEmbeddingIndex marks an index as failed and drops the vectors built so far.
+func (v *VectorStore) FailEmbeddingIndex(ctx context.Context, indexID, reason string) error {
+	if err := v.DeleteIndexEmbeddings(ctx, indexID); err != nil {
+		return err
+	}
+	_, err := v.store.db.ExecContext(ctx, `UPDATE embedding_indexes SET status = $1, error = $2 WHERE id = $3`,
+		domain.EmbeddingIndexFailed, reason, indexID)
+	return err
+}
+
+// PendingReembed returns up to limit chunks of index from that have no counterpart
+// (same snapshot, file and chunk) in index to. Repeated calls therefore walk the
+// source index and also pick up chunks written to it while a migration runs.
+func (v *VectorStore) PendingReembed(ctx context.Context, fromIndexID, toIndexID string, limit int) ([]domain.Embedding, error) {
+	rows, err := v.store.db.QueryContext(ctx, `
+		SELECT e.id, e.content FROM embeddings e
+		WHERE e.index_id = $1
+		  AND NOT EXISTS (
+			SELECT 1 FROM embeddings n
+			WHERE n.index_id = $2 AND n.snapshot_id = e.snapshot_id
+			  AND n.file_path = e.file_path AND n.chunk_index = e.chunk_index)
+		ORDER BY e.id
+		LIMIT $3`, fromIndexID, toIndexID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("pending re-embed: %w", err)
+	}
+	defer rows.Close()
+
+	var chunks []domain.Embedding
+	for rows.Next() {
+		var e domain.Embedding
+		if err := rows.Scan(&e.ID, &e.Content); err != nil {
+			return nil, fmt.Errorf("scan pending chunk: %w", err)
+		}
+		chunks = append(chunks, e)
+	}
+	return chunks, rows.Err()
+}
+
+// StoreReembedded copies source chunks into index to with new vectors (vectors[i] for sourceIDs[i]).
+func (v *VectorStore) StoreReembedded(ctx context.Context, to *domain.EmbeddingIndex, sourceIDs []string, vectors [][]float32) error {
+	if len(sourceIDs) != len(vectors) {
+		return fmt.Errorf("re-embed: %d vectors for %d chunks", len(vectors), len(sourceIDs))
+	}
+	texts := make([]string, len(vectors))
+	for i, vec := range vectors {
+		if err := checkDimension(to, vec); err != nil {
+			return err
+		}
+		texts[i] = vectorToString(vec)
+	}
+
+	_, err := v.store.db.ExecContext(ctx, `
+		INSERT INTO embeddings (snapshot_id, repo_id, file_path, chunk_index, content, language,
+		                        symbol_name, symbol_kind, parent_symbol, start_line, end_line, commit_hash, content_hash, index_id, vector)
+		SELECT e.snapshot_id, e.repo_id, e.file_path, e.chunk_index, e.content, e.language,
+		       e.symbol_name, e.symbol_kind, e.parent_symbol, e.start_line, e.end_line, e.commit_hash, e.content_hash, $1, v.vec::vector
+		FROM embeddings e
+		JOIN unnest($2::uuid[], $3::text[]) AS v(id, vec) ON e.id = v.id`,
+		to.ID, pq.Array(sourceIDs), pq.Array(texts))
+	if err != nil {
+		return fmt.Errorf("store re-embedded chunks: %w", err)
+	}
+	return nil
+}
+
+// ActivateEmbeddingIndex atomically retires the active index and makes indexID active.
+// Returns the retired index.
+func (v *VectorStore) ActivateEmbeddingIndex(ctx context.Context, indexID string) (*domain.EmbeddingIndex, error) {
+	tx, err := v.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	retired, err := scanEmbeddingIndex(tx.QueryRowContext(ctx, `
+		UPDATE embedding_indexes SET status = $1 WHERE status = $2
+		RETURNING `+embeddingIndexColumns, domain.EmbeddingIndexRetired, domain.EmbeddingIndexActive))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("retire active index: %w", err)
+	}
+
+	active, err := scanEmbeddingIndex(tx.QueryRowContext(ctx, `
+		UPDATE embedding_indexes SET status = $1, activated_at = NOW() WHERE id = $2 AND status = $3
+		RETURNING `+embeddingIndexColumns, domain.EmbeddingIndexActive, indexID, domain.EmbeddingIndexBuilding))
+	if err != nil {
+		return nil, fmt.Errorf("activate index %s: %w", indexID, err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	v.active.Store(active)
+	return retired, nil
+}
+
+// DeleteIndexEmbeddings removes every vector of an index.
+func (v *VectorStore) DeleteIndexEmbeddings(ctx context.Context, indexID string) error {
+	_, err := v.store.db.ExecContext(ctx, `DELETE FROM embeddings WHERE index_id = $1`, indexID)
+	return err
+}
+
+func (v *VectorStore) getIndex(ctx context.Context, where string, args ...interface{}) (*domain.EmbeddingIndex, error) {
+	return scanEmbeddingIndex(v.store.db.QueryRowContext(ctx, `SELECT `+embeddingIndexColumns+` FROM embedding_indexes `+where, args...))
+}
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEmbeddingIndex(row rowScanner) (*domain.EmbeddingIndex, error) {
+	var idx domain.EmbeddingIndex
+	var activatedAt sql.NullTime
+	if err := row.Scan(&idx.ID, &idx.Model, &idx.Dimension, &idx.Status, &idx.TotalChunks,
+		&idx.EmbeddedChunks, &idx.Error, &idx.CreatedAt, &activatedAt); err != nil {
+		return nil, err
+	}
+	if activatedAt.Valid {
+		idx.ActivatedAt = &activatedAt.Time
+	}
+	return &idx, nil
+}
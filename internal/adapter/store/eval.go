@@ -0,0 +1,85 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- RAG Evaluation Runs ---
+
+// SaveEvalRun stores a run and its per-question results in one transaction,
+// filling in the generated ID and timestamp.
+func (s *PostgresStore) SaveEvalRun(ctx context.Context, run *domain.EvalRun) error {
+	config, err := json.Marshal(run.Config)
+	if err != nil {
+		return fmt.Errorf("marshal eval config: %w", err)
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO eval_runs (repo_id, set_name, label, config, k, case_count, recall_at_k, mrr, faithfulness, fact_recall)
+		VALUES ($1, $2, $3, $4::jsonb, $5, $6, $7, $8, $9, $10)
+		RETURNING id, created_at`,
+		run.RepoID, run.SetName, run.Label, string(config), run.K, run.CaseCount,
+		run.RecallAtK, run.MRR, run.Faithfulness, run.FactRecall,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("insert eval run: %w", err)
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO eval_results (run_id, case_id, question, recall, reciprocal_rank, faithfulness, fact_recall, retrieved_files, answer, error)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`)
+	if err != nil {
+		return fmt.Errorf("prepare eval result: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, r := range run.Results {
+		if _, err := stmt.ExecContext(ctx, run.ID, r.CaseID, r.Question, r.Recall, r.ReciprocalRank,
+			r.Faithfulness, r.FactRecall, pq.Array(r.RetrievedFiles), r.Answer, r.Error); err != nil {
+			return fmt.Errorf("insert eval result %s: %w", r.CaseID, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListEvalRuns returns the most recent runs of a question set for a repo, newest first,
+// without per-question results.
+func (s *PostgresStore) ListEvalRuns(ctx context.Context, repoID, setName string, limit int) ([]domain.EvalRun, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, repo_id, set_name, label, config, k, case_count, recall_at_k, mrr, faithfulness, fact_recall, created_at
+		FROM eval_runs
+		WHERE repo_id = $1 AND set_name = $2
+		ORDER BY created_at DESC
+		LIMIT $3`, repoID, setName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list eval runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []domain.EvalRun
+	for rows.Next() {
+		var r domain.EvalRun
+		var config []byte
+		if err := rows.Scan(&r.ID, &r.RepoID, &r.SetName, &r.Label, &config, &r.K, &r.CaseCount,
+			&r.RecallAtK, &r.MRR, &r.Faithfulness, &r.FactRecall, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan eval run: %w", err)
+		}
+		if err := json.Unmarshal(config, &r.Config); err != nil {
+			return nil, fmt.Errorf("decode eval config: %w", err)
+		}
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
@@ -0,0 +1,75 @@
+package store
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- Indexing Failures ---
+
+// SaveIndexFailures records files that could not be indexed.
+func (v *VectorStore) SaveIndexFailures(ctx context.Context, failures []domain.IndexFailure) error {
+	if len(failures) == 0 {
+		return nil
+	}
+
+	tx, err := v.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO index_failures (snapshot_id, file_path, stage, error) VALUES ($1, $2, $3, $4)`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range failures {
+		if _, err := stmt.ExecContext(ctx, f.SnapshotID, f.FilePath, f.Stage, f.Error); err != nil {
+			return fmt.Errorf("insert index failure: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// ListIndexFailures returns up to limit failures recorded for a snapshot, by file path.
+func (v *VectorStore) ListIndexFailures(ctx context.Context, snapshotID string, limit int) ([]domain.IndexFailure, error) {
+	rows, err := v.store.db.QueryContext(ctx, `
+		SELECT snapshot_id, file_path, stage, error, created_at FROM index_failures
+		WHERE snapshot_id = $1 ORDER BY file_path LIMIT $2`, snapshotID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list index failures: %w", err)
+	}
+	defer rows.Close()
+
+	var failures []domain.IndexFailure
+	for rows.Next() {
+		var f domain.IndexFailure
+		if err := rows.Scan(&f.SnapshotID, &f.FilePath, &f.Stage, &f.Error, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan index failure: %w", err)
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
+// DeleteIndexFailures clears the failures of a snapshot before it is re-indexed.
+func (v *VectorStore) DeleteIndexFailures(ctx context.Context, snapshotID string) error {
+	_, err := v.store.db.ExecContext(ctx, `DELETE FROM index_failures WHERE snapshot_id = $1`, snapshotID)
+	return err
+}
+
+// DeleteFileEmbeddings removes the chunks of some files from a snapshot
+// (used to drop partially written files whose indexing failed).
+func (v *VectorStore) DeleteFileEmbeddings(ctx context.Context, snapshotID string, filePaths []string) error {
+	if len(filePaths) == 0 {
+		return nil
+	}
+	_, err := v.store.db.ExecContext(ctx, `DELETE FROM embeddings WHERE snapshot_id = $1 AND file_path = ANY($2)`,
+		snapshotID, pq.Array(filePaths))
+	return err
+}
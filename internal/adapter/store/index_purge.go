@@ -0,0 +1,100 @@
+package store
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- Index Purge & Retention ---
+
+// IndexedSnapshotIDs returns the snapshots of a repo that hold an index to purge:
+// vectorized or failed ones, and any others with leftover embeddings.
+func (v *VectorStore) IndexedSnapshotIDs(ctx context.Context, repoID string) ([]string, error) {
+	query := `SELECT s.id FROM snapshots s
+	          WHERE s.repo_id = $1
+	            AND (s.status IN ($2, $3) OR EXISTS (SELECT 1 FROM embeddings e WHERE e.snapshot_id = s.id))`
+	return v.snapshotIDs(ctx, query, repoID, domain.SnapshotStatusVectorized, domain.SnapshotStatusFailed)
+}
+
+// SupersededSnapshotIDs returns the snapshots, across all repos, whose index falls outside
+// the retention policy: older than each repo's keep newest vectorized snapshots of its
+// tracked branch and created before the given time. Snapshots indexed at other refs count
+// toward nothing, the tracked HEAD's snapshot is never selected, and neither are snapshots
+// newer than the kept ones (such as one being indexed) or those of repos without a
+// vectorized snapshot on their tracked branch.
+func (v *VectorStore) SupersededSnapshotIDs(ctx context.Context, keep int, before time.Time) ([]string, error) {
+	query := `WITH kept AS (
+	              SELECT repo_id, MIN(created_at) AS since FROM (
+	                  SELECT s.repo_id, s.created_at,
+	                         ROW_NUMBER() OVER (PARTITION BY s.repo_id ORDER BY s.created_at DESC) AS rn
+	                  FROM snapshots s
+	                  JOIN repos r ON r.id = s.repo_id
+	                  WHERE s.status = $1 AND (s.branch = r.default_branch OR s.commit_hash = r.head_commit)
+	              ) ranked
+	              WHERE rn <= $2
+	              GROUP BY repo_id
+	          )
+	          SELECT s.id FROM snapshots s
+	          JOIN kept k ON k.repo_id = s.repo_id
+	          JOIN repos r ON r.id = s.repo_id
+	          WHERE s.created_at < k.since
+	            AND s.created_at < $3
+	            AND s.commit_hash <> r.head_commit
+	            AND (s.status IN ($1, $4) OR EXISTS (SELECT 1 FROM embeddings e WHERE e.snapshot_id = s.id))`
+	return v.snapshotIDs(ctx, query, domain.SnapshotStatusVectorized, keep, before, domain.SnapshotStatusFailed)
+}
+
+// snapshotIDs runs a query selecting a single snapshot ID column.
+func (v *VectorStore) snapshotIDs(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := v.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("select snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan snapshot id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PurgeSnapshots deletes the embeddings and indexing failures of the given snapshots
+// and marks them pruned, in one transaction. Returns the number of embeddings deleted.
+func (v *VectorStore) PurgeSnapshots(ctx context.Context, snapshotIDs []string) (int64, error) {
+	if len(snapshotIDs) == 0 {
+		return 0, nil
+	}
+
+	tx, err := v.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `DELETE FROM embeddings WHERE snapshot_id = ANY($1)`, pq.Array(snapshotIDs))
+	if err != nil {
+		return 0, fmt.Errorf("delete embeddings: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM index_failures WHERE snapshot_id = ANY($1)`, pq.Array(snapshotIDs)); err != nil {
+		return 0, fmt.Errorf("delete index failures: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE snapshots SET status = $1, chunk_count = 0, failed_files = 0, index_error = ''
+	                                  WHERE id = ANY($2)`, domain.SnapshotStatusPruned, pq.Array(snapshotIDs)); err != nil {
+		return 0, fmt.Errorf("mark snapshots pruned: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit purge: %w", err)
+	}
+	return deleted, nil
+}
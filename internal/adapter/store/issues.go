@@ -0,0 +1,142 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- Issue Trackers ---
+
+// issueTrackerColumns is the column list scanned by scanIssueTracker.
+const issueTrackerColumns = `repo_id, kind, base_url, project, username, token, token_encrypted, issue_type, labels,
+	title_template, body_template, created_at, updated_at`
+
+// GetIssueTracker returns the issue tracker of a repo.
+func (s *PostgresStore) GetIssueTracker(ctx context.Context, repoID string) (*domain.IssueTrackerConfig, error) {
+	query := `SELECT ` + issueTrackerColumns + ` FROM issue_trackers WHERE repo_id = $1`
+	cfg, err := scanIssueTracker(s.db.QueryRowContext(ctx, query, repoID))
+	if err != nil {
+		return nil, fmt.Errorf("get issue tracker: %w", err)
+	}
+	return cfg, nil
+}
+
+// SaveIssueTracker creates or replaces the issue tracker of a repo. The token is stored
+// as cfg.TokenSealed; cfg.Token is expected to be empty.
+func (s *PostgresStore) SaveIssueTracker(ctx context.Context, cfg *domain.IssueTrackerConfig) (*domain.IssueTrackerConfig, error) {
+	query := `INSERT INTO issue_trackers (repo_id, kind, base_url, project, username, token, token_encrypted, issue_type, labels, title_template, body_template)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	          ON CONFLICT (repo_id) DO UPDATE
+	          SET kind = EXCLUDED.kind, base_url = EXCLUDED.base_url, project = EXCLUDED.project,
+	              username = EXCLUDED.username, token = EXCLUDED.token, token_encrypted = EXCLUDED.token_encrypted,
+	              issue_type = EXCLUDED.issue_type,
+	              labels = EXCLUDED.labels, title_template = EXCLUDED.title_template,
+	              body_template = EXCLUDED.body_template, updated_at = NOW()
+	          RETURNING ` + issueTrackerColumns
+
+	saved, err := scanIssueTracker(s.db.QueryRowContext(ctx, query,
+		cfg.RepoID, cfg.Kind, cfg.BaseURL, cfg.Project, cfg.Username, cfg.Token, nonNilBytes(cfg.TokenSealed), cfg.IssueType,
+		pq.Array(nonNilStrings(cfg.Labels)), cfg.TitleTemplate, cfg.BodyTemplate,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("save issue tracker: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteIssueTracker removes the issue tracker of a repo. Links of findings to issues
+// already opened are kept. Returns sql.ErrNoRows if the repo has none.
+func (s *PostgresStore) DeleteIssueTracker(ctx context.Context, repoID string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM issue_trackers WHERE repo_id = $1`, repoID)
+	if err != nil {
+		return fmt.Errorf("delete issue tracker: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// ListPlaintextTrackerTokens returns the repo IDs and tokens of the issue trackers whose
+// token was saved before tokens were encrypted.
+func (s *PostgresStore) ListPlaintextTrackerTokens(ctx context.Context) (map[string]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT repo_id, token FROM issue_trackers WHERE token <> ''`)
+	if err != nil {
+		return nil, fmt.Errorf("list plaintext tracker tokens: %w", err)
+	}
+	defer rows.Close()
+
+	tokens := map[string]string{}
+	for rows.Next() {
+		var repoID, token string
+		if err := rows.Scan(&repoID, &token); err != nil {
+			return nil, fmt.Errorf("scan tracker token: %w", err)
+		}
+		tokens[repoID] = token
+	}
+	return tokens, rows.Err()
+}
+
+// SealTrackerToken replaces the plaintext token of a repo's issue tracker with its
+// sealed form, unless the token changed since it was read.
+func (s *PostgresStore) SealTrackerToken(ctx context.Context, repoID, token string, sealed []byte) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE issue_trackers SET token = '', token_encrypted = $3 WHERE repo_id = $1 AND token = $2`,
+		repoID, token, sealed)
+	if err != nil {
+		return fmt.Errorf("seal tracker token: %w", err)
+	}
+	return nil
+}
+
+// scanIssueTracker scans a row selected with issueTrackerColumns.
+func scanIssueTracker(row rowScanner) (*domain.IssueTrackerConfig, error) {
+	var cfg domain.IssueTrackerConfig
+	err := row.Scan(&cfg.RepoID, &cfg.Kind, &cfg.BaseURL, &cfg.Project, &cfg.Username, &cfg.Token, &cfg.TokenSealed, &cfg.IssueType,
+		pq.Array(&cfg.Labels), &cfg.TitleTemplate, &cfg.BodyTemplate, &cfg.CreatedAt, &cfg.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	cfg.HasToken = cfg.Token != "" || len(cfg.TokenSealed) > 0
+	if cfg.Labels == nil {
+		cfg.Labels = []string{}
+	}
+	return &cfg, nil
+}
+
+// --- Finding Issues ---
+
+// LinkFindingIssue records the issue opened for a finding.
+func (s *PostgresStore) LinkFindingIssue(ctx context.Context, findingID string, link *domain.IssueLink) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE review_findings
+		SET issue_tracker = $1, issue_key = $2, issue_url = $3, issue_state = $4, issue_synced_at = NOW()
+		WHERE id = $5`, link.Tracker, link.Key, link.URL, link.State, findingID)
+	if err != nil {
+		return fmt.Errorf("link finding issue: %w", err)
+	}
+	return nil
+}
+
+// SetFindingIssueState records the state of a finding's issue as of now.
+func (s *PostgresStore) SetFindingIssueState(ctx context.Context, findingID, state string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE review_findings SET issue_state = $1, issue_synced_at = NOW() WHERE id = $2`,
+		state, findingID)
+	if err != nil {
+		return fmt.Errorf("set finding issue state: %w", err)
+	}
+	return nil
+}
+
+// ListOpenIssueFindings returns findings whose issue was open when last synced, before
+// syncedBefore, least recently synced first. An empty repoID covers every repo.
+func (s *PostgresStore) ListOpenIssueFindings(ctx context.Context, repoID string, syncedBefore time.Time, limit int) ([]domain.Finding, error) {
+	return s.queryFindings(ctx, `SELECT `+findingColumns+` FROM review_findings
+		WHERE issue_state = 'open' AND issue_synced_at < $1 AND ($2 = '' OR repo_id = NULLIF($2, '')::uuid)
+		ORDER BY issue_synced_at
+		LIMIT $3`, syncedBefore, repoID, limit)
+}
@@ -0,0 +1,276 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- Notification Subscriptions ---
+
+// subscriptionColumns is the column list scanned by scanSubscription.
+const subscriptionColumns = `id, user_id, COALESCE(repo_id::text, ''), channel, target, secret, events, enabled, created_at, updated_at`
+
+// CreateSubscription inserts a notification subscription.
+func (s *PostgresStore) CreateSubscription(ctx context.Context, sub *domain.NotificationSubscription) (*domain.NotificationSubscription, error) {
+	query := `INSERT INTO notification_subscriptions (user_id, repo_id, channel, target, secret, events, enabled)
+	          VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7)
+	          RETURNING ` + subscriptionColumns
+
+	created, err := scanSubscription(s.db.QueryRowContext(ctx, query,
+		sub.UserID, sub.RepoID, sub.Channel, sub.Target, sub.Secret, pq.Array(nonNilStrings(sub.Events)), sub.Enabled,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create subscription: %w", err)
+	}
+	return created, nil
+}
+
+// GetSubscription returns a user's subscription by ID.
+func (s *PostgresStore) GetSubscription(ctx context.Context, userID, id string) (*domain.NotificationSubscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM notification_subscriptions WHERE id = $1 AND user_id = $2`
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx, query, id, userID))
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// ListSubscriptionsByUser returns a user's subscriptions, oldest first.
+func (s *PostgresStore) ListSubscriptionsByUser(ctx context.Context, userID string) ([]domain.NotificationSubscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM notification_subscriptions WHERE user_id = $1 ORDER BY created_at`
+	return s.querySubscriptions(ctx, query, userID)
+}
+
+// ListSubscriptionsForEvent returns the enabled subscriptions of a user that cover
+// eventType on repoID: user-level ones and the repo's own.
+func (s *PostgresStore) ListSubscriptionsForEvent(ctx context.Context, userID, repoID, eventType string) ([]domain.NotificationSubscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM notification_subscriptions
+	          WHERE user_id = $1 AND enabled
+	            AND (repo_id IS NULL OR repo_id = $2::uuid)
+	            AND (cardinality(events) = 0 OR $3 = ANY(events))`
+	return s.querySubscriptions(ctx, query, userID, repoID, eventType)
+}
+
+// UpdateSubscription saves the editable fields of a subscription.
+func (s *PostgresStore) UpdateSubscription(ctx context.Context, sub *domain.NotificationSubscription) (*domain.NotificationSubscription, error) {
+	query := `UPDATE notification_subscriptions
+	          SET repo_id = NULLIF($1, '')::uuid, channel = $2, target = $3, secret = $4, events = $5, enabled = $6, updated_at = NOW()
+	          WHERE id = $7 AND user_id = $8
+	          RETURNING ` + subscriptionColumns
+
+	updated, err := scanSubscription(s.db.QueryRowContext(ctx, query,
+		sub.RepoID, sub.Channel, sub.Target, sub.Secret, pq.Array(nonNilStrings(sub.Events)), sub.Enabled, sub.ID, sub.UserID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("update subscription: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteSubscription removes a user's subscription and its delivery log.
+// Returns sql.ErrNoRows if it does not exist.
+func (s *PostgresStore) DeleteSubscription(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM notification_subscriptions WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete subscription: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// querySubscriptions runs a query selecting subscriptionColumns.
+func (s *PostgresStore) querySubscriptions(ctx context.Context, query string, args ...interface{}) ([]domain.NotificationSubscription, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []domain.NotificationSubscription
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan subscription: %w", err)
+		}
+		subs = append(subs, *sub)
+	}
+	return subs, rows.Err()
+}
+
+// scanSubscription scans a row selected with subscriptionColumns.
+func scanSubscription(row rowScanner) (*domain.NotificationSubscription, error) {
+	var sub domain.NotificationSubscription
+	err := row.Scan(&sub.ID, &sub.UserID, &sub.RepoID, &sub.Channel, &sub.Target, &sub.Secret,
+		pq.Array(&sub.Events), &sub.Enabled, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	sub.HasSecret = sub.Secret != ""
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+	return &sub, nil
+}
+
+// --- Notification Deliveries ---
+
+// deliveryColumns is the column list scanned by scanDelivery.
+const deliveryColumns = `id, subscription_id, event_type, payload, status, attempts, next_attempt_at, last_error, created_at, delivered_at`
+
+// EnqueueDeliveries adds a pending delivery of payload for each subscription.
+func (s *PostgresStore) EnqueueDeliveries(ctx context.Context, subscriptionIDs []string, eventType string, payload []byte) error {
+	if len(subscriptionIDs) == 0 {
+		return nil
+	}
+	_, err := s.db.ExecContext(ctx, `
+		INSERT INTO notification_deliveries (subscription_id, event_type, payload)
+		SELECT unnest($1::uuid[]), $2, $3::jsonb`, pq.Array(subscriptionIDs), eventType, string(payload))
+	if err != nil {
+		return fmt.Errorf("enqueue deliveries: %w", err)
+	}
+	return nil
+}
+
+// ClaimDueDeliveries returns up to limit pending deliveries that are due, leasing them
+// for lease so that concurrent dispatchers (other instances) skip them meanwhile.
+func (s *PostgresStore) ClaimDueDeliveries(ctx context.Context, limit int, lease time.Duration) ([]domain.NotificationDelivery, error) {
+	query := `UPDATE notification_deliveries SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+	          WHERE id IN (
+	              SELECT id FROM notification_deliveries
+	              WHERE status = 'pending' AND next_attempt_at <= NOW()
+	              ORDER BY next_attempt_at
+	              LIMIT $1
+	              FOR UPDATE SKIP LOCKED)
+	          RETURNING ` + deliveryColumns
+	return s.queryDeliveries(ctx, query, limit, lease.Seconds())
+}
+
+// GetSubscriptionByID returns a subscription regardless of owner, for the dispatcher.
+func (s *PostgresStore) GetSubscriptionByID(ctx context.Context, id string) (*domain.NotificationSubscription, error) {
+	query := `SELECT ` + subscriptionColumns + ` FROM notification_subscriptions WHERE id = $1`
+	sub, err := scanSubscription(s.db.QueryRowContext(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("get subscription: %w", err)
+	}
+	return sub, nil
+}
+
+// MarkDeliveryDelivered records a successful send.
+func (s *PostgresStore) MarkDeliveryDelivered(ctx context.Context, id string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE notification_deliveries
+		SET status = 'delivered', attempts = attempts + 1, last_error = '', delivered_at = NOW()
+		WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("mark delivery delivered: %w", err)
+	}
+	return nil
+}
+
+// MarkDeliveryFailed records a failed send. The delivery is retried at nextAttempt,
+// or given up on (status failed) when nextAttempt is nil.
+func (s *PostgresStore) MarkDeliveryFailed(ctx context.Context, id, sendErr string, nextAttempt *time.Time) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE notification_deliveries
+		SET attempts = attempts + 1, last_error = $1,
+		    status = CASE WHEN $2::timestamptz IS NULL THEN 'failed' ELSE 'pending' END,
+		    next_attempt_at = COALESCE($2::timestamptz, next_attempt_at)
+		WHERE id = $3`, sendErr, nextAttempt, id)
+	if err != nil {
+		return fmt.Errorf("mark delivery failed: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveriesByUser returns a user's most recent deliveries, optionally filtered by status.
+func (s *PostgresStore) ListDeliveriesByUser(ctx context.Context, userID, status string, limit int) ([]domain.NotificationDelivery, error) {
+	query := `SELECT d.id, d.subscription_id, d.event_type, d.payload, d.status, d.attempts, d.next_attempt_at,
+	                 d.last_error, d.created_at, d.delivered_at
+	          FROM notification_deliveries d
+	          JOIN notification_subscriptions s ON s.id = d.subscription_id
+	          WHERE s.user_id = $1 AND ($2 = '' OR d.status = $2)
+	          ORDER BY d.created_at DESC
+	          LIMIT $3`
+	return s.queryDeliveries(ctx, query, userID, status, limit)
+}
+
+// RetryDelivery puts a user's undelivered delivery back in the queue, due now.
+// Returns sql.ErrNoRows if there is no such undelivered delivery.
+func (s *PostgresStore) RetryDelivery(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `UPDATE notification_deliveries d
+		SET status = 'pending', next_attempt_at = NOW()
+		FROM notification_subscriptions s
+		WHERE d.id = $1 AND s.id = d.subscription_id AND s.user_id = $2 AND d.status <> 'delivered'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("retry delivery: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// queryDeliveries runs a query selecting deliveryColumns.
+func (s *PostgresStore) queryDeliveries(ctx context.Context, query string, args ...interface{}) ([]domain.NotificationDelivery, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []domain.NotificationDelivery
+	for rows.Next() {
+		var d domain.NotificationDelivery
+		var delivered sql.NullTime
+		if err := rows.Scan(&d.ID, &d.SubscriptionID, &d.EventType, &d.Payload, &d.Status, &d.Attempts,
+			&d.NextAttemptAt, &d.LastError, &d.CreatedAt, &delivered); err != nil {
+			return nil, fmt.Errorf("scan delivery: %w", err)
+		}
+		if delivered.Valid {
+			d.DeliveredAt = &delivered.Time
+		}
+		deliveries = append(deliveries, d)
+	}
+	return deliveries, rows.Err()
+}
+
+// --- Analysis score history ---
+
+// AnalysisScore is the score of one stored analysis result.
+type AnalysisScore struct {
+	Score     float64
+	CreatedAt time.Time
+}
+
+// RecentAnalysisScores returns, per strategy, the two most recent non-zero scores of
+// a repo's analysis results, newest first. A zero score marks a failed run and is skipped.
+func (s *PostgresStore) RecentAnalysisScores(ctx context.Context, repoID string, strategies []string) (map[string][]AnalysisScore, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT strategy, score, created_at FROM (
+			SELECT strategy, score, created_at, ROW_NUMBER() OVER (PARTITION BY strategy ORDER BY created_at DESC) AS rn
+			FROM analysis_results
+			WHERE repo_id = $1 AND strategy = ANY($2) AND score > 0
+		) ranked
+		WHERE rn <= 2
+		ORDER BY strategy, rn`, repoID, pq.Array(strategies))
+	if err != nil {
+		return nil, fmt.Errorf("analysis scores: %w", err)
+	}
+	defer rows.Close()
+
+	scores := map[string][]AnalysisScore{}
+	for rows.Next() {
+		var strategy string
+		var sc AnalysisScore
+		if err := rows.Scan(&strategy, &sc.Score, &sc.CreatedAt); err != nil {
+			return nil, fmt.Errorf("scan analysis score: %w", err)
+		}
+		scores[strategy] = append(scores[strategy], sc)
+	}
+	return scores, rows.Err()
+}
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/lib/pq"
 )
 
 // PostgresStore handles all relational database operations.
@@ -95,43 +97,62 @@ func (s *PostgresStore) GetUserByID(ctx context.Context, id string) (*domain.Use
 
 // CreateRepo inserts a new repository record.
 func (s *PostgresStore) CreateRepo(ctx context.Context, r *domain.Repo) (*domain.Repo, error) {
-	query := `INSERT INTO repos (user_id, name, url, default_branch, local_path, status)
-	          VALUES ($1, $2, $3, $4, $5, $6)
-	          RETURNING id, user_id, name, url, default_branch, local_path, status, report_language, created_at, updated_at`
+	query := `INSERT INTO repos (user_id, name, url, default_branch, local_path, status,
+	                             clone_depth, clone_blobless, clone_single_branch, clone_sparse_paths)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	          RETURNING ` + repoColumns
 
-	var repo domain.Repo
-	err := s.db.QueryRowContext(ctx, query,
+	opts := r.CloneOptions
+	repo, err := scanRepo(s.db.QueryRowContext(ctx, query,
 		r.UserID, r.Name, r.URL, r.DefaultBranch, r.LocalPath, r.Status,
-	).Scan(
-		&repo.ID, &repo.UserID, &repo.Name, &repo.URL, &repo.DefaultBranch,
-		&repo.LocalPath, &repo.Status, &repo.ReportLanguage, &repo.CreatedAt, &repo.UpdatedAt,
-	)
+		opts.Depth, opts.Blobless, opts.SingleBranch, pq.Array(nonNilStrings(opts.SparsePaths)),
+	))
 	if err != nil {
 		return nil, fmt.Errorf("create repo: %w", err)
 	}
-	return &repo, nil
+	return repo, nil
 }
 
 // GetRepoByID returns a repo by its ID.
 func (s *PostgresStore) GetRepoByID(repoID string) (*domain.Repo, error) {
-	query := `SELECT id, user_id, name, url, default_branch, local_path, status, report_language, created_at, updated_at
-	          FROM repos WHERE id = $1`
+	query := `SELECT ` + repoColumns + ` FROM repos WHERE id = $1`
 
-	var r domain.Repo
-	err := s.db.QueryRow(query, repoID).Scan(
-		&r.ID, &r.UserID, &r.Name, &r.URL, &r.DefaultBranch,
-		&r.LocalPath, &r.Status, &r.ReportLanguage, &r.CreatedAt, &r.UpdatedAt,
-	)
+	r, err := scanRepo(s.db.QueryRow(query, repoID))
 	if err != nil {
 		return nil, fmt.Errorf("get repo: %w", err)
 	}
-	return &r, nil
+	return r, nil
+}
+
+// ListReposByURLPath returns every user's repos whose URL has exactly the lowercase path
+// (e.g. "owner/name"), ignoring case, a ".git" suffix and trailing slashes. Hosts are
+// not compared; callers compare normalized URLs for that.
+func (s *PostgresStore) ListReposByURLPath(ctx context.Context, path string) ([]domain.Repo, error) {
+	query := `SELECT ` + repoColumns + ` FROM repos
+	          WHERE right(lower(regexp_replace(url, '(\.git)?/*$', '')), length($1) + 1) IN ('/' || $1, ':' || $1)
+	          ORDER BY created_at`
+
+	rows, err := s.db.QueryContext(ctx, query, path)
+	if err != nil {
+		return nil, fmt.Errorf("list repos by url: %w", err)
+	}
+	defer rows.Close()
+
+	return scanRepos(rows)
+}
+
+// SetRepoWebhookSecret replaces the webhook secret of a repo.
+func (s *PostgresStore) SetRepoWebhookSecret(ctx context.Context, id, secret string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE repos SET webhook_secret = $1 WHERE id = $2`, secret, id)
+	if err != nil {
+		return fmt.Errorf("set webhook secret: %w", err)
+	}
+	return nil
 }
 
 // ListReposByUser returns all repos for a user.
 func (s *PostgresStore) ListReposByUser(ctx context.Context, userID string) ([]domain.Repo, error) {
-	query := `SELECT id, user_id, name, url, default_branch, local_path, status, report_language, created_at, updated_at
-	          FROM repos WHERE user_id = $1 ORDER BY created_at DESC`
+	query := `SELECT ` + repoColumns + ` FROM repos WHERE user_id = $1 ORDER BY created_at DESC`
 
 	rows, err := s.db.QueryContext(ctx, query, userID)
 	if err != nil {
@@ -139,18 +160,7 @@ func (s *PostgresStore) ListReposByUser(ctx context.Context, userID string) ([]d
 	}
 	defer rows.Close()
 
-	var repos []domain.Repo
-	for rows.Next() {
-		var r domain.Repo
-		if err := rows.Scan(
-			&r.ID, &r.UserID, &r.Name, &r.URL, &r.DefaultBranch,
-			&r.LocalPath, &r.Status, &r.ReportLanguage, &r.CreatedAt, &r.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan repo: %w", err)
-		}
-		repos = append(repos, r)
-	}
-	return repos, nil
+	return scanRepos(rows)
 }
 
 // UpdateRepoStatus updates the status and local_path of a repo.
@@ -160,26 +170,217 @@ func (s *PostgresStore) UpdateRepoStatus(ctx context.Context, id, status, localP
 	return err
 }
 
+// UpdateRepoSync records the outcome of a sync: the HEAD reached and the error, if any.
+// A failed sync keeps the previous HEAD.
+func (s *PostgresStore) UpdateRepoSync(ctx context.Context, id, headCommit, syncErr string) error {
+	query := `UPDATE repos
+	          SET head_commit = COALESCE(NULLIF($1, ''), head_commit), sync_error = $2, last_synced_at = NOW()
+	          WHERE id = $3`
+	if _, err := s.db.ExecContext(ctx, query, headCommit, syncErr, id); err != nil {
+		return fmt.Errorf("update repo sync: %w", err)
+	}
+	return nil
+}
+
+// TrackedHead returns the HEAD commit recorded at a repo's last clone or sync ("" when
+// unknown) and the branch it tracks.
+func (s *PostgresStore) TrackedHead(ctx context.Context, repoID string) (commit, branch string, err error) {
+	err = s.db.QueryRowContext(ctx, `SELECT head_commit, default_branch FROM repos WHERE id = $1`, repoID).Scan(&commit, &branch)
+	if err == sql.ErrNoRows {
+		return "", "", port.ErrRepoNotFound
+	}
+	if err != nil {
+		return "", "", fmt.Errorf("tracked head: %w", err)
+	}
+	return commit, branch, nil
+}
+
+// SetRepoSyncInterval sets how often the background syncer fetches a repo (0 = never).
+func (s *PostgresStore) SetRepoSyncInterval(ctx context.Context, id string, minutes int) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE repos SET sync_interval_minutes = $1 WHERE id = $2`, minutes, id); err != nil {
+		return fmt.Errorf("set repo sync interval: %w", err)
+	}
+	return nil
+}
+
+// ListReposDueForSync returns ready repos with a sync interval whose last sync
+// (or clone, if never synced) is at least one interval old.
+func (s *PostgresStore) ListReposDueForSync(ctx context.Context) ([]domain.Repo, error) {
+	query := `SELECT ` + repoColumns + ` FROM repos
+	          WHERE status = $1 AND sync_interval_minutes > 0
+	            AND COALESCE(last_synced_at, created_at) + make_interval(mins => sync_interval_minutes) <= NOW()
+	          ORDER BY COALESCE(last_synced_at, created_at)`
+
+	rows, err := s.db.QueryContext(ctx, query, domain.RepoStatusReady)
+	if err != nil {
+		return nil, fmt.Errorf("list repos due for sync: %w", err)
+	}
+	defer rows.Close()
+	return scanRepos(rows)
+}
+
+// ListUnscrubbedRepos returns the repos whose clone may still hold credentials in its
+// origin URL.
+func (s *PostgresStore) ListUnscrubbedRepos(ctx context.Context) ([]domain.Repo, error) {
+	query := `SELECT ` + repoColumns + ` FROM repos WHERE NOT remote_scrubbed ORDER BY created_at`
+
+	rows, err := s.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("list unscrubbed repos: %w", err)
+	}
+	defer rows.Close()
+	return scanRepos(rows)
+}
+
+// SetRepoCloneOptions saves the options a repo is cloned with.
+func (s *PostgresStore) SetRepoCloneOptions(ctx context.Context, id string, opts domain.CloneOptions) error {
+	query := `UPDATE repos SET clone_depth = $1, clone_blobless = $2, clone_single_branch = $3, clone_sparse_paths = $4
+	          WHERE id = $5`
+	if _, err := s.db.ExecContext(ctx, query, opts.Depth, opts.Blobless, opts.SingleBranch, pq.Array(nonNilStrings(opts.SparsePaths)), id); err != nil {
+		return fmt.Errorf("set repo clone options: %w", err)
+	}
+	return nil
+}
+
+// MarkRepoScrubbed records that a repo's clone holds no credentials.
+func (s *PostgresStore) MarkRepoScrubbed(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE repos SET remote_scrubbed = TRUE WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("mark repo scrubbed: %w", err)
+	}
+	return nil
+}
+
+// repoColumns is the column list scanned by scanRepo.
+const repoColumns = `id, user_id, name, url, default_branch, local_path, status, report_language,
+	sync_interval_minutes, head_commit, last_synced_at, sync_error,
+	clone_depth, clone_blobless, clone_single_branch, clone_sparse_paths, disk_bytes, last_used_at,
+	webhook_secret, created_at, updated_at`
+
+// scanRepo scans a row selected with repoColumns.
+func scanRepo(row rowScanner) (*domain.Repo, error) {
+	var r domain.Repo
+	var lastSynced sql.NullTime
+	if err := row.Scan(
+		&r.ID, &r.UserID, &r.Name, &r.URL, &r.DefaultBranch, &r.LocalPath, &r.Status, &r.ReportLanguage,
+		&r.SyncInterval, &r.HeadCommit, &lastSynced, &r.SyncError,
+		&r.CloneOptions.Depth, &r.CloneOptions.Blobless, &r.CloneOptions.SingleBranch, pq.Array(&r.CloneOptions.SparsePaths),
+		&r.DiskBytes, &r.LastUsedAt, &r.WebhookSecret, &r.CreatedAt, &r.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if r.CloneOptions.SparsePaths == nil {
+		r.CloneOptions.SparsePaths = []string{}
+	}
+	if lastSynced.Valid {
+		r.LastSyncedAt = &lastSynced.Time
+	}
+	return &r, nil
+}
+
+// scanRepos scans all rows selected with repoColumns.
+func scanRepos(rows *sql.Rows) ([]domain.Repo, error) {
+	var repos []domain.Repo
+	for rows.Next() {
+		r, err := scanRepo(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan repo: %w", err)
+		}
+		repos = append(repos, *r)
+	}
+	return repos, rows.Err()
+}
+
 // --- Snapshots ---
 
+// snapshotColumns is the column list scanned by scanSnapshot.
+const snapshotColumns = `id, repo_id, commit_hash, branch, message, author, file_count,
+	chunk_count, failed_files, index_error, status, indexed_at, created_at`
+
 // CreateSnapshot creates a new snapshot record.
+// Creating a snapshot for a commit that already has one returns the existing record.
 func (s *PostgresStore) CreateSnapshot(ctx context.Context, snap *domain.Snapshot) (*domain.Snapshot, error) {
 	query := `INSERT INTO snapshots (repo_id, commit_hash, branch, message, author, file_count, status)
 	          VALUES ($1, $2, $3, $4, $5, $6, $7)
 	          ON CONFLICT (repo_id, commit_hash) DO UPDATE SET status = snapshots.status
-	          RETURNING id, repo_id, commit_hash, branch, message, author, file_count, status, created_at`
+	          RETURNING ` + snapshotColumns
 
-	var result domain.Snapshot
-	err := s.db.QueryRowContext(ctx, query,
+	result, err := scanSnapshot(s.db.QueryRowContext(ctx, query,
 		snap.RepoID, snap.CommitHash, snap.Branch, snap.Message, snap.Author, snap.FileCount, snap.Status,
-	).Scan(
-		&result.ID, &result.RepoID, &result.CommitHash, &result.Branch,
-		&result.Message, &result.Author, &result.FileCount, &result.Status, &result.CreatedAt,
-	)
+	))
 	if err != nil {
 		return nil, fmt.Errorf("create snapshot: %w", err)
 	}
-	return &result, nil
+	return result, nil
+}
+
+// GetSnapshot returns a snapshot by ID.
+func (s *PostgresStore) GetSnapshot(ctx context.Context, id string) (*domain.Snapshot, error) {
+	query := `SELECT ` + snapshotColumns + ` FROM snapshots WHERE id = $1`
+	snap, err := scanSnapshot(s.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, port.ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get snapshot: %w", err)
+	}
+	return snap, nil
+}
+
+// ListSnapshots returns a repo's snapshots, newest first.
+func (s *PostgresStore) ListSnapshots(ctx context.Context, repoID string, limit int) ([]domain.Snapshot, error) {
+	query := `SELECT ` + snapshotColumns + ` FROM snapshots
+	          WHERE repo_id = $1 ORDER BY created_at DESC LIMIT $2`
+
+	rows, err := s.db.QueryContext(ctx, query, repoID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	var snaps []domain.Snapshot
+	for rows.Next() {
+		snap, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan snapshot: %w", err)
+		}
+		snaps = append(snaps, *snap)
+	}
+	return snaps, rows.Err()
+}
+
+// UpdateSnapshotStatus sets the status and file count of a snapshot.
+func (s *PostgresStore) UpdateSnapshotStatus(ctx context.Context, id, status string, fileCount int) error {
+	query := `UPDATE snapshots SET status = $1, file_count = $2 WHERE id = $3`
+	_, err := s.db.ExecContext(ctx, query, status, fileCount, id)
+	return err
+}
+
+// UpdateSnapshotIndex records the outcome of indexing a snapshot for RAG.
+func (s *PostgresStore) UpdateSnapshotIndex(ctx context.Context, id, status string, files, chunks, failed int, indexErr string) error {
+	query := `UPDATE snapshots
+	          SET status = $1, file_count = $2, chunk_count = $3, failed_files = $4, index_error = $5, indexed_at = NOW()
+	          WHERE id = $6`
+	if _, err := s.db.ExecContext(ctx, query, status, files, chunks, failed, indexErr, id); err != nil {
+		return fmt.Errorf("update snapshot index: %w", err)
+	}
+	return nil
+}
+
+// scanSnapshot scans a row selected with snapshotColumns.
+func scanSnapshot(row rowScanner) (*domain.Snapshot, error) {
+	var snap domain.Snapshot
+	var indexedAt sql.NullTime
+	err := row.Scan(
+		&snap.ID, &snap.RepoID, &snap.CommitHash, &snap.Branch, &snap.Message, &snap.Author, &snap.FileCount,
+		&snap.ChunkCount, &snap.FailedFiles, &snap.IndexError, &snap.Status, &indexedAt, &snap.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if indexedAt.Valid {
+		snap.IndexedAt = &indexedAt.Time
+	}
+	return &snap, nil
 }
 
 // --- Audit Logs ---
@@ -329,7 +530,7 @@ func (s *PostgresStore) ListAllAnalysisResults(ctx context.Context, userID strin
 // SearchReposByUser searches repos by name or url using ILIKE, scoped to a user.
 func (s *PostgresStore) SearchReposByUser(ctx context.Context, userID, query string) ([]domain.Repo, error) {
 	pattern := "%" + query + "%"
-	sqlQuery := `SELECT id, user_id, name, url, default_branch, local_path, status, report_language, created_at, updated_at
+	sqlQuery := `SELECT ` + repoColumns + `
 	             FROM repos
 	             WHERE user_id = $1 AND (name ILIKE $2 OR url ILIKE $2)
 	             ORDER BY created_at DESC`
@@ -340,18 +541,7 @@ func (s *PostgresStore) SearchReposByUser(ctx context.Context, userID, query str
 	}
 	defer rows.Close()
 
-	var repos []domain.Repo
-	for rows.Next() {
-		var r domain.Repo
-		if err := rows.Scan(
-			&r.ID, &r.UserID, &r.Name, &r.URL, &r.DefaultBranch,
-			&r.LocalPath, &r.Status, &r.ReportLanguage, &r.CreatedAt, &r.UpdatedAt,
-		); err != nil {
-			return nil, fmt.Errorf("scan repo: %w", err)
-		}
-		repos = append(repos, r)
-	}
-	return repos, nil
+	return scanRepos(rows)
 }
 
 // SearchAnalysisResults searches analysis results by strategy or summary, scoped to a user's repos.
@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// --- Reviews ---
+
+// reviewColumns is the column list scanned by scanReview.
+const reviewColumns = `id, repo_id, provider, number, title, url, base_sha, head_sha, status, summary, error,
+	finding_count, created_at, completed_at, comments_posted_at, comments_error`
+
+// StartReview records a running review of a pull request revision. A revision is
+// reviewed once: if a review of it exists and did not fail, nil is returned; a failed
+// review is reset and started again.
+func (s *PostgresStore) StartReview(ctx context.Context, r *domain.Review) (*domain.Review, error) {
+	query := `INSERT INTO reviews (repo_id, provider, number, title, url, head_sha)
+	          VALUES ($1, $2, $3, $4, $5, $6)
+	          ON CONFLICT (repo_id, provider, number, head_sha) DO UPDATE
+	          SET status = 'running', error = '', summary = '', title = EXCLUDED.title,
+	              created_at = NOW(), completed_at = NULL
+	          WHERE reviews.status = 'failed'
+	          RETURNING ` + reviewColumns
+
+	started, err := scanReview(s.db.QueryRowContext(ctx, query, r.RepoID, r.Provider, r.Number, r.Title, r.URL, r.HeadSHA))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("start review: %w", err)
+	}
+	return started, nil
+}
+
+// CompleteReview stores the findings of a review and marks it complete.
+func (s *PostgresStore) CompleteReview(ctx context.Context, id, baseSHA, summary string, findings []domain.Finding) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO review_findings (review_id, repo_id, file_path, line, severity, title, body)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+	if err != nil {
+		return fmt.Errorf("prepare: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, f := range findings {
+		if _, err := stmt.ExecContext(ctx, id, f.RepoID, f.FilePath, f.Line, f.Severity, f.Title, f.Body); err != nil {
+			return fmt.Errorf("insert finding: %w", err)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE reviews
+		SET status = 'complete', base_sha = $1, summary = $2, finding_count = $3, completed_at = NOW()
+		WHERE id = $4`, baseSHA, summary, len(findings), id); err != nil {
+		return fmt.Errorf("complete review: %w", err)
+	}
+	return tx.Commit()
+}
+
+// FailReview marks a review failed with the error that stopped it.
+func (s *PostgresStore) FailReview(ctx context.Context, id, reviewErr string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE reviews SET status = 'failed', error = $1, completed_at = NOW() WHERE id = $2`, reviewErr, id)
+	if err != nil {
+		return fmt.Errorf("fail review: %w", err)
+	}
+	return nil
+}
+
+// RecordReviewComments records the outcome of posting a review's findings to its pull
+// request: the post time on success, the error otherwise.
+func (s *PostgresStore) RecordReviewComments(ctx context.Context, id, postErr string) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE reviews
+		SET comments_posted_at = CASE WHEN $1 = '' THEN NOW() ELSE comments_posted_at END, comments_error = $1
+		WHERE id = $2`, postErr, id)
+	if err != nil {
+		return fmt.Errorf("record review comments: %w", err)
+	}
+	return nil
+}
+
+// GetReview returns a review of a repo by ID.
+func (s *PostgresStore) GetReview(ctx context.Context, repoID, id string) (*domain.Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE id = $1 AND repo_id = $2`
+	r, err := scanReview(s.db.QueryRowContext(ctx, query, id, repoID))
+	if err != nil {
+		return nil, fmt.Errorf("get review: %w", err)
+	}
+	return r, nil
+}
+
+// ListReviews returns a repo's most recent reviews, newest first.
+func (s *PostgresStore) ListReviews(ctx context.Context, repoID string, limit int) ([]domain.Review, error) {
+	query := `SELECT ` + reviewColumns + ` FROM reviews WHERE repo_id = $1 ORDER BY created_at DESC LIMIT $2`
+	rows, err := s.db.QueryContext(ctx, query, repoID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("list reviews: %w", err)
+	}
+	defer rows.Close()
+
+	var reviews []domain.Review
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan review: %w", err)
+		}
+		reviews = append(reviews, *r)
+	}
+	return reviews, rows.Err()
+}
+
+// ListFindings returns the findings of a review, most severe first.
+func (s *PostgresStore) ListFindings(ctx context.Context, reviewID string) ([]domain.Finding, error) {
+	return s.queryFindings(ctx, `SELECT `+findingColumns+` FROM review_findings
+		WHERE review_id = $1
+		ORDER BY array_position(ARRAY['critical','high','medium','low','info'], severity), file_path, line`, reviewID)
+}
+
+// findingColumns is the column list scanned by queryFindings.
+const findingColumns = `id, review_id, repo_id, file_path, line, severity, title, body, created_at,
+	issue_tracker, issue_key, issue_url, issue_state, issue_synced_at`
+
+// queryFindings runs a query selecting findingColumns.
+func (s *PostgresStore) queryFindings(ctx context.Context, query string, args ...interface{}) ([]domain.Finding, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list findings: %w", err)
+	}
+	defer rows.Close()
+
+	var findings []domain.Finding
+	for rows.Next() {
+		var f domain.Finding
+		var link domain.IssueLink
+		var synced sql.NullTime
+		if err := rows.Scan(&f.ID, &f.ReviewID, &f.RepoID, &f.FilePath, &f.Line, &f.Severity, &f.Title, &f.Body, &f.CreatedAt,
+			&link.Tracker, &link.Key, &link.URL, &link.State, &synced); err != nil {
+			return nil, fmt.Errorf("scan finding: %w", err)
+		}
+		if link.Key != "" {
+			f.Issue = &link
+		}
+		if synced.Valid {
+			f.IssueSyncedAt = &synced.Time
+		}
+		findings = append(findings, f)
+	}
+	return findings, rows.Err()
+}
+
+// scanReview scans a row selected with reviewColumns.
+func scanReview(row rowScanner) (*domain.Review, error) {
+	var r domain.Review
+	var completed, posted sql.NullTime
+	err := row.Scan(&r.ID, &r.RepoID, &r.Provider, &r.Number, &r.Title, &r.URL, &r.BaseSHA, &r.HeadSHA, &r.Status,
+		&r.Summary, &r.Error, &r.FindingCount, &r.CreatedAt, &completed, &posted, &r.CommentsError)
+	if err != nil {
+		return nil, err
+	}
+	if completed.Valid {
+		r.CompletedAt = &completed.Time
+	}
+	if posted.Valid {
+		r.CommentsPostedAt = &posted.Time
+	}
+	return &r, nil
+}
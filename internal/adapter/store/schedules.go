@@ -0,0 +1,175 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/lib/pq"
+)
+
+// --- Schedules ---
+
+// scheduleColumns is the column list scanned by scanSchedule.
+const scheduleColumns = `id, repo_id, strategies, cron, timezone, sync_before_run, enabled,
+	next_run_at, last_run_at, last_commit, last_job_id, last_status, last_error, created_at, updated_at`
+
+// CreateSchedule inserts a schedule and returns the stored record.
+func (s *PostgresStore) CreateSchedule(ctx context.Context, sch *domain.Schedule) (*domain.Schedule, error) {
+	query := `INSERT INTO schedules (repo_id, strategies, cron, timezone, sync_before_run, enabled, next_run_at)
+	          VALUES ($1, $2, $3, $4, $5, $6, $7)
+	          RETURNING ` + scheduleColumns
+
+	created, err := scanSchedule(s.db.QueryRowContext(ctx, query,
+		sch.RepoID, pq.Array(nonNilStrings(sch.Strategies)), sch.Cron, sch.Timezone, sch.SyncBeforeRun, sch.Enabled, sch.NextRunAt,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create schedule: %w", err)
+	}
+	return created, nil
+}
+
+// GetSchedule returns a schedule of a repo by ID.
+func (s *PostgresStore) GetSchedule(ctx context.Context, repoID, id string) (*domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE id = $1 AND repo_id = $2`
+	sch, err := scanSchedule(s.db.QueryRowContext(ctx, query, id, repoID))
+	if err != nil {
+		return nil, fmt.Errorf("get schedule: %w", err)
+	}
+	return sch, nil
+}
+
+// ListSchedulesByRepo returns a repo's schedules, oldest first.
+func (s *PostgresStore) ListSchedulesByRepo(ctx context.Context, repoID string) ([]domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules WHERE repo_id = $1 ORDER BY created_at`
+	return s.querySchedules(ctx, query, repoID)
+}
+
+// ListDueSchedules returns enabled schedules whose next run is at or before now.
+func (s *PostgresStore) ListDueSchedules(ctx context.Context, now time.Time) ([]domain.Schedule, error) {
+	query := `SELECT ` + scheduleColumns + ` FROM schedules
+	          WHERE enabled AND next_run_at IS NOT NULL AND next_run_at <= $1
+	          ORDER BY next_run_at`
+	return s.querySchedules(ctx, query, now)
+}
+
+// UpdateSchedule saves the editable fields of a schedule and its next run time.
+func (s *PostgresStore) UpdateSchedule(ctx context.Context, sch *domain.Schedule) (*domain.Schedule, error) {
+	query := `UPDATE schedules
+	          SET strategies = $1, cron = $2, timezone = $3, sync_before_run = $4, enabled = $5,
+	              next_run_at = $6, updated_at = NOW()
+	          WHERE id = $7 AND repo_id = $8
+	          RETURNING ` + scheduleColumns
+
+	updated, err := scanSchedule(s.db.QueryRowContext(ctx, query,
+		pq.Array(nonNilStrings(sch.Strategies)), sch.Cron, sch.Timezone, sch.SyncBeforeRun, sch.Enabled, sch.NextRunAt, sch.ID, sch.RepoID,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("update schedule: %w", err)
+	}
+	return updated, nil
+}
+
+// RecordScheduleRun stores the outcome of a run and when the schedule runs next.
+// commit, the HEAD a started run analyzes, and jobID are only recorded when set; the
+// commit becomes the last analyzed HEAD once FinishScheduleRun sees the job complete.
+func (s *PostgresStore) RecordScheduleRun(ctx context.Context, id, status, commit, jobID, runErr string, nextRun *time.Time) error {
+	query := `UPDATE schedules
+	          SET last_run_at = NOW(), last_status = $1, last_error = $2,
+	              run_commit = COALESCE(NULLIF($3, ''), run_commit),
+	              last_job_id = COALESCE(NULLIF($4, ''), last_job_id),
+	              next_run_at = $5
+	          WHERE id = $6`
+	if _, err := s.db.ExecContext(ctx, query, status, runErr, commit, jobID, nextRun, id); err != nil {
+		return fmt.Errorf("record schedule run: %w", err)
+	}
+	return nil
+}
+
+// FinishScheduleRun records the end of the analysis job a schedule run started: on
+// success (runErr empty) the run's HEAD becomes the last analyzed one. status replaces
+// a last status that is still "started". Jobs no schedule started match nothing.
+func (s *PostgresStore) FinishScheduleRun(ctx context.Context, jobID, status, runErr string) error {
+	query := `UPDATE schedules
+	          SET last_commit = CASE WHEN $3 = '' THEN run_commit ELSE last_commit END,
+	              last_status = CASE WHEN last_status = $4 THEN $2 ELSE last_status END,
+	              last_error  = CASE WHEN last_status = $4 THEN $3 ELSE last_error END
+	          WHERE last_job_id = $1`
+	if _, err := s.db.ExecContext(ctx, query, jobID, status, runErr, domain.ScheduleRunStarted); err != nil {
+		return fmt.Errorf("finish schedule run: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes a schedule of a repo. Returns sql.ErrNoRows if it does not exist.
+func (s *PostgresStore) DeleteSchedule(ctx context.Context, repoID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = $1 AND repo_id = $2`, id, repoID)
+	if err != nil {
+		return fmt.Errorf("delete schedule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// querySchedules runs a query selecting scheduleColumns.
+func (s *PostgresStore) querySchedules(ctx context.Context, query string, args ...interface{}) ([]domain.Schedule, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("list schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []domain.Schedule
+	for rows.Next() {
+		sch, err := scanSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan schedule: %w", err)
+		}
+		schedules = append(schedules, *sch)
+	}
+	return schedules, rows.Err()
+}
+
+// scanSchedule scans a row selected with scheduleColumns.
+func scanSchedule(row rowScanner) (*domain.Schedule, error) {
+	var sch domain.Schedule
+	var nextRun, lastRun sql.NullTime
+	err := row.Scan(
+		&sch.ID, &sch.RepoID, pq.Array(&sch.Strategies), &sch.Cron, &sch.Timezone, &sch.SyncBeforeRun, &sch.Enabled,
+		&nextRun, &lastRun, &sch.LastCommit, &sch.LastJobID, &sch.LastStatus, &sch.LastError, &sch.CreatedAt, &sch.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if nextRun.Valid {
+		sch.NextRunAt = &nextRun.Time
+	}
+	if lastRun.Valid {
+		sch.LastRunAt = &lastRun.Time
+	}
+	if sch.Strategies == nil {
+		sch.Strategies = []string{}
+	}
+	return &sch, nil
+}
+
+// nonNilStrings returns an empty slice for nil, so that pq.Array stores '{}' instead of NULL.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
+
+// nonNilBytes returns an empty slice for nil, so that a NOT NULL BYTEA column gets an
+// empty value instead of NULL.
+func nonNilBytes(b []byte) []byte {
+	if b == nil {
+		return []byte{}
+	}
+	return b
+}
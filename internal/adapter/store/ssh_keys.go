@@ -0,0 +1,106 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// --- SSH Keys ---
+
+// sshKeyColumns is the column list scanned by scanSSHKey.
+const sshKeyColumns = `id, user_id, COALESCE(repo_id::text, ''), name, key_type, public_key, fingerprint,
+	private_key_encrypted, last_used_at, created_at`
+
+// CreateSSHKey inserts a key pair; PrivateKey must already be encrypted.
+func (s *PostgresStore) CreateSSHKey(ctx context.Context, key *domain.SSHKey) (*domain.SSHKey, error) {
+	query := `INSERT INTO ssh_keys (user_id, repo_id, name, key_type, public_key, fingerprint, private_key_encrypted)
+	          VALUES ($1, NULLIF($2, '')::uuid, $3, $4, $5, $6, $7)
+	          RETURNING ` + sshKeyColumns
+
+	created, err := scanSSHKey(s.db.QueryRowContext(ctx, query,
+		key.UserID, key.RepoID, key.Name, key.KeyType, key.PublicKey, key.Fingerprint, key.PrivateKey,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("create ssh key: %w", err)
+	}
+	return created, nil
+}
+
+// ListSSHKeys returns a user's keys, newest first: the user keys when repoID is empty,
+// the deploy keys of the repo otherwise.
+func (s *PostgresStore) ListSSHKeys(ctx context.Context, userID, repoID string) ([]domain.SSHKey, error) {
+	query := `SELECT ` + sshKeyColumns + ` FROM ssh_keys
+	          WHERE user_id = $1 AND repo_id IS NOT DISTINCT FROM NULLIF($2, '')::uuid
+	          ORDER BY created_at DESC`
+	rows, err := s.db.QueryContext(ctx, query, userID, repoID)
+	if err != nil {
+		return nil, fmt.Errorf("list ssh keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []domain.SSHKey{}
+	for rows.Next() {
+		key, err := scanSSHKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan ssh key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	return keys, rows.Err()
+}
+
+// GetSSHKeyForRepo returns the key to clone a repo with: its newest deploy key, else the
+// newest user key of its owner. Returns sql.ErrNoRows if there is neither.
+func (s *PostgresStore) GetSSHKeyForRepo(ctx context.Context, repo *domain.Repo) (*domain.SSHKey, error) {
+	query := `SELECT ` + sshKeyColumns + ` FROM ssh_keys
+	          WHERE user_id = $1 AND (repo_id = $2::uuid OR repo_id IS NULL)
+	          ORDER BY repo_id IS NULL, created_at DESC
+	          LIMIT 1`
+	key, err := scanSSHKey(s.db.QueryRowContext(ctx, query, repo.UserID, repo.ID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get ssh key for repo: %w", err)
+	}
+	return key, nil
+}
+
+// TouchSSHKey records that a key was just used.
+func (s *PostgresStore) TouchSSHKey(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, `UPDATE ssh_keys SET last_used_at = NOW() WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("touch ssh key: %w", err)
+	}
+	return nil
+}
+
+// DeleteSSHKey removes a user's key. Returns sql.ErrNoRows if it does not exist.
+func (s *PostgresStore) DeleteSSHKey(ctx context.Context, userID, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM ssh_keys WHERE id = $1 AND user_id = $2`, id, userID)
+	if err != nil {
+		return fmt.Errorf("delete ssh key: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// scanSSHKey scans a row selected with sshKeyColumns.
+func scanSSHKey(row rowScanner) (*domain.SSHKey, error) {
+	var key domain.SSHKey
+	var lastUsed sql.NullTime
+	err := row.Scan(&key.ID, &key.UserID, &key.RepoID, &key.Name, &key.KeyType, &key.PublicKey, &key.Fingerprint,
+		&key.PrivateKey, &lastUsed, &key.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if lastUsed.Valid {
+		key.LastUsedAt = &lastUsed.Time
+	}
+	return &key, nil
+}
@@ -2,31 +2,62 @@ package store
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/lib/pq"
 )
 
+// insertEmbeddingSQL inserts one embedding row; the vector is passed in pgvector text format.
+const insertEmbeddingSQL = `INSERT INTO embeddings (snapshot_id, repo_id, file_path, chunk_index, content, language,
+	                                symbol_name, symbol_kind, parent_symbol, start_line, end_line, commit_hash, content_hash, index_id, vector)
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15::vector)`
+
+// chunkColumns is the select list shared by the search queries, in scanSimilarChunks order.
+const chunkColumns = `e.id, e.snapshot_id, e.repo_id, e.file_path, e.chunk_index, e.content, e.language,
+	e.symbol_name, e.symbol_kind, e.parent_symbol, e.start_line, e.end_line, e.commit_hash, e.created_at`
+
 // VectorStore handles pgvector-specific operations for embeddings.
+// Reads and writes go to the active embedding index (see EnsureActiveIndex).
 type VectorStore struct {
 	store     *PostgresStore
-	dimension int
+	dimension int // configured dimension, used when the first index is created
+	active    atomic.Pointer[domain.EmbeddingIndex]
+	indexCfg  VectorIndexConfig
+	indexMu   sync.Mutex // serializes ANN index builds
 }
 
 // NewVectorStore creates a vector store backed by the given Postgres store.
-func NewVectorStore(store *PostgresStore, dimension int) *VectorStore {
-	return &VectorStore{store: store, dimension: dimension}
+// An empty indexCfg.Type selects HNSW.
+func NewVectorStore(store *PostgresStore, dimension int, indexCfg VectorIndexConfig) *VectorStore {
+	if indexCfg.Type != VectorIndexIVFFlat {
+		indexCfg.Type = VectorIndexHNSW
+	}
+	return &VectorStore{store: store, dimension: dimension, indexCfg: indexCfg}
 }
 
-// StoreEmbedding persists a single embedding record with its vector.
+// StoreEmbedding persists a single embedding record with its vector in the active index.
 func (v *VectorStore) StoreEmbedding(ctx context.Context, e *domain.Embedding) error {
-	vectorStr := vectorToString(e.Vector)
-	query := `INSERT INTO embeddings (snapshot_id, repo_id, file_path, chunk_index, content, language, vector)
-	          VALUES ($1, $2, $3, $4, $5, $6, $7::vector)`
+	idx, err := v.activeIndex()
+	if err != nil {
+		return err
+	}
+	if err := checkDimension(idx, e.Vector); err != nil {
+		return err
+	}
 
-	_, err := v.store.db.ExecContext(ctx, query,
-		e.SnapshotID, e.RepoID, e.FilePath, e.ChunkIndex, e.Content, e.Language, vectorStr,
+	vectorStr := vectorToString(e.Vector)
+	_, err = v.store.db.ExecContext(ctx, insertEmbeddingSQL,
+		e.SnapshotID, e.RepoID, e.FilePath, e.ChunkIndex, e.Content, e.Language,
+		e.SymbolName, e.SymbolKind, e.ParentSymbol, e.StartLine, e.EndLine, e.CommitHash, e.ContentHash, idx.ID, vectorStr,
 	)
 	if err != nil {
 		return fmt.Errorf("store embedding: %w", err)
@@ -34,21 +65,30 @@ func (v *VectorStore) StoreEmbedding(ctx context.Context, e *domain.Embedding) e
 	return nil
 }
 
-// StoreBatchEmbeddings persists multiple embeddings efficiently.
+// StoreBatchEmbeddings persists multiple embeddings efficiently in the active index.
+// The batch is rejected if any vector does not match the index dimension.
 func (v *VectorStore) StoreBatchEmbeddings(ctx context.Context, embeddings []domain.Embedding) error {
 	if len(embeddings) == 0 {
 		return nil
 	}
 
+	idx, err := v.activeIndex()
+	if err != nil {
+		return err
+	}
+	for i := range embeddings {
+		if err := checkDimension(idx, embeddings[i].Vector); err != nil {
+			return fmt.Errorf("%s chunk %d: %w", embeddings[i].FilePath, embeddings[i].ChunkIndex, err)
+		}
+	}
+
 	tx, err := v.store.db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("begin tx: %w", err)
 	}
 	defer tx.Rollback()
 
-	stmt, err := tx.PrepareContext(ctx,
-		`INSERT INTO embeddings (snapshot_id, repo_id, file_path, chunk_index, content, language, vector)
-		 VALUES ($1, $2, $3, $4, $5, $6, $7::vector)`)
+	stmt, err := tx.PrepareContext(ctx, insertEmbeddingSQL)
 	if err != nil {
 		return fmt.Errorf("prepare: %w", err)
 	}
@@ -57,7 +97,8 @@ func (v *VectorStore) StoreBatchEmbeddings(ctx context.Context, embeddings []dom
 	for _, e := range embeddings {
 		vectorStr := vectorToString(e.Vector)
 		if _, err := stmt.ExecContext(ctx,
-			e.SnapshotID, e.RepoID, e.FilePath, e.ChunkIndex, e.Content, e.Language, vectorStr,
+			e.SnapshotID, e.RepoID, e.FilePath, e.ChunkIndex, e.Content, e.Language,
+			e.SymbolName, e.SymbolKind, e.ParentSymbol, e.StartLine, e.EndLine, e.CommitHash, e.ContentHash, idx.ID, vectorStr,
 		); err != nil {
 			return fmt.Errorf("insert embedding: %w", err)
 		}
@@ -66,34 +107,393 @@ func (v *VectorStore) StoreBatchEmbeddings(ctx context.Context, embeddings []dom
 	return tx.Commit()
 }
 
-// SearchSimilar performs a cosine similarity search on embeddings.
-func (v *VectorStore) SearchSimilar(ctx context.Context, repoID string, queryVector []float32, limit int) ([]domain.SimilarChunk, error) {
-	vectorStr := vectorToString(queryVector)
-	query := `SELECT e.id, e.snapshot_id, e.repo_id, e.file_path, e.chunk_index, e.content, e.language, e.created_at,
-	                 1 - (e.vector <=> $1::vector) AS similarity
+// CopyEmbeddings bulk-loads embeddings into idx, the index whose model embedded them,
+// with COPY, which is much faster than row inserts for large snapshots. All vectors are
+// validated first. An index that is no longer active returns port.ErrIndexChanged.
+func (v *VectorStore) CopyEmbeddings(ctx context.Context, idx *domain.EmbeddingIndex, embeddings []domain.Embedding) error {
+	if len(embeddings) == 0 {
+		return nil
+	}
+
+	active, err := v.activeIndex()
+	if err != nil {
+		return err
+	}
+	if active.ID != idx.ID {
+		return port.ErrIndexChanged
+	}
+	for i := range embeddings {
+		if err := checkDimension(idx, embeddings[i].Vector); err != nil {
+			return fmt.Errorf("%s chunk %d: %w", embeddings[i].FilePath, embeddings[i].ChunkIndex, err)
+		}
+	}
+
+	tx, err := v.store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.PrepareContext(ctx, pq.CopyIn("embeddings",
+		"snapshot_id", "repo_id", "file_path", "chunk_index", "content", "language",
+		"symbol_name", "symbol_kind", "parent_symbol", "start_line", "end_line", "commit_hash", "content_hash", "index_id", "vector"))
+	if err != nil {
+		return fmt.Errorf("prepare copy: %w", err)
+	}
+
+	for _, e := range embeddings {
+		if _, err := stmt.ExecContext(ctx,
+			e.SnapshotID, e.RepoID, e.FilePath, e.ChunkIndex, e.Content, e.Language,
+			e.SymbolName, e.SymbolKind, e.ParentSymbol, e.StartLine, e.EndLine, e.CommitHash, e.ContentHash, idx.ID, vectorToString(e.Vector),
+		); err != nil {
+			stmt.Close()
+			return fmt.Errorf("copy embedding: %w", err)
+		}
+	}
+	if _, err := stmt.ExecContext(ctx); err != nil {
+		stmt.Close()
+		return fmt.Errorf("flush copy: %w", err)
+	}
+	if err := stmt.Close(); err != nil {
+		return fmt.Errorf("close copy: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// SearchSimilar performs a cosine similarity search over the chunks matching f.
+func (v *VectorStore) SearchSimilar(ctx context.Context, f domain.SearchFilter, queryVector []float32) ([]domain.SimilarChunk, error) {
+	idx, err := v.activeIndex()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDimension(idx, queryVector); err != nil {
+		return nil, err
+	}
+
+	where, args := filterClause(f, idx, []interface{}{vectorToString(queryVector)})
+	args = append(args, f.Limit, f.Offset)
+	query := `SELECT ` + chunkColumns + `, 1 - ` + distanceExpr(idx) + ` AS similarity
 	          FROM embeddings e
-	          WHERE e.repo_id = $2
-	          ORDER BY e.vector <=> $1::vector
-	          LIMIT $3`
+	          WHERE ` + where + `
+	          ORDER BY ` + distanceExpr(idx) + `
+	          LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	tx, err := v.searchTx(ctx, f.Offset+f.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
-	rows, err := v.store.db.QueryContext(ctx, query, vectorStr, repoID, limit)
+	rows, err := tx.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("search similar: %w", err)
 	}
 	defer rows.Close()
 
-	var results []domain.SimilarChunk
+	return scanSimilarChunks(rows)
+}
+
+// SearchLexical performs a full-text search over the chunks matching f.
+// Query terms are OR-ed together and ranked by cover density, so exact identifiers
+// and error strings surface even when their embeddings are not nearby.
+// The query vector is only used to report a similarity for each hit.
+func (v *VectorStore) SearchLexical(ctx context.Context, f domain.SearchFilter, queryText string, queryVector []float32) ([]domain.SimilarChunk, error) {
+	tsQuery := lexicalQuery(queryText)
+	if tsQuery == "" {
+		return nil, nil
+	}
+
+	idx, err := v.activeIndex()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkDimension(idx, queryVector); err != nil {
+		return nil, err
+	}
+
+	where, args := filterClause(f, idx, []interface{}{vectorToString(queryVector), tsQuery})
+	args = append(args, f.Limit, f.Offset)
+	query := `SELECT ` + chunkColumns + `, 1 - ` + distanceExpr(idx) + ` AS similarity
+	          FROM embeddings e
+	          WHERE e.content_tsv @@ to_tsquery('simple', $2) AND ` + where + `
+	          ORDER BY ts_rank_cd(e.content_tsv, to_tsquery('simple', $2)) DESC
+	          LIMIT $` + strconv.Itoa(len(args)-1) + ` OFFSET $` + strconv.Itoa(len(args))
+
+	rows, err := v.store.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("search lexical: %w", err)
+	}
+	defer rows.Close()
+
+	return scanSimilarChunks(rows)
+}
+
+// SearchHybrid runs vector and lexical searches and fuses both rankings with
+// reciprocal rank fusion. lexicalWeight (0–1) is the share given to the lexical
+// ranking; the vector ranking gets the remainder. f.Limit/f.Offset page the fused list.
+func (v *VectorStore) SearchHybrid(ctx context.Context, f domain.SearchFilter, queryText string, queryVector []float32, lexicalWeight float64) ([]domain.SimilarChunk, error) {
+	// Over-fetch from each side so fusion has something to reorder
+	candidates := f
+	candidates.Limit = (f.Offset + f.Limit) * 3
+	candidates.Offset = 0
+
+	vectorHits, err := v.SearchSimilar(ctx, candidates, queryVector)
+	if err != nil {
+		return nil, err
+	}
+	lexicalHits, err := v.SearchLexical(ctx, candidates, queryText, queryVector)
+	if err != nil {
+		return nil, err
+	}
+
+	return FuseRRF(
+		[][]domain.SimilarChunk{vectorHits, lexicalHits},
+		[]float64{1 - lexicalWeight, lexicalWeight},
+		f.Offset, f.Limit,
+	), nil
+}
+
+// distanceExpr is the cosine distance between a row and the query vector ($1), cast to
+// the index dimension so the planner can use that dimension's partial ANN index.
+func distanceExpr(idx *domain.EmbeddingIndex) string {
+	return fmt.Sprintf("(e.vector::vector(%[1]d) <=> $1::vector(%[1]d))", idx.Dimension)
+}
+
+// filterClause renders f as a WHERE condition over the rows of index idx in embeddings e,
+// appending its arguments after the ones already in args (the query vector is always $1).
+// Without an explicit snapshot or commit, each repo is searched at its latest snapshot.
+func filterClause(f domain.SearchFilter, idx *domain.EmbeddingIndex, args []interface{}) (string, []interface{}) {
+	arg := func(val interface{}) string {
+		args = append(args, val)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	index := arg(idx.ID)
+	repos := arg(pq.Array(f.RepoIDs))
+	conds := []string{
+		"e.index_id = " + index,
+		"vector_dims(e.vector) = " + strconv.Itoa(idx.Dimension),
+		"e.repo_id = ANY(" + repos + ")",
+	}
+
+	switch {
+	case f.SnapshotID != "":
+		conds = append(conds, "e.snapshot_id = "+arg(f.SnapshotID))
+	case f.CommitHash != "":
+		// A plain prefix comparison: LIKE would treat '%' and '_' in the input as wildcards
+		commit := arg(f.CommitHash)
+		conds = append(conds, `e.snapshot_id IN (
+			SELECT s.id FROM snapshots s
+			WHERE s.repo_id = ANY(`+repos+`) AND left(s.commit_hash, length(`+commit+`)) = `+commit+`)`)
+	default:
+		conds = append(conds, `e.snapshot_id IN (
+			SELECT DISTINCT ON (s.repo_id) s.id FROM snapshots s
+			JOIN repos r ON r.id = s.repo_id
+			WHERE s.repo_id = ANY(`+repos+`)
+			  AND EXISTS (SELECT 1 FROM embeddings x WHERE x.snapshot_id = s.id AND x.index_id = `+index+`)
+			ORDER BY s.repo_id, `+servingOrder+`)`)
+	}
+
+	if f.Language != "" {
+		conds = append(conds, "e.language = "+arg(f.Language))
+	}
+	if f.SymbolKind != "" {
+		conds = append(conds, "e.symbol_kind = "+arg(f.SymbolKind))
+	}
+	if f.PathGlob != "" {
+		conds = append(conds, "e.file_path ~ "+arg(globToRegexp(f.PathGlob)))
+	}
+	if f.MinSimilarity > 0 {
+		conds = append(conds, "1 - "+distanceExpr(idx)+" >= "+arg(f.MinSimilarity))
+	}
+
+	return strings.Join(conds, " AND "), args
+}
+
+// globToRegexp converts a path glob to an anchored POSIX regex.
+// "*" and "?" stay within one path segment; "**" spans directories ("**/" also matches none).
+func globToRegexp(glob string) string {
+	var sb strings.Builder
+	sb.WriteString("^")
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; {
+		case c == '*' && strings.HasPrefix(glob[i:], "**/"):
+			sb.WriteString("(.*/)?")
+			i += 2
+		case c == '*' && strings.HasPrefix(glob[i:], "**"):
+			sb.WriteString(".*")
+			i++
+		case c == '*':
+			sb.WriteString("[^/]*")
+		case c == '?':
+			sb.WriteString("[^/]")
+		default:
+			sb.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	sb.WriteString("$")
+	return sb.String()
+}
+
+// rrfK dampens the contribution of top ranks in reciprocal rank fusion (the usual value from the RRF paper).
+const rrfK = 60
+
+// FuseRRF merges ranked lists with weighted reciprocal rank fusion and returns one page
+// of the result. weights[i] applies to lists[i]; each chunk's Score is its fused score.
+func FuseRRF(lists [][]domain.SimilarChunk, weights []float64, offset, limit int) []domain.SimilarChunk {
+	byID := make(map[string]*domain.SimilarChunk)
+	var order []string
+
+	for li, hits := range lists {
+		for rank, hit := range hits {
+			sc, ok := byID[hit.ID]
+			if !ok {
+				h := hit
+				h.Score = 0
+				sc = &h
+				byID[hit.ID] = sc
+				order = append(order, hit.ID)
+			}
+			sc.Score += weights[li] / float64(rrfK+rank+1)
+		}
+	}
+
+	fused := make([]domain.SimilarChunk, 0, len(order))
+	for _, id := range order {
+		fused = append(fused, *byID[id])
+	}
+	sort.SliceStable(fused, func(i, j int) bool { return fused[i].Score > fused[j].Score })
+
+	if offset >= len(fused) {
+		return nil
+	}
+	fused = fused[offset:]
+	if len(fused) > limit {
+		fused = fused[:limit]
+	}
+	return fused
+}
+
+var lexicalTokenRe = regexp.MustCompile(`[A-Za-z0-9_]+`)
+
+// lexicalStopwords are dropped from full-text queries; they match almost every chunk.
+var lexicalStopwords = map[string]bool{
+	"the": true, "and": true, "for": true, "how": true, "what": true, "does": true,
+	"where": true, "which": true, "who": true, "why": true, "when": true, "this": true,
+	"that": true, "with": true, "from": true, "are": true, "is": true, "was": true,
+	"can": true, "do": true, "work": true, "works": true, "code": true, "use": true, "used": true,
+}
+
+// lexicalQuery turns free text into an OR-ed tsquery string (e.g. "buildanalysisrequest | repo").
+// Returns "" when nothing searchable remains.
+func lexicalQuery(text string) string {
+	seen := map[string]bool{}
+	var terms []string
+	for _, tok := range lexicalTokenRe.FindAllString(text, -1) {
+		tok = strings.ToLower(tok)
+		if len(tok) < 3 || lexicalStopwords[tok] || seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		terms = append(terms, tok)
+	}
+	return strings.Join(terms, " | ")
+}
+
+// servingOrder ranks the snapshots of a repo (s, joined with its repos row r) for
+// serving searches: finished indexes first, then the tracked HEAD, then other commits
+// of the tracked branch, newest first. Snapshots indexed at another ref only serve
+// when the tracked branch has no index.
+const servingOrder = `(s.status = '` + domain.SnapshotStatusVectorized + `') DESC,
+	(s.commit_hash = r.head_commit) DESC, (s.branch = r.default_branch) DESC, s.created_at DESC`
+
+// LatestSnapshotID returns the snapshot that searches use by default: the index of the
+// repo's tracked HEAD, or failing that the best one by servingOrder with any embeddings.
+// Returns "" when the repo has no embeddings at all.
+func (v *VectorStore) LatestSnapshotID(ctx context.Context, repoID string) (string, error) {
+	query := `SELECT s.id FROM snapshots s
+	          JOIN repos r ON r.id = s.repo_id
+	          WHERE s.repo_id = $1
+	            AND EXISTS (SELECT 1 FROM embeddings e WHERE e.snapshot_id = s.id)
+	          ORDER BY ` + servingOrder + `
+	          LIMIT 1`
+
+	var id string
+	err := v.store.db.QueryRowContext(ctx, query, repoID).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("latest snapshot: %w", err)
+	}
+	return id, nil
+}
+
+// FileHashes returns the content hash of every file indexed in a snapshot.
+func (v *VectorStore) FileHashes(ctx context.Context, snapshotID string) (map[string]string, error) {
+	query := `SELECT DISTINCT file_path, content_hash FROM embeddings
+	          WHERE snapshot_id = $1 AND content_hash <> ''`
+
+	rows, err := v.store.db.QueryContext(ctx, query, snapshotID)
+	if err != nil {
+		return nil, fmt.Errorf("file hashes: %w", err)
+	}
+	defer rows.Close()
+
+	hashes := make(map[string]string)
 	for rows.Next() {
-		var sc domain.SimilarChunk
-		if err := rows.Scan(
-			&sc.ID, &sc.SnapshotID, &sc.RepoID, &sc.FilePath, &sc.ChunkIndex,
-			&sc.Content, &sc.Language, &sc.CreatedAt, &sc.Similarity,
-		); err != nil {
-			return nil, fmt.Errorf("scan similar: %w", err)
+		var path, hash string
+		if err := rows.Scan(&path, &hash); err != nil {
+			return nil, fmt.Errorf("scan file hash: %w", err)
 		}
-		results = append(results, sc)
+		hashes[path] = hash
 	}
-	return results, nil
+	return hashes, rows.Err()
+}
+
+// CopyFileEmbeddings reuses the chunks (vectors included) of unchanged files from one
+// snapshot in another, re-stamping them with the target snapshot's commit hash.
+// Chunks keep their embedding index, so a migration in progress does not re-embed them.
+func (v *VectorStore) CopyFileEmbeddings(ctx context.Context, fromSnapshotID, toSnapshotID, commitHash string, filePaths []string) (int64, error) {
+	if len(filePaths) == 0 {
+		return 0, nil
+	}
+
+	query := `INSERT INTO embeddings (snapshot_id, repo_id, file_path, chunk_index, content, language,
+	                                  symbol_name, symbol_kind, parent_symbol, start_line, end_line, commit_hash, content_hash, index_id, vector)
+	          SELECT $2, repo_id, file_path, chunk_index, content, language,
+	                 symbol_name, symbol_kind, parent_symbol, start_line, end_line, $3, content_hash, index_id, vector
+	          FROM embeddings
+	          WHERE snapshot_id = $1 AND file_path = ANY($4)`
+
+	res, err := v.store.db.ExecContext(ctx, query, fromSnapshotID, toSnapshotID, commitHash, pq.Array(filePaths))
+	if err != nil {
+		return 0, fmt.Errorf("copy embeddings: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// KeepFileEmbeddings deletes the chunks of a snapshot's files outside filePaths and
+// returns how many chunks remain, so that a snapshot re-indexed in place keeps the
+// vectors of its unchanged files.
+func (v *VectorStore) KeepFileEmbeddings(ctx context.Context, snapshotID string, filePaths []string) (int64, error) {
+	if _, err := v.store.db.ExecContext(ctx, `DELETE FROM embeddings WHERE snapshot_id = $1 AND NOT (file_path = ANY($2))`,
+		snapshotID, pq.Array(nonNilStrings(filePaths))); err != nil {
+		return 0, fmt.Errorf("drop changed files: %w", err)
+	}
+	var kept int64
+	if err := v.store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings WHERE snapshot_id = $1`, snapshotID).Scan(&kept); err != nil {
+		return 0, fmt.Errorf("count kept chunks: %w", err)
+	}
+	return kept, nil
+}
+
+// DeleteEmbeddingsBySnapshot deletes all embeddings of a snapshot.
+func (v *VectorStore) DeleteEmbeddingsBySnapshot(ctx context.Context, snapshotID string) error {
+	query := `DELETE FROM embeddings WHERE snapshot_id = $1`
+	_, err := v.store.db.ExecContext(ctx, query, snapshotID)
+	return err
 }
 
 // DeleteEmbeddingsByRepo deletes all embeddings for a repo.
@@ -103,6 +503,23 @@ func (v *VectorStore) DeleteEmbeddingsByRepo(ctx context.Context, repoID string)
 	return err
 }
 
+// scanSimilarChunks reads rows selected as chunkColumns followed by a similarity column.
+func scanSimilarChunks(rows *sql.Rows) ([]domain.SimilarChunk, error) {
+	var results []domain.SimilarChunk
+	for rows.Next() {
+		var sc domain.SimilarChunk
+		if err := rows.Scan(
+			&sc.ID, &sc.SnapshotID, &sc.RepoID, &sc.FilePath, &sc.ChunkIndex, &sc.Content, &sc.Language,
+			&sc.SymbolName, &sc.SymbolKind, &sc.ParentSymbol, &sc.StartLine, &sc.EndLine, &sc.CommitHash, &sc.CreatedAt,
+			&sc.Similarity,
+		); err != nil {
+			return nil, fmt.Errorf("scan similar: %w", err)
+		}
+		results = append(results, sc)
+	}
+	return results, rows.Err()
+}
+
 // vectorToString converts a float32 slice to pgvector string format: [0.1,0.2,0.3].
 func vectorToString(v []float32) string {
 	parts := make([]string, len(v))
@@ -0,0 +1,323 @@
+package store
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"math"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// Vector index types.
+const (
+	VectorIndexHNSW    = "hnsw"
+	VectorIndexIVFFlat = "ivfflat"
+)
+
+// maxIndexedDimension is the largest dimension pgvector can build an ANN index for.
+const maxIndexedDimension = 2000
+
+// ivfflatRowsPerList sizes automatic IVFFlat lists (rows/1000, as pgvector recommends up to 1M rows).
+const ivfflatRowsPerList = 1000
+
+// rebuildGrowthFactor triggers an IVFFlat rebuild once the data has grown this much since
+// the last build, since its lists are clustered on the rows present at build time.
+const rebuildGrowthFactor = 2
+
+// VectorIndexConfig selects the ANN index built for each vector dimension and the
+// per-query search settings.
+type VectorIndexConfig struct {
+	Type           string // hnsw (default) or ivfflat
+	M              int    // HNSW max connections per layer
+	EfConstruction int    // HNSW candidate list size while building
+	EfSearch       int    // HNSW candidate list size per query (raised to the query limit)
+	Lists          int    // IVFFlat lists; 0 sizes them from the row count at build time
+	Probes         int    // IVFFlat lists scanned per query
+}
+
+// buildParams returns the parameters an index of this config would be built with.
+func (c VectorIndexConfig) buildParams(rows int64) map[string]int {
+	if c.Type == VectorIndexIVFFlat {
+		lists := c.Lists
+		if lists <= 0 {
+			lists = int(max(rows/ivfflatRowsPerList, 10))
+		}
+		return map[string]int{"lists": lists}
+	}
+	return map[string]int{"m": c.M, "ef_construction": c.EfConstruction}
+}
+
+func vectorIndexName(dimension int) string {
+	return fmt.Sprintf("idx_embeddings_vector_%d", dimension)
+}
+
+// EnsureDimensionIndex makes sure the ANN index for a dimension matches the configured
+// type and parameters, and (for IVFFlat) has not been outgrown by the data. Call it at
+// startup and after bulk loads. Returns whether the index was (re)built.
+func (v *VectorStore) EnsureDimensionIndex(ctx context.Context, dimension int) (bool, error) {
+	if dimension > maxIndexedDimension {
+		return false, fmt.Errorf("%w: %d exceeds the %d dimensions pgvector can index", port.ErrDimensionMismatch, dimension, maxIndexedDimension)
+	}
+
+	v.indexMu.Lock()
+	defer v.indexMu.Unlock()
+
+	stats, err := v.dimensionIndexStats(ctx, dimension)
+	if err != nil {
+		return false, err
+	}
+
+	reason := ""
+	switch {
+	case stats.Type == "" || !stats.Valid:
+		reason = "missing"
+	case stats.Type != v.indexCfg.Type:
+		reason = "type changed"
+	case v.indexCfg.Type == VectorIndexHNSW && !maps.Equal(stats.Params, v.indexCfg.buildParams(stats.Rows)):
+		reason = "parameters changed"
+	case v.indexCfg.Type == VectorIndexIVFFlat && v.indexCfg.Lists > 0 && stats.Params["lists"] != v.indexCfg.Lists:
+		reason = "parameters changed"
+	case v.indexCfg.Type == VectorIndexIVFFlat && stats.Rows >= ivfflatRowsPerList && stats.Rows > rebuildGrowthFactor*stats.RowsAtBuild:
+		reason = "data outgrew lists"
+	}
+	if reason == "" {
+		return false, nil
+	}
+
+	slog.Info("building vector index", "dimension", dimension, "type", v.indexCfg.Type, "reason", reason, "rows", stats.Rows)
+	if err := v.buildDimensionIndex(ctx, dimension, stats.Rows); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RebuildDimensionIndex unconditionally rebuilds the ANN index for a dimension.
+func (v *VectorStore) RebuildDimensionIndex(ctx context.Context, dimension int) error {
+	v.indexMu.Lock()
+	defer v.indexMu.Unlock()
+
+	stats, err := v.dimensionIndexStats(ctx, dimension)
+	if err != nil {
+		return err
+	}
+	return v.buildDimensionIndex(ctx, dimension, stats.Rows)
+}
+
+// buildDimensionIndex builds the index concurrently under a temporary name and swaps it
+// in, so searches keep using the old index until the new one is ready.
+func (v *VectorStore) buildDimensionIndex(ctx context.Context, dimension int, rows int64) error {
+	db := v.store.db
+	name := vectorIndexName(dimension)
+	tmp := name + "_build"
+	params := v.indexCfg.buildParams(rows)
+
+	var with string
+	if v.indexCfg.Type == VectorIndexIVFFlat {
+		with = fmt.Sprintf("lists = %d", params["lists"])
+	} else {
+		with = fmt.Sprintf("m = %d, ef_construction = %d", params["m"], params["ef_construction"])
+	}
+
+	steps := []string{
+		`DROP INDEX CONCURRENTLY IF EXISTS ` + tmp, // leftover of an interrupted build
+		fmt.Sprintf(`CREATE INDEX CONCURRENTLY %s ON embeddings
+			USING %s ((vector::vector(%d)) vector_cosine_ops) WITH (%s)
+			WHERE vector_dims(vector) = %d`, tmp, v.indexCfg.Type, dimension, with, dimension),
+		`DROP INDEX CONCURRENTLY IF EXISTS ` + name,
+		`ALTER INDEX ` + tmp + ` RENAME TO ` + name,
+	}
+	for _, step := range steps {
+		if _, err := db.ExecContext(ctx, step); err != nil {
+			return fmt.Errorf("build vector index (%d): %w", dimension, err)
+		}
+	}
+
+	paramsJSON, _ := json.Marshal(params)
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO vector_index_builds (dimension, index_type, params, rows_at_build, built_at)
+		VALUES ($1, $2, $3::jsonb, $4, NOW())
+		ON CONFLICT (dimension) DO UPDATE SET
+			index_type = EXCLUDED.index_type, params = EXCLUDED.params,
+			rows_at_build = EXCLUDED.rows_at_build, built_at = EXCLUDED.built_at`,
+		dimension, v.indexCfg.Type, string(paramsJSON), rows)
+	if err != nil {
+		return fmt.Errorf("record vector index build: %w", err)
+	}
+	slog.Info("vector index built", "dimension", dimension, "type", v.indexCfg.Type, "params", params, "rows", rows)
+	return nil
+}
+
+// VectorIndexStats reports on the ANN index of the active embedding index's dimension.
+func (v *VectorStore) VectorIndexStats(ctx context.Context) (*domain.VectorIndexStats, error) {
+	idx, err := v.activeIndex()
+	if err != nil {
+		return nil, err
+	}
+	stats, err := v.dimensionIndexStats(ctx, idx.Dimension)
+	if err != nil {
+		return nil, err
+	}
+	err = v.store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings WHERE index_id = $1`, idx.ID).Scan(&stats.ActiveRows)
+	if err != nil {
+		return nil, fmt.Errorf("count active rows: %w", err)
+	}
+	return stats, nil
+}
+
+// dimensionIndexStats reads the catalog and build record of a dimension's index.
+// A build record is only trusted if the index it describes still exists.
+func (v *VectorStore) dimensionIndexStats(ctx context.Context, dimension int) (*domain.VectorIndexStats, error) {
+	db := v.store.db
+	stats := &domain.VectorIndexStats{Dimension: dimension, Name: vectorIndexName(dimension), Params: map[string]int{}}
+
+	var amName sql.NullString
+	err := db.QueryRowContext(ctx, `
+		SELECT am.amname, i.indisvalid, pg_relation_size(c.oid)
+		FROM pg_class c
+		JOIN pg_index i ON i.indexrelid = c.oid
+		JOIN pg_am am ON am.oid = c.relam
+		WHERE c.relname = $1`, stats.Name).Scan(&amName, &stats.Valid, &stats.SizeBytes)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("inspect vector index: %w", err)
+	}
+
+	err = db.QueryRowContext(ctx, `SELECT COUNT(*) FROM embeddings WHERE vector_dims(vector) = $1`, dimension).Scan(&stats.Rows)
+	if err != nil {
+		return nil, fmt.Errorf("count vectors: %w", err)
+	}
+
+	if !amName.Valid {
+		return stats, nil
+	}
+	stats.Type = amName.String
+
+	var buildType string
+	var params []byte
+	var builtAt sql.NullTime
+	err = db.QueryRowContext(ctx, `SELECT index_type, params, rows_at_build, built_at FROM vector_index_builds WHERE dimension = $1`,
+		dimension).Scan(&buildType, &params, &stats.RowsAtBuild, &builtAt)
+	switch {
+	case err == sql.ErrNoRows:
+		// Built outside the server (e.g. by a migration): parameters unknown
+	case err != nil:
+		return nil, fmt.Errorf("load vector index build: %w", err)
+	case buildType == stats.Type:
+		if err := json.Unmarshal(params, &stats.Params); err != nil {
+			return nil, fmt.Errorf("decode vector index params: %w", err)
+		}
+		if builtAt.Valid {
+			stats.BuiltAt = &builtAt.Time
+		}
+	}
+	return stats, nil
+}
+
+// searchTx opens a read-only transaction with the per-query ANN settings applied:
+// hnsw.ef_search (at least the number of rows wanted) and ivfflat.probes.
+func (v *VectorStore) searchTx(ctx context.Context, want int) (*sql.Tx, error) {
+	tx, err := v.store.db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("begin search tx: %w", err)
+	}
+
+	efSearch := min(max(v.indexCfg.EfSearch, want), 1000)
+	settings := fmt.Sprintf(`SET LOCAL hnsw.ef_search = %d; SET LOCAL ivfflat.probes = %d`, efSearch, max(v.indexCfg.Probes, 1))
+	if _, err := tx.ExecContext(ctx, settings); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("apply search settings: %w", err)
+	}
+	return tx, nil
+}
+
+// MeasureRecall estimates ANN recall for the active embedding index: for samples random
+// stored vectors it compares the top-k ids returned through the index (with the configured
+// search settings) with an exact scan, and returns the mean overlap in [0, 1].
+func (v *VectorStore) MeasureRecall(ctx context.Context, samples, k int) (float64, error) {
+	idx, err := v.activeIndex()
+	if err != nil {
+		return 0, err
+	}
+
+	rows, err := v.store.db.QueryContext(ctx, `SELECT vector::text FROM embeddings WHERE index_id = $1 ORDER BY random() LIMIT $2`,
+		idx.ID, samples)
+	if err != nil {
+		return 0, fmt.Errorf("sample vectors: %w", err)
+	}
+	var queries []string
+	for rows.Next() {
+		var q string
+		if err := rows.Scan(&q); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("scan sample: %w", err)
+		}
+		queries = append(queries, q)
+	}
+	rows.Close()
+	if len(queries) == 0 {
+		return 0, fmt.Errorf("measure recall: no vectors in active index")
+	}
+
+	query := `SELECT e.id FROM embeddings e
+	          WHERE e.index_id = $2 AND vector_dims(e.vector) = ` + fmt.Sprint(idx.Dimension) + `
+	          ORDER BY ` + distanceExpr(idx) + ` LIMIT $3`
+
+	var total float64
+	for _, q := range queries {
+		approx, err := v.topIDs(ctx, query, false, k, q, idx.ID, k)
+		if err != nil {
+			return 0, err
+		}
+		exact, err := v.topIDs(ctx, query, true, k, q, idx.ID, k)
+		if err != nil {
+			return 0, err
+		}
+		if len(exact) == 0 {
+			continue
+		}
+		hits := 0
+		for id := range exact {
+			if approx[id] {
+				hits++
+			}
+		}
+		total += float64(hits) / float64(len(exact))
+	}
+	return math.Round(total/float64(len(queries))*1000) / 1000, nil
+}
+
+// topIDs runs an id query inside a search transaction; exact disables index scans
+// so the planner falls back to a sequential scan and sort.
+func (v *VectorStore) topIDs(ctx context.Context, query string, exact bool, want int, args ...interface{}) (map[string]bool, error) {
+	tx, err := v.searchTx(ctx, want)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if exact {
+		if _, err := tx.ExecContext(ctx, `SET LOCAL enable_indexscan = off; SET LOCAL enable_bitmapscan = off`); err != nil {
+			return nil, fmt.Errorf("disable index scan: %w", err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("recall query: %w", err)
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("scan id: %w", err)
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,108 @@
+package store
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+func hits(ids ...string) []domain.SimilarChunk {
+	out := make([]domain.SimilarChunk, len(ids))
+	for i, id := range ids {
+		out[i] = domain.SimilarChunk{Embedding: domain.Embedding{ID: id}, Score: 0.9}
+	}
+	return out
+}
+
+func TestFuseRRF(t *testing.T) {
+	tests := []struct {
+		name          string
+		lists         [][]domain.SimilarChunk
+		weights       []float64
+		offset, limit int
+		want          []string
+	}{
+		{"single list keeps its order", [][]domain.SimilarChunk{hits("a", "b", "c")}, []float64{1}, 0, 10, []string{"a", "b", "c"}},
+		{"shared hits rise", [][]domain.SimilarChunk{hits("a", "b", "c"), hits("c", "d")}, []float64{1, 1}, 0, 10, []string{"c", "a", "b", "d"}},
+		{"weights favor a list", [][]domain.SimilarChunk{hits("a", "b"), hits("c", "d")}, []float64{1, 2}, 0, 10, []string{"c", "d", "a", "b"}},
+		{"ties keep first-seen order", [][]domain.SimilarChunk{hits("a"), hits("b")}, []float64{1, 1}, 0, 10, []string{"a", "b"}},
+		{"page", [][]domain.SimilarChunk{hits("a", "b", "c", "d")}, []float64{1}, 1, 2, []string{"b", "c"}},
+		{"offset past end", [][]domain.SimilarChunk{hits("a", "b")}, []float64{1}, 2, 10, nil},
+		{"empty lists", [][]domain.SimilarChunk{nil, nil}, []float64{1, 1}, 0, 10, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FuseRRF(tt.lists, tt.weights, tt.offset, tt.limit)
+			var ids []string
+			for _, h := range got {
+				ids = append(ids, h.ID)
+			}
+			if !slices.Equal(ids, tt.want) {
+				t.Errorf("FuseRRF = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
+
+func TestFuseRRFScores(t *testing.T) {
+	got := FuseRRF([][]domain.SimilarChunk{hits("a", "b"), hits("b")}, []float64{1, 0.5}, 0, 10)
+	want := map[string]float64{
+		"a": 1.0 / 61,
+		"b": 1.0/62 + 0.5/61,
+	}
+	for _, h := range got {
+		if diff := h.Score - want[h.ID]; diff > 1e-12 || diff < -1e-12 {
+			t.Errorf("score of %s = %v, want %v", h.ID, h.Score, want[h.ID])
+		}
+	}
+}
+
+func TestLexicalQuery(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"How does BuildAnalysisRequest work?", "buildanalysisrequest"},
+		{"where is the repo_id used in the repo handler", "repo_id | repo | handler"},
+		{"ParseConfig parseconfig PARSECONFIG", "parseconfig"},
+		{"go db: ok", ""},
+		{"what is this code", ""},
+		{"", ""},
+		{"retry-after header, 429", "retry | after | header | 429"},
+	}
+	for _, tt := range tests {
+		if got := lexicalQuery(tt.text); got != tt.want {
+			t.Errorf("lexicalQuery(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob  string
+		match []string
+		miss  []string
+	}{
+		{"*.go", []string{"main.go"}, []string{"cmd/main.go", "main.gox", "mainxgo"}},
+		{"internal/*.go", []string{"internal/a.go"}, []string{"internal/x/a.go", "internal.go"}},
+		{"**/*.go", []string{"main.go", "a/b/c.go"}, []string{"a/b/c.ts"}},
+		{"internal/**", []string{"internal/a.go", "internal/x/y/z"}, []string{"cmd/internal/a.go"}},
+		{"internal/**/handler.go", []string{"internal/handler.go", "internal/api/v1/handler.go"}, []string{"internal/api/handler.go.bak"}},
+		{"file?.txt", []string{"file1.txt"}, []string{"file10.txt", "file/.txt"}},
+		{"a+b (1).md", []string{"a+b (1).md"}, []string{"aab (1).md", "a+b 1.md"}},
+	}
+	for _, tt := range tests {
+		re := regexp.MustCompile(globToRegexp(tt.glob))
+		for _, p := range tt.match {
+			if !re.MatchString(p) {
+				t.Errorf("glob %q (%s) does not match %q", tt.glob, re, p)
+			}
+		}
+		for _, p := range tt.miss {
+			if re.MatchString(p) {
+				t.Errorf("glob %q (%s) matches %q", tt.glob, re, p)
+			}
+		}
+	}
+}
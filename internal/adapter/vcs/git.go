@@ -3,6 +3,7 @@ package vcs
 import (
 	"context"
 	"fmt"
+	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -14,44 +15,143 @@ import (
 )
 
 // GitProvider implements port.VCSProvider using the git CLI.
-type GitProvider struct{}
+type GitProvider struct {
+	ssh SSHConfig
+}
+
+// NewGitProvider creates a new Git VCS provider. ssh sets how the host keys of SSH
+// remotes are verified.
+func NewGitProvider(ssh SSHConfig) *GitProvider {
+	return &GitProvider{ssh: ssh}
+}
+
+// Clone clones a repository into dest. Sparse clones check out the root files and
+// opts.SparsePaths (cone mode); their blobs are fetched here, with auth.
+func (g *GitProvider) Clone(ctx context.Context, url string, dest string, opts *domain.CloneOptions, auth *domain.GitAuth) error {
+	args := []string{"clone"}
+	if opts != nil {
+		if opts.Depth > 0 {
+			args = append(args, "--depth", strconv.Itoa(opts.Depth))
+			if !opts.SingleBranch {
+				args = append(args, "--no-single-branch") // --depth implies --single-branch
+			}
+		}
+		if opts.SingleBranch {
+			args = append(args, "--single-branch")
+			if opts.Branch != "" {
+				args = append(args, "--branch", opts.Branch)
+			}
+		}
+		if opts.Blobless {
+			args = append(args, "--filter=blob:none")
+		}
+		if len(opts.SparsePaths) > 0 {
+			args = append(args, "--sparse")
+		}
+	}
+	if err := g.runRemote(ctx, auth, append(args, "--", url, dest)...); err != nil {
+		return fmt.Errorf("git clone %s: %w", redact(url, auth), err)
+	}
+
+	if opts != nil && len(opts.SparsePaths) > 0 {
+		args := append([]string{"-C", dest, "sparse-checkout", "set", "--"}, opts.SparsePaths...)
+		if err := g.runRemote(ctx, auth, args...); err != nil {
+			return fmt.Errorf("git sparse-checkout %s: %w", dest, err)
+		}
+	}
+	return nil
+}
+
+// Deepen fetches up to commits more commits of history into a shallow clone. It
+// reports false, without fetching, when the clone already has its full history.
+func (g *GitProvider) Deepen(ctx context.Context, repoPath string, commits int, auth *domain.GitAuth) (bool, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--is-shallow-repository").Output()
+	if err != nil {
+		return false, fmt.Errorf("git rev-parse %s: %w", repoPath, err)
+	}
+	if strings.TrimSpace(string(out)) != "true" || commits <= 0 {
+		return false, nil
+	}
+	if err := g.runRemote(ctx, auth, "-C", repoPath, "fetch", "--no-tags", "--deepen="+strconv.Itoa(commits), "origin"); err != nil {
+		return false, fmt.Errorf("git fetch --deepen %s: %w", repoPath, err)
+	}
+	return true, nil
+}
 
-// NewGitProvider creates a new Git VCS provider.
-func NewGitProvider() *GitProvider {
-	return &GitProvider{}
+// ScrubRemote removes credentials from the URL of a clone's origin remote, as written
+// by clones of URLs with an embedded token. It reports whether there were any.
+func (g *GitProvider) ScrubRemote(ctx context.Context, repoPath string) (bool, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "config", "--get", "remote.origin.url").Output()
+	if err != nil {
+		return false, fmt.Errorf("git remote url %s: %w", repoPath, err)
+	}
+	u, err := url.Parse(strings.TrimSpace(string(out)))
+	if err != nil || u.User == nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false, nil
+	}
+	u.User = nil
+	if err := exec.CommandContext(ctx, "git", "-C", repoPath, "remote", "set-url", "origin", u.String()).Run(); err != nil {
+		return false, fmt.Errorf("git remote set-url %s: %w", repoPath, err)
+	}
+	return true, nil
 }
 
-// Clone clones a repository into dest.
-func (g *GitProvider) Clone(ctx context.Context, url string, dest string) error {
-	cmd := exec.CommandContext(ctx, "git", "clone", url, dest)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git clone %s: %w", url, err)
+// Pull fetches branch from origin and checks it out at the fetched commit,
+// discarding local changes. An empty branch pulls the current branch.
+func (g *GitProvider) Pull(ctx context.Context, repoPath, branch string, auth *domain.GitAuth) error {
+	if branch == "" {
+		out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-parse", "--abbrev-ref", "HEAD").Output()
+		if err != nil {
+			return fmt.Errorf("git current branch %s: %w", repoPath, err)
+		}
+		branch = strings.TrimSpace(string(out))
+	}
+	if err := checkBranch(ctx, branch); err != nil {
+		return err
+	}
+
+	remoteRef := "refs/remotes/origin/" + branch
+	if err := g.runRemote(ctx, auth, "-C", repoPath, "fetch", "--prune", "origin", "+refs/heads/"+branch+":"+remoteRef); err != nil {
+		return fmt.Errorf("git fetch %s: %w", repoPath, err)
+	}
+	// The checkout fetches the new commit's blobs in blobless clones
+	if err := g.runRemote(ctx, auth, "-C", repoPath, "checkout", "--force", "-B", branch, remoteRef); err != nil {
+		return fmt.Errorf("git checkout %s: %w", repoPath, err)
+	}
+	if err := g.runRemote(ctx, auth, "-C", repoPath, "clean", "-fd"); err != nil {
+		return fmt.Errorf("git clean %s: %w", repoPath, err)
 	}
 	return nil
 }
 
-// Pull fetches the latest changes for an existing repository.
-func (g *GitProvider) Pull(ctx context.Context, repoPath string) error {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "pull", "--ff-only")
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("git pull %s: %w", repoPath, err)
+// checkBranch checks that branch is a valid branch name with git check-ref-format.
+// Shorthands such as @{-1}, which git expands to another name, are rejected too.
+func checkBranch(ctx context.Context, branch string) error {
+	out, err := exec.CommandContext(ctx, "git", "check-ref-format", "--branch", branch).Output()
+	if err != nil || strings.TrimSpace(string(out)) != branch {
+		return fmt.Errorf("invalid branch %q", branch)
 	}
 	return nil
 }
 
-// Log returns the commit history.
-func (g *GitProvider) Log(ctx context.Context, repoPath string, limit int) ([]domain.CommitInfo, error) {
+// Log returns the commit history. In blobless clones file counts are left out, as
+// --shortstat would fetch the blobs of every listed commit; auth is used for any
+// other object git has to fetch.
+func (g *GitProvider) Log(ctx context.Context, repoPath string, limit int, auth *domain.GitAuth) ([]domain.CommitInfo, error) {
 	format := "%H|%an|%s|%aI|%m"
-	args := []string{"-C", repoPath, "log", fmt.Sprintf("--format=%s", format), "--shortstat"}
+	args := []string{"-C", repoPath, "log", fmt.Sprintf("--format=%s", format)}
+	if !g.partialClone(ctx, repoPath) {
+		args = append(args, "--shortstat")
+	}
 	if limit > 0 {
 		args = append(args, fmt.Sprintf("-n%d", limit))
 	}
 
-	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd, cleanup, err := g.remoteCommand(ctx, auth, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("git log: %w", err)
@@ -98,9 +198,83 @@ func (g *GitProvider) Log(ctx context.Context, repoPath string, limit int) ([]do
 	return commits, nil
 }
 
-// Diff returns the unified diff between two commits.
-func (g *GitProvider) Diff(ctx context.Context, repoPath, fromHash, toHash string) (string, error) {
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "diff", fromHash, toHash)
+// partialClone reports whether a clone was made with a filter, such as a blobless
+// clone, and fetches missing objects from its remote on demand.
+func (g *GitProvider) partialClone(ctx context.Context, repoPath string) bool {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "config", "--bool", "remote.origin.promisor").Output()
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// CountCommits returns the number of commits reachable from HEAD: in a shallow clone,
+// those fetched so far.
+func (g *GitProvider) CountCommits(ctx context.Context, repoPath string) (int, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "rev-list", "--count", "HEAD").Output()
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list --count: %w", err)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	if err != nil {
+		return 0, fmt.Errorf("git rev-list --count: %w", err)
+	}
+	return n, nil
+}
+
+// ResolveRef returns the commit a branch, tag or (abbreviated) hash points to.
+func (g *GitProvider) ResolveRef(ctx context.Context, repoPath, ref string) (*domain.CommitInfo, error) {
+	if strings.HasPrefix(ref, "-") {
+		return nil, fmt.Errorf("invalid ref %q", ref)
+	}
+	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "log", "-1", "--format=%H|%an|%aI|%s", ref+"^{commit}", "--")
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git resolve %s: %w", ref, err)
+	}
+
+	parts := strings.SplitN(strings.TrimSpace(string(output)), "|", 4)
+	if len(parts) < 4 {
+		return nil, fmt.Errorf("git resolve %s: unexpected output", ref)
+	}
+	ts, _ := time.Parse(time.RFC3339, parts[2])
+	return &domain.CommitInfo{Hash: parts[0], Author: parts[1], Message: parts[3], Timestamp: ts}, nil
+}
+
+// Fetch fetches refspecs from origin without touching the working copy.
+func (g *GitProvider) Fetch(ctx context.Context, repoPath string, auth *domain.GitAuth, refspecs ...string) error {
+	for _, spec := range refspecs {
+		if strings.HasPrefix(spec, "-") {
+			return fmt.Errorf("invalid refspec %q", spec)
+		}
+	}
+	args := append([]string{"-C", repoPath, "fetch", "--no-tags", "origin"}, refspecs...)
+	if err := g.runRemote(ctx, auth, args...); err != nil {
+		return fmt.Errorf("git fetch %s: %w", repoPath, err)
+	}
+	return nil
+}
+
+// MergeBase returns the best common ancestor of two commits.
+func (g *GitProvider) MergeBase(ctx context.Context, repoPath, a, b string) (string, error) {
+	if strings.HasPrefix(a, "-") || strings.HasPrefix(b, "-") {
+		return "", fmt.Errorf("invalid commits %q, %q", a, b)
+	}
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "merge-base", a, b).Output()
+	if err != nil {
+		return "", fmt.Errorf("git merge-base %s %s: %w", a, b, err)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Diff returns the unified diff between two commits. auth is used by blobless clones
+// to fetch the blobs of both sides.
+func (g *GitProvider) Diff(ctx context.Context, repoPath, fromHash, toHash string, auth *domain.GitAuth) (string, error) {
+	if strings.HasPrefix(fromHash, "-") || strings.HasPrefix(toHash, "-") {
+		return "", fmt.Errorf("invalid commits %q, %q", fromHash, toHash)
+	}
+	cmd, cleanup, err := g.remoteCommand(ctx, auth, "-C", repoPath, "diff", fromHash, toHash)
+	if err != nil {
+		return "", err
+	}
+	defer cleanup()
 	output, err := cmd.Output()
 	if err != nil {
 		return "", fmt.Errorf("git diff: %w", err)
@@ -108,8 +282,14 @@ func (g *GitProvider) Diff(ctx context.Context, repoPath, fromHash, toHash strin
 	return string(output), nil
 }
 
-// ListFiles returns all file paths in the repository at a given commit.
+// ListFiles returns all file paths in the repository at a given commit. In sparse
+// clones only the root files and the sparse directories are listed.
 func (g *GitProvider) ListFiles(ctx context.Context, repoPath string, commitHash string) ([]string, error) {
+	sparse, err := g.sparsePaths(ctx, repoPath)
+	if err != nil {
+		return nil, err
+	}
+
 	args := []string{"-C", repoPath, "ls-tree", "-r", "--name-only"}
 	if commitHash != "" {
 		args = append(args, commitHash)
@@ -127,15 +307,49 @@ func (g *GitProvider) ListFiles(ctx context.Context, repoPath string, commitHash
 	var result []string
 	for _, f := range files {
 		f = strings.TrimSpace(f)
-		if f != "" {
+		if f != "" && inSparseCheckout(f, sparse) {
 			result = append(result, f)
 		}
 	}
 	return result, nil
 }
 
-// ReadFile reads a file's content at a specific commit hash.
-func (g *GitProvider) ReadFile(ctx context.Context, repoPath string, commitHash string, filePath string) ([]byte, error) {
+// inSparseCheckout reports whether a path is checked out: a root file, or a file
+// under one of the sparse directories. nil dirs means a full checkout.
+func inSparseCheckout(path string, dirs []string) bool {
+	if dirs == nil || !strings.Contains(path, "/") {
+		return true
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(path, dir+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// sparsePaths returns the directories of a sparse clone, or nil for a full checkout.
+func (g *GitProvider) sparsePaths(ctx context.Context, repoPath string) ([]string, error) {
+	out, err := exec.CommandContext(ctx, "git", "-C", repoPath, "config", "--bool", "core.sparseCheckout").Output()
+	if err != nil || strings.TrimSpace(string(out)) != "true" {
+		return nil, nil // unset: not sparse
+	}
+	out, err = exec.CommandContext(ctx, "git", "-C", repoPath, "sparse-checkout", "list").Output()
+	if err != nil {
+		return nil, fmt.Errorf("git sparse-checkout list %s: %w", repoPath, err)
+	}
+	dirs := []string{}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			dirs = append(dirs, line)
+		}
+	}
+	return dirs, nil
+}
+
+// ReadFile reads a file's content at a specific commit hash. auth is used by blobless
+// clones to fetch the file's blob.
+func (g *GitProvider) ReadFile(ctx context.Context, repoPath string, commitHash string, filePath string, auth *domain.GitAuth) ([]byte, error) {
 	if commitHash == "" {
 		// Read from working tree
 		fullPath := filepath.Join(repoPath, filePath)
@@ -143,7 +357,11 @@ func (g *GitProvider) ReadFile(ctx context.Context, repoPath string, commitHash
 	}
 
 	ref := fmt.Sprintf("%s:%s", commitHash, filePath)
-	cmd := exec.CommandContext(ctx, "git", "-C", repoPath, "show", ref)
+	cmd, cleanup, err := g.remoteCommand(ctx, auth, "-C", repoPath, "show", ref)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
 	output, err := cmd.Output()
 	if err != nil {
 		return nil, fmt.Errorf("git show %s: %w", ref, err)
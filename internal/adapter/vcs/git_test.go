@@ -0,0 +1,28 @@
+package vcs
+
+import (
+	"context"
+	"testing"
+)
+
+func TestCheckBranch(t *testing.T) {
+	tests := map[string]bool{
+		"main":          true,
+		"feature/login": true,
+		"release-1.2":   true,
+		"-main":         false,
+		"--upload-pack": false,
+		"a..b":          false,
+		"a b":           false,
+		"x.lock":        false,
+		"@{-1}":         false,
+		"HEAD":          false,
+		"main~1":        false,
+		"":              false,
+	}
+	for branch, ok := range tests {
+		if err := checkBranch(context.Background(), branch); (err == nil) != ok {
+			t.Errorf("checkBranch(%q) = %v, want ok=%v", branch, err, ok)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package vcs
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// Host key policies for SSH remotes.
+const (
+	HostKeyStrict    = "strict"     // only hosts already in known_hosts
+	HostKeyAcceptNew = "accept-new" // trust a host on first use, reject changed keys
+	HostKeyOff       = "off"        // no verification
+)
+
+// SSHConfig sets how git verifies the host keys of SSH remotes.
+type SSHConfig struct {
+	KnownHostsFile string // empty = ssh's default, ~/.ssh/known_hosts
+	HostKeyPolicy  string // strict, accept-new (default) or off
+}
+
+// strictHostKeyChecking maps a host key policy to ssh's StrictHostKeyChecking option.
+var strictHostKeyChecking = map[string]string{
+	HostKeyStrict:    "yes",
+	HostKeyAcceptNew: "accept-new",
+	HostKeyOff:       "no",
+}
+
+// runRemote runs a git command that talks to a remote. Credentials reach git only
+// through its environment, never through remote URLs or arguments: SSH remotes are
+// reached in batch mode under the host key policy, with auth's key written to a
+// temporary file that is removed when the command returns, and HTTPS remotes under
+// auth.TokenURL are sent the token in an http.extraHeader. git's output is redacted,
+// logged, and its tail returned in the error.
+func (g *GitProvider) runRemote(ctx context.Context, auth *domain.GitAuth, args ...string) error {
+	cmd, cleanup, err := g.remoteCommand(ctx, auth, args...)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+	runErr := cmd.Run()
+
+	output := strings.TrimSpace(redact(out.String(), auth))
+	if runErr != nil {
+		if len(output) > maxErrorOutput {
+			output = "..." + output[len(output)-maxErrorOutput:]
+		}
+		return fmt.Errorf("%w: %s", runErr, output)
+	}
+	if output != "" {
+		slog.Debug("git output", "args", redact(strings.Join(args, " "), auth), "output", output)
+	}
+	return nil
+}
+
+// remoteCommand prepares a git command with the remote environment of runRemote, for
+// commands that may reach the remote, such as the lazy fetches of blobless clones.
+// cleanup must be called once the command has run.
+func (g *GitProvider) remoteCommand(ctx context.Context, auth *domain.GitAuth, args ...string) (*exec.Cmd, func(), error) {
+	sshCommand, cleanup, err := g.sshCommand(auth)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Env = append(os.Environ(), "GIT_SSH_COMMAND="+sshCommand, "GIT_TERMINAL_PROMPT=0")
+	if auth != nil && auth.Token != "" && auth.TokenURL != "" {
+		basic := base64.StdEncoding.EncodeToString([]byte(auth.TokenUser + ":" + auth.Token))
+		cmd.Env = append(cmd.Env,
+			"GIT_CONFIG_COUNT=1",
+			"GIT_CONFIG_KEY_0=http."+auth.TokenURL+".extraHeader",
+			"GIT_CONFIG_VALUE_0=Authorization: Basic "+basic,
+		)
+	}
+	return cmd, cleanup, nil
+}
+
+// maxErrorOutput caps the git output included in errors.
+const maxErrorOutput = 1024
+
+// credentialsInURL matches the user info of HTTP URLs.
+var credentialsInURL = regexp.MustCompile(`(?i)(https?://)[^/@\s]+@`)
+
+// redact removes credentials from git output (or a URL): the user info of HTTP URLs,
+// and auth's token wherever it appears.
+func redact(s string, auth *domain.GitAuth) string {
+	if auth != nil && auth.Token != "" {
+		s = strings.ReplaceAll(s, auth.Token, "***")
+	}
+	return credentialsInURL.ReplaceAllString(s, "${1}***@")
+}
+
+// sshCommand builds the GIT_SSH_COMMAND of a remote operation.
+func (g *GitProvider) sshCommand(auth *domain.GitAuth) (string, func(), error) {
+	checking, ok := strictHostKeyChecking[g.ssh.HostKeyPolicy]
+	if !ok {
+		checking = strictHostKeyChecking[HostKeyAcceptNew]
+	}
+	parts := []string{"ssh", "-o", "BatchMode=yes", "-o", "StrictHostKeyChecking=" + checking}
+
+	switch {
+	case g.ssh.HostKeyPolicy == HostKeyOff:
+		parts = append(parts, "-o", "UserKnownHostsFile=/dev/null")
+	case g.ssh.KnownHostsFile != "":
+		if err := os.MkdirAll(filepath.Dir(g.ssh.KnownHostsFile), 0o700); err != nil {
+			return "", nil, fmt.Errorf("known_hosts directory: %w", err)
+		}
+		parts = append(parts, "-o", shellQuote("UserKnownHostsFile="+g.ssh.KnownHostsFile))
+	}
+
+	if auth == nil || len(auth.SSHKey) == 0 {
+		return strings.Join(parts, " "), func() {}, nil
+	}
+
+	dir, err := os.MkdirTemp("", "codelens-ssh-")
+	if err != nil {
+		return "", nil, fmt.Errorf("ssh key directory: %w", err)
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+	keyFile := filepath.Join(dir, "id")
+	if err := os.WriteFile(keyFile, auth.SSHKey, 0o600); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("write ssh key: %w", err)
+	}
+	parts = append(parts, "-i", shellQuote(keyFile), "-o", "IdentitiesOnly=yes")
+	return strings.Join(parts, " "), cleanup, nil
+}
+
+// shellQuote quotes s for the shell git runs GIT_SSH_COMMAND with.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
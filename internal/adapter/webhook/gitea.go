@@ -0,0 +1,97 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GiteaParser decodes Gitea (and Forgejo) webhook deliveries.
+type GiteaParser struct{}
+
+// NewGiteaParser creates a new Gitea webhook parser.
+func NewGiteaParser() *GiteaParser {
+	return &GiteaParser{}
+}
+
+// giteaRepository is the repository object shared by Gitea event payloads.
+type giteaRepository struct {
+	CloneURL string `json:"clone_url"`
+	HTMLURL  string `json:"html_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+func (r giteaRepository) urls() []string {
+	return nonEmpty(r.CloneURL, r.HTMLURL, r.SSHURL)
+}
+
+// Verify checks the X-Gitea-Signature header (a bare hex HMAC-SHA256 of the body).
+func (p *GiteaParser) Verify(headers http.Header, body []byte, secret string) error {
+	return verifyHMAC(headers.Get("X-Gitea-Signature"), "", body, secret)
+}
+
+// Parse decodes push and pull_request events.
+func (p *GiteaParser) Parse(headers http.Header, body []byte) (*domain.WebhookEvent, error) {
+	evt := &domain.WebhookEvent{Provider: "gitea", Kind: domain.WebhookEventIgnored, DeliveryID: headers.Get("X-Gitea-Delivery")}
+
+	switch headers.Get("X-Gitea-Event") {
+	case "push":
+		var payload struct {
+			Ref        string          `json:"ref"`
+			After      string          `json:"after"`
+			Repository giteaRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode gitea push: %w", err)
+		}
+		evt.RepoURLs = payload.Repository.urls()
+		if payload.After != "" && payload.After != zeroSHA {
+			evt.Kind, evt.Ref, evt.After = domain.WebhookEventPush, payload.Ref, payload.After
+		}
+
+	case "pull_request":
+		var payload struct {
+			Action      string `json:"action"`
+			Number      int    `json:"number"`
+			PullRequest struct {
+				Title   string `json:"title"`
+				HTMLURL string `json:"html_url"`
+				Head    struct {
+					SHA string `json:"sha"`
+				} `json:"head"`
+				Base struct {
+					Ref string `json:"ref"`
+				} `json:"base"`
+			} `json:"pull_request"`
+			Repository giteaRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode gitea pull_request: %w", err)
+		}
+		evt.RepoURLs = payload.Repository.urls()
+
+		action := ""
+		switch payload.Action {
+		case "opened", "reopened":
+			action = domain.PullRequestActionOpened
+		case "synchronized":
+			action = domain.PullRequestActionUpdated
+		}
+		if action == "" {
+			return evt, nil
+		}
+		evt.Kind = domain.WebhookEventPullRequest
+		evt.PullRequest = &domain.PullRequest{
+			Number:     payload.Number,
+			Title:      payload.PullRequest.Title,
+			URL:        payload.PullRequest.HTMLURL,
+			Action:     action,
+			BaseBranch: payload.PullRequest.Base.Ref,
+			HeadSHA:    payload.PullRequest.Head.SHA,
+			HeadRef:    fmt.Sprintf("refs/pull/%d/head", payload.Number),
+		}
+	}
+	return evt, nil
+}
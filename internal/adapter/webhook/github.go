@@ -0,0 +1,109 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GitHubParser decodes GitHub webhook deliveries.
+type GitHubParser struct{}
+
+// NewGitHubParser creates a new GitHub webhook parser.
+func NewGitHubParser() *GitHubParser {
+	return &GitHubParser{}
+}
+
+// githubRepository is the repository object shared by GitHub event payloads.
+type githubRepository struct {
+	CloneURL string `json:"clone_url"`
+	HTMLURL  string `json:"html_url"`
+	SSHURL   string `json:"ssh_url"`
+}
+
+func (r githubRepository) urls() []string {
+	return nonEmpty(r.CloneURL, r.HTMLURL, r.SSHURL)
+}
+
+// Verify checks the X-Hub-Signature-256 header.
+func (p *GitHubParser) Verify(headers http.Header, body []byte, secret string) error {
+	return verifyHMAC(headers.Get("X-Hub-Signature-256"), "sha256=", body, secret)
+}
+
+// Parse decodes push, pull_request and ping events.
+func (p *GitHubParser) Parse(headers http.Header, body []byte) (*domain.WebhookEvent, error) {
+	evt := &domain.WebhookEvent{Provider: "github", Kind: domain.WebhookEventIgnored, DeliveryID: headers.Get("X-GitHub-Delivery")}
+
+	switch headers.Get("X-GitHub-Event") {
+	case "ping":
+		// Repository hooks name their repository, whose secret the ping is verified with
+		var payload struct {
+			Repository githubRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode github ping: %w", err)
+		}
+		evt.Kind, evt.RepoURLs = domain.WebhookEventPing, payload.Repository.urls()
+
+	case "push":
+		var payload struct {
+			Ref        string           `json:"ref"`
+			After      string           `json:"after"`
+			Deleted    bool             `json:"deleted"`
+			Repository githubRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode github push: %w", err)
+		}
+		evt.RepoURLs = payload.Repository.urls()
+		if !payload.Deleted && payload.After != zeroSHA {
+			evt.Kind, evt.Ref, evt.After = domain.WebhookEventPush, payload.Ref, payload.After
+		}
+
+	case "pull_request":
+		var payload struct {
+			Action      string `json:"action"`
+			Number      int    `json:"number"`
+			PullRequest struct {
+				Title   string `json:"title"`
+				HTMLURL string `json:"html_url"`
+				Draft   bool   `json:"draft"`
+				Head    struct {
+					SHA string `json:"sha"`
+				} `json:"head"`
+				Base struct {
+					Ref string `json:"ref"`
+				} `json:"base"`
+			} `json:"pull_request"`
+			Repository githubRepository `json:"repository"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode github pull_request: %w", err)
+		}
+		evt.RepoURLs = payload.Repository.urls()
+
+		action := ""
+		switch payload.Action {
+		case "opened", "reopened", "ready_for_review":
+			action = domain.PullRequestActionOpened
+		case "synchronize":
+			action = domain.PullRequestActionUpdated
+		}
+		if action == "" || payload.PullRequest.Draft {
+			return evt, nil
+		}
+		evt.Kind = domain.WebhookEventPullRequest
+		evt.PullRequest = &domain.PullRequest{
+			Number:     payload.Number,
+			Title:      payload.PullRequest.Title,
+			URL:        payload.PullRequest.HTMLURL,
+			Action:     action,
+			BaseBranch: payload.PullRequest.Base.Ref,
+			HeadSHA:    payload.PullRequest.Head.SHA,
+			HeadRef:    fmt.Sprintf("refs/pull/%d/head", payload.Number),
+		}
+	}
+	return evt, nil
+}
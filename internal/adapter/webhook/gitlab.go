@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// GitLabParser decodes GitLab webhook deliveries.
+type GitLabParser struct{}
+
+// NewGitLabParser creates a new GitLab webhook parser.
+func NewGitLabParser() *GitLabParser {
+	return &GitLabParser{}
+}
+
+// gitlabProject is the project object shared by GitLab event payloads.
+type gitlabProject struct {
+	GitHTTPURL string `json:"git_http_url"`
+	GitSSHURL  string `json:"git_ssh_url"`
+	WebURL     string `json:"web_url"`
+}
+
+func (p gitlabProject) urls() []string {
+	return nonEmpty(p.GitHTTPURL, p.WebURL, p.GitSSHURL)
+}
+
+// Verify checks the X-Gitlab-Token header. GitLab sends the secret itself rather than a signature.
+func (p *GitLabParser) Verify(headers http.Header, body []byte, secret string) error {
+	return verifyToken(headers.Get("X-Gitlab-Token"), secret)
+}
+
+// Parse decodes push and merge request events.
+func (p *GitLabParser) Parse(headers http.Header, body []byte) (*domain.WebhookEvent, error) {
+	evt := &domain.WebhookEvent{Provider: "gitlab", Kind: domain.WebhookEventIgnored, DeliveryID: headers.Get("X-Gitlab-Event-UUID")}
+
+	switch headers.Get("X-Gitlab-Event") {
+	case "Push Hook":
+		var payload struct {
+			Ref     string        `json:"ref"`
+			After   string        `json:"after"`
+			Project gitlabProject `json:"project"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode gitlab push: %w", err)
+		}
+		evt.RepoURLs = payload.Project.urls()
+		if payload.After != "" && payload.After != zeroSHA {
+			evt.Kind, evt.Ref, evt.After = domain.WebhookEventPush, payload.Ref, payload.After
+		}
+
+	case "Merge Request Hook":
+		var payload struct {
+			Project          gitlabProject `json:"project"`
+			ObjectAttributes struct {
+				IID          int    `json:"iid"`
+				Title        string `json:"title"`
+				URL          string `json:"url"`
+				Action       string `json:"action"`
+				TargetBranch string `json:"target_branch"`
+				OldRev       string `json:"oldrev"` // set on "update" only when commits were pushed
+				Draft        bool   `json:"draft"`
+				LastCommit   struct {
+					ID string `json:"id"`
+				} `json:"last_commit"`
+			} `json:"object_attributes"`
+		}
+		if err := json.Unmarshal(body, &payload); err != nil {
+			return nil, fmt.Errorf("decode gitlab merge request: %w", err)
+		}
+		evt.RepoURLs = payload.Project.urls()
+		mr := payload.ObjectAttributes
+
+		action := ""
+		switch {
+		case mr.Action == "open" || mr.Action == "reopen":
+			action = domain.PullRequestActionOpened
+		case mr.Action == "update" && mr.OldRev != "":
+			action = domain.PullRequestActionUpdated
+		}
+		if action == "" || mr.Draft {
+			return evt, nil
+		}
+		evt.Kind = domain.WebhookEventPullRequest
+		evt.PullRequest = &domain.PullRequest{
+			Number:     mr.IID,
+			Title:      mr.Title,
+			URL:        mr.URL,
+			Action:     action,
+			BaseBranch: mr.TargetBranch,
+			HeadSHA:    mr.LastCommit.ID,
+			HeadRef:    fmt.Sprintf("refs/merge-requests/%d/head", mr.IID),
+		}
+	}
+	return evt, nil
+}
@@ -0,0 +1,51 @@
+// Package webhook decodes push and pull-request webhook deliveries from Git forges.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// zeroSHA is the "after" commit of a push that deleted the ref.
+const zeroSHA = "0000000000000000000000000000000000000000"
+
+// verifyHMAC checks a hex-encoded HMAC-SHA256 of body, optionally prefixed (e.g. "sha256=").
+func verifyHMAC(signature, prefix string, body []byte, secret string) error {
+	if secret == "" || !strings.HasPrefix(signature, prefix) {
+		return port.ErrWebhookSignature
+	}
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, prefix))
+	if err != nil {
+		return port.ErrWebhookSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return port.ErrWebhookSignature
+	}
+	return nil
+}
+
+// verifyToken compares a plain shared token in constant time.
+func verifyToken(token, secret string) error {
+	if secret == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return port.ErrWebhookSignature
+	}
+	return nil
+}
+
+// nonEmpty returns the non-empty strings of values.
+func nonEmpty(values ...string) []string {
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		if v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
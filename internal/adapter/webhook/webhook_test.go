@@ -0,0 +1,208 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+func fixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func headers(kv ...string) http.Header {
+	h := http.Header{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		h.Set(kv[i], kv[i+1])
+	}
+	return h
+}
+
+func sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+var (
+	githubURLs = []string{"https://github.com/acme/app.git", "https://github.com/acme/app", "git@github.com:acme/app.git"}
+	gitlabURLs = []string{"https://gitlab.example.com/group/sub/app.git", "https://gitlab.example.com/group/sub/app", "git@gitlab.example.com:group/sub/app.git"}
+	giteaURLs  = []string{"https://gitea.example.com/acme/app.git", "https://gitea.example.com/acme/app", "git@gitea.example.com:acme/app.git"}
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		parser  port.WebhookParser
+		headers http.Header
+		fixture string
+		want    *domain.WebhookEvent
+	}{
+		{
+			"github ping", NewGitHubParser(),
+			headers("X-GitHub-Event", "ping", "X-GitHub-Delivery", "d-1"), "github_ping.json",
+			&domain.WebhookEvent{Provider: "github", Kind: domain.WebhookEventPing, DeliveryID: "d-1", RepoURLs: githubURLs},
+		},
+		{
+			"github push", NewGitHubParser(),
+			headers("X-GitHub-Event", "push", "X-GitHub-Delivery", "d-2"), "github_push.json",
+			&domain.WebhookEvent{
+				Provider: "github", Kind: domain.WebhookEventPush, DeliveryID: "d-2", RepoURLs: githubURLs,
+				Ref: "refs/heads/main", After: "0d1a26e67d8f5eaf1f6ba5c57fc3c7d91ac0fd1c",
+			},
+		},
+		{
+			"github branch deletion", NewGitHubParser(),
+			headers("X-GitHub-Event", "push"), "github_push_deleted.json",
+			&domain.WebhookEvent{Provider: "github", Kind: domain.WebhookEventIgnored, RepoURLs: githubURLs},
+		},
+		{
+			"github pull request opened", NewGitHubParser(),
+			headers("X-GitHub-Event", "pull_request", "X-GitHub-Delivery", "d-3"), "github_pull_request_opened.json",
+			&domain.WebhookEvent{
+				Provider: "github", Kind: domain.WebhookEventPullRequest, DeliveryID: "d-3", RepoURLs: githubURLs,
+				PullRequest: &domain.PullRequest{
+					Number: 42, Title: "Add rate limiting", URL: "https://github.com/acme/app/pull/42",
+					Action: domain.PullRequestActionOpened, BaseBranch: "main",
+					HeadSHA: "9c48853fa3dc5c1c3d6f1f1cd1f2f5ae30ffd2b6", HeadRef: "refs/pull/42/head",
+				},
+			},
+		},
+		{
+			"github draft pull request", NewGitHubParser(),
+			headers("X-GitHub-Event", "pull_request"), "github_pull_request_draft.json",
+			&domain.WebhookEvent{Provider: "github", Kind: domain.WebhookEventIgnored, RepoURLs: githubURLs},
+		},
+		{
+			"github unknown event", NewGitHubParser(),
+			headers("X-GitHub-Event", "star"), "github_push.json",
+			&domain.WebhookEvent{Provider: "github", Kind: domain.WebhookEventIgnored},
+		},
+		{
+			"gitlab push", NewGitLabParser(),
+			headers("X-Gitlab-Event", "Push Hook", "X-Gitlab-Event-UUID", "g-1"), "gitlab_push.json",
+			&domain.WebhookEvent{
+				Provider: "gitlab", Kind: domain.WebhookEventPush, DeliveryID: "g-1", RepoURLs: gitlabURLs,
+				Ref: "refs/heads/main", After: "da1560886d4f094c3e6c9ef40349f7d38b5d27d7",
+			},
+		},
+		{
+			"gitlab merge request update", NewGitLabParser(),
+			headers("X-Gitlab-Event", "Merge Request Hook", "X-Gitlab-Event-UUID", "g-2"), "gitlab_merge_request_update.json",
+			&domain.WebhookEvent{
+				Provider: "gitlab", Kind: domain.WebhookEventPullRequest, DeliveryID: "g-2", RepoURLs: gitlabURLs,
+				PullRequest: &domain.PullRequest{
+					Number: 7, Title: "Speed up indexing", URL: "https://gitlab.example.com/group/sub/app/-/merge_requests/7",
+					Action: domain.PullRequestActionUpdated, BaseBranch: "main",
+					HeadSHA: "b83d6e391c22777fca1ed3012fce84f633d7fed0", HeadRef: "refs/merge-requests/7/head",
+				},
+			},
+		},
+		{
+			"gitlab merge request approval", NewGitLabParser(),
+			headers("X-Gitlab-Event", "Merge Request Hook"), "gitlab_merge_request_approved.json",
+			&domain.WebhookEvent{Provider: "gitlab", Kind: domain.WebhookEventIgnored, RepoURLs: gitlabURLs},
+		},
+		{
+			"gitea push", NewGiteaParser(),
+			headers("X-Gitea-Event", "push", "X-Gitea-Delivery", "t-1"), "gitea_push.json",
+			&domain.WebhookEvent{
+				Provider: "gitea", Kind: domain.WebhookEventPush, DeliveryID: "t-1", RepoURLs: giteaURLs,
+				Ref: "refs/heads/main", After: "bffeb74224043ba2feb48d137756c8a9331c449a",
+			},
+		},
+		{
+			"gitea pull request synchronized", NewGiteaParser(),
+			headers("X-Gitea-Event", "pull_request", "X-Gitea-Delivery", "t-2"), "gitea_pull_request_synchronized.json",
+			&domain.WebhookEvent{
+				Provider: "gitea", Kind: domain.WebhookEventPullRequest, DeliveryID: "t-2", RepoURLs: giteaURLs,
+				PullRequest: &domain.PullRequest{
+					Number: 12, Title: "Handle empty diffs", URL: "https://gitea.example.com/acme/app/pulls/12",
+					Action: domain.PullRequestActionUpdated, BaseBranch: "main",
+					HeadSHA: "4f0d5a4e58a3c6a2c91e7e5d0a1b3c2d4e5f6a7b", HeadRef: "refs/pull/12/head",
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		got, err := tt.parser.Parse(tt.headers, fixture(t, tt.fixture))
+		if err != nil {
+			t.Errorf("%s: Parse: %v", tt.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: Parse =\n%+v\nwant\n%+v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseRejectsMalformedPayload(t *testing.T) {
+	tests := []struct {
+		parser  port.WebhookParser
+		headers http.Header
+	}{
+		{NewGitHubParser(), headers("X-GitHub-Event", "push")},
+		{NewGitLabParser(), headers("X-Gitlab-Event", "Push Hook")},
+		{NewGiteaParser(), headers("X-Gitea-Event", "pull_request")},
+	}
+	for _, tt := range tests {
+		if _, err := tt.parser.Parse(tt.headers, []byte(`{"ref": 1`)); err == nil {
+			t.Errorf("%T accepted a truncated payload", tt.parser)
+		}
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := fixture(t, "github_push.json")
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = ' '
+	const secret = "8c3f0b1e6a2d4f57"
+
+	tests := []struct {
+		name    string
+		parser  port.WebhookParser
+		headers http.Header
+		body    []byte
+		secret  string
+		ok      bool
+	}{
+		{"github valid", NewGitHubParser(), headers("X-Hub-Signature-256", "sha256="+sign(body, secret)), body, secret, true},
+		{"github wrong secret", NewGitHubParser(), headers("X-Hub-Signature-256", "sha256="+sign(body, "other")), body, secret, false},
+		{"github tampered body", NewGitHubParser(), headers("X-Hub-Signature-256", "sha256="+sign(body, secret)), tampered, secret, false},
+		{"github missing prefix", NewGitHubParser(), headers("X-Hub-Signature-256", sign(body, secret)), body, secret, false},
+		{"github sha1 header only", NewGitHubParser(), headers("X-Hub-Signature", "sha1=0123"), body, secret, false},
+		{"github empty secret", NewGitHubParser(), headers("X-Hub-Signature-256", "sha256="+sign(body, "")), body, "", false},
+		{"gitea valid", NewGiteaParser(), headers("X-Gitea-Signature", sign(body, secret)), body, secret, true},
+		{"gitea prefixed", NewGiteaParser(), headers("X-Gitea-Signature", "sha256="+sign(body, secret)), body, secret, false},
+		{"gitea not hex", NewGiteaParser(), headers("X-Gitea-Signature", "zz"), body, secret, false},
+		{"gitea tampered body", NewGiteaParser(), headers("X-Gitea-Signature", sign(body, secret)), tampered, secret, false},
+		{"gitea empty secret", NewGiteaParser(), headers("X-Gitea-Signature", sign(body, "")), body, "", false},
+		{"gitlab valid", NewGitLabParser(), headers("X-Gitlab-Token", secret), body, secret, true},
+		{"gitlab wrong token", NewGitLabParser(), headers("X-Gitlab-Token", secret+"x"), body, secret, false},
+		{"gitlab missing token", NewGitLabParser(), headers(), body, secret, false},
+		{"gitlab empty secret", NewGitLabParser(), headers("X-Gitlab-Token", ""), body, "", false},
+	}
+	for _, tt := range tests {
+		err := tt.parser.Verify(tt.headers, tt.body, tt.secret)
+		if tt.ok && err != nil {
+			t.Errorf("%s: Verify = %v", tt.name, err)
+		}
+		if !tt.ok && !errors.Is(err, port.ErrWebhookSignature) {
+			t.Errorf("%s: Verify = %v, want ErrWebhookSignature", tt.name, err)
+		}
+	}
+}
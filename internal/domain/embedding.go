@@ -4,19 +4,106 @@ import "time"
 
 // Embedding represents a vectorized chunk of code stored in pgvector.
 type Embedding struct {
-	ID         string    `json:"id"          db:"id"`
-	SnapshotID string    `json:"snapshot_id" db:"snapshot_id"`
-	RepoID     string    `json:"repo_id"     db:"repo_id"`
-	FilePath   string    `json:"file_path"   db:"file_path"`
-	ChunkIndex int       `json:"chunk_index" db:"chunk_index"`
-	Content    string    `json:"content"     db:"content"`
-	Language   string    `json:"language"    db:"language"`
-	Vector     []float32 `json:"-"           db:"vector"`
-	CreatedAt  time.Time `json:"created_at"  db:"created_at"`
+	ID           string    `json:"id"            db:"id"`
+	SnapshotID   string    `json:"snapshot_id"   db:"snapshot_id"`
+	RepoID       string    `json:"repo_id"       db:"repo_id"`
+	FilePath     string    `json:"file_path"     db:"file_path"`
+	ChunkIndex   int       `json:"chunk_index"   db:"chunk_index"`
+	Content      string    `json:"content"       db:"content"`
+	Language     string    `json:"language"      db:"language"`
+	SymbolName   string    `json:"symbol_name"   db:"symbol_name"`
+	SymbolKind   string    `json:"symbol_kind"   db:"symbol_kind"`
+	ParentSymbol string    `json:"parent_symbol" db:"parent_symbol"`
+	StartLine    int       `json:"start_line"    db:"start_line"`
+	EndLine      int       `json:"end_line"      db:"end_line"`
+	CommitHash   string    `json:"commit_hash"   db:"commit_hash"`
+	ContentHash  string    `json:"content_hash"  db:"content_hash"` // sha256 of the whole source file
+	IndexID      string    `json:"index_id"      db:"index_id"`     // embedding index (model + dimension) the vector belongs to
+	Vector       []float32 `json:"-"             db:"vector"`
+	CreatedAt    time.Time `json:"created_at"    db:"created_at"`
+}
+
+// EmbeddingIndex is the set of vectors produced by one embedding model at one dimension.
+// Searches are served by the single active index; others are being built or retired.
+type EmbeddingIndex struct {
+	ID             string     `json:"id"`
+	Model          string     `json:"model"`
+	Dimension      int        `json:"dimension"`
+	Status         string     `json:"status"` // building, active, retired, failed
+	TotalChunks    int        `json:"total_chunks"`
+	EmbeddedChunks int        `json:"embedded_chunks"`
+	Error          string     `json:"error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	ActivatedAt    *time.Time `json:"activated_at,omitempty"`
+}
+
+// Embedding index status constants.
+const (
+	EmbeddingIndexBuilding = "building"
+	EmbeddingIndexActive   = "active"
+	EmbeddingIndexRetired  = "retired"
+	EmbeddingIndexFailed   = "failed"
+)
+
+// VectorIndexStats describes the ANN index serving one vector dimension.
+type VectorIndexStats struct {
+	Dimension     int            `json:"dimension"`
+	Name          string         `json:"name"`
+	Type          string         `json:"type"`   // hnsw, ivfflat, or "" when no index exists
+	Params        map[string]int `json:"params"` // m/ef_construction or lists
+	Valid         bool           `json:"valid"`  // false while a concurrent build is unfinished
+	SizeBytes     int64          `json:"size_bytes"`
+	Rows          int64          `json:"rows"`          // rows with this dimension, all embedding indexes
+	ActiveRows    int64          `json:"active_rows"`   // rows of the active embedding index
+	RowsAtBuild   int64          `json:"rows_at_build"` // rows when the index was last built
+	BuiltAt       *time.Time     `json:"built_at,omitempty"`
+	Recall        *float64       `json:"recall,omitempty"` // ANN vs exact top-k overlap, when measured
+	RecallK       int            `json:"recall_k,omitempty"`
+	RecallSamples int            `json:"recall_samples,omitempty"`
 }
 
 // SimilarChunk is returned by semantic search, including similarity score.
 type SimilarChunk struct {
 	Embedding
-	Similarity float64 `json:"similarity"`
+	Similarity  float64 `json:"similarity"`
+	Score       float64 `json:"score,omitempty"`        // fused rank score from hybrid search
+	RerankScore float64 `json:"rerank_score,omitempty"` // 0–1 relevance from the reranking stage
+}
+
+// SearchFilter narrows a search over embedding chunks.
+type SearchFilter struct {
+	RepoIDs       []string `json:"repo_ids"`                 // one repo, or all repos a user owns for cross-repo search
+	SnapshotID    string   `json:"snapshot_id,omitempty"`    // exact snapshot; empty = latest snapshot of each repo
+	CommitHash    string   `json:"commit,omitempty"`         // snapshot at this commit (prefix match), if SnapshotID is empty
+	Language      string   `json:"language,omitempty"`       // as detected at index time (go, python, ...)
+	PathGlob      string   `json:"path,omitempty"`           // e.g. "internal/**/*.go"
+	SymbolKind    string   `json:"symbol_kind,omitempty"`    // see SymbolKind* constants
+	MinSimilarity float64  `json:"min_similarity,omitempty"` // cosine similarity floor, 0–1
+	Limit         int      `json:"limit"`
+	Offset        int      `json:"offset"`
+}
+
+// Symbol kind constants for embedding chunks.
+const (
+	SymbolKindFile   = "file"   // package clause, imports, module header
+	SymbolKindFunc   = "func"   // top-level function
+	SymbolKindMethod = "method" // function with a receiver
+	SymbolKindType   = "type"   // type declaration
+	SymbolKindConst  = "const"  // const block
+	SymbolKindVar    = "var"    // var block
+	SymbolKindBlock  = "block"  // plain line window (no structure detected)
+)
+
+// Citation maps an [n] marker in a RAG answer back to the source lines it refers to.
+type Citation struct {
+	N      int       `json:"n"`
+	File   string    `json:"file"`
+	Lines  LineRange `json:"lines"`
+	Commit string    `json:"commit"`
+}
+
+// LineRange is an inclusive, 1-based range of lines in a file.
+type LineRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
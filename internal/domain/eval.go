@@ -0,0 +1,50 @@
+package domain
+
+import "time"
+
+// EvalSet is a golden set of questions for one repository, loaded from YAML.
+type EvalSet struct {
+	Name   string     `json:"name"    yaml:"name"`
+	RepoID string     `json:"repo_id" yaml:"repo_id"`
+	K      int        `json:"k"       yaml:"k"` // cutoff for recall@k (default 5)
+	Cases  []EvalCase `json:"cases"   yaml:"questions"`
+}
+
+// EvalCase is a single golden question with the files that should be retrieved
+// and the facts a correct answer must state.
+type EvalCase struct {
+	ID            string   `json:"id"             yaml:"id"`
+	Question      string   `json:"question"       yaml:"question"`
+	ExpectedFiles []string `json:"expected_files" yaml:"expected_files"`
+	Facts         []string `json:"facts"          yaml:"facts"`
+}
+
+// EvalRun is one execution of an EvalSet with its aggregate metrics.
+type EvalRun struct {
+	ID           string            `json:"id"`
+	RepoID       string            `json:"repo_id"`
+	SetName      string            `json:"set_name"`
+	Label        string            `json:"label"`  // free-form tag, e.g. "chunk-256"
+	Config       map[string]string `json:"config"` // models and retrieval options used
+	K            int               `json:"k"`
+	CaseCount    int               `json:"case_count"`
+	RecallAtK    float64           `json:"recall_at_k"`
+	MRR          float64           `json:"mrr"`
+	Faithfulness float64           `json:"faithfulness"`
+	FactRecall   float64           `json:"fact_recall"`
+	Results      []EvalResult      `json:"results,omitempty"`
+	CreatedAt    time.Time         `json:"created_at"`
+}
+
+// EvalResult holds the per-question metrics of an EvalRun.
+type EvalResult struct {
+	CaseID         string   `json:"case_id"`
+	Question       string   `json:"question"`
+	Recall         float64  `json:"recall"`
+	ReciprocalRank float64  `json:"reciprocal_rank"`
+	Faithfulness   float64  `json:"faithfulness"`
+	FactRecall     float64  `json:"fact_recall"`
+	RetrievedFiles []string `json:"retrieved_files"`
+	Answer         string   `json:"answer"`
+	Error          string   `json:"error,omitempty"`
+}
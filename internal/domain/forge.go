@@ -0,0 +1,18 @@
+package domain
+
+// ForgeRepo is a repository listed from a forge account. The JSON names follow the
+// GitHub API, which the repository import screen was first written against.
+type ForgeRepo struct {
+	ID            string `json:"id"`
+	Forge         string `json:"forge"` // github, gitlab, gitea, bitbucket
+	Name          string `json:"name"`
+	FullName      string `json:"full_name"`
+	Description   string `json:"description"`
+	HTMLURL       string `json:"html_url"`
+	CloneURL      string `json:"clone_url"` // HTTPS, without credentials
+	DefaultBranch string `json:"default_branch"`
+	Private       bool   `json:"private"`
+	Language      string `json:"language"`
+	Stars         int    `json:"stargazers_count"`
+	UpdatedAt     string `json:"updated_at"`
+}
@@ -0,0 +1,49 @@
+package domain
+
+import "time"
+
+// IssueTrackerConfig is where the findings of a repo are opened as issues.
+type IssueTrackerConfig struct {
+	RepoID        string    `json:"repo_id"`
+	Kind          string    `json:"kind"`     // github, gitlab, jira
+	BaseURL       string    `json:"base_url"` // Jira site; for forges, a host other than the repo's
+	Project       string    `json:"project"`  // owner/name, group/project or Jira project key; empty = the repo itself
+	Username      string    `json:"username"` // Jira Cloud account email; empty = bearer token
+	Token         string    `json:"-"`        // empty = the repo owner's OAuth token
+	TokenSealed   []byte    `json:"-"`        // Token encrypted at rest: nonce || AES-GCM sealed
+	HasToken      bool      `json:"has_token"`
+	IssueType     string    `json:"issue_type"` // Jira issue type
+	Labels        []string  `json:"labels"`     // added to every issue, next to severity:<level>
+	TitleTemplate string    `json:"title_template"`
+	BodyTemplate  string    `json:"body_template"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// Issue tracker kinds.
+const (
+	IssueTrackerGitHub = "github"
+	IssueTrackerGitLab = "gitlab"
+	IssueTrackerJira   = "jira"
+)
+
+// IssueDraft is the content of an issue about to be opened.
+type IssueDraft struct {
+	Title  string
+	Body   string
+	Labels []string
+}
+
+// IssueLink points from a finding to the issue opened for it.
+type IssueLink struct {
+	Tracker string `json:"tracker"`
+	Key     string `json:"key"` // issue number, or Jira issue key
+	URL     string `json:"url"`
+	State   string `json:"state"` // open, closed
+}
+
+// Issue state constants.
+const (
+	IssueStateOpen   = "open"
+	IssueStateClosed = "closed"
+)
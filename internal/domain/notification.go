@@ -0,0 +1,71 @@
+package domain
+
+import "time"
+
+// Notification event types.
+const (
+	EventJobComplete     = "job.complete"
+	EventJobFailed       = "job.failed"
+	EventScoreRegression = "score.regression" // a strategy scored lower than its previous run
+	EventCriticalFinding = "finding.critical" // a review raised critical findings
+)
+
+// NotificationEvents lists the event types users can subscribe to.
+var NotificationEvents = []string{EventJobComplete, EventJobFailed, EventScoreRegression, EventCriticalFinding}
+
+// NotificationEvent is something that happened to a repository that users can be notified of.
+// It is also the JSON body sent to generic webhooks.
+type NotificationEvent struct {
+	Type       string         `json:"type"`
+	RepoID     string         `json:"repo_id"`
+	RepoName   string         `json:"repo_name"`
+	UserID     string         `json:"-"` // owner of the repo, whose subscriptions apply
+	Title      string         `json:"title"`
+	Message    string         `json:"message"`
+	URL        string         `json:"url,omitempty"` // where to see the details, if anywhere
+	Data       map[string]any `json:"data,omitempty"`
+	OccurredAt time.Time      `json:"occurred_at"`
+}
+
+// NotificationSubscription sends a user's events for all repos, or one, to a channel.
+type NotificationSubscription struct {
+	ID        string    `json:"id"`
+	UserID    string    `json:"user_id"`
+	RepoID    string    `json:"repo_id,omitempty"` // empty = every repo of the user
+	Channel   string    `json:"channel"`           // webhook, slack, email
+	Target    string    `json:"target"`            // URL or email address
+	Secret    string    `json:"-"`                 // webhook: HMAC key for the X-CodeLens-Signature header
+	HasSecret bool      `json:"has_secret"`
+	Events    []string  `json:"events"` // empty = every event
+	Enabled   bool      `json:"enabled"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Notification channels.
+const (
+	ChannelWebhook = "webhook"
+	ChannelSlack   = "slack" // Slack-compatible incoming webhook (Slack, Mattermost, Rocket.Chat)
+	ChannelEmail   = "email"
+)
+
+// NotificationDelivery is one attempt-tracked send of an event to a subscription.
+type NotificationDelivery struct {
+	ID             string     `json:"id"`
+	SubscriptionID string     `json:"subscription_id"`
+	EventType      string     `json:"event_type"`
+	Payload        []byte     `json:"-"` // the NotificationEvent as JSON
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      string     `json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+}
+
+// Delivery status constants.
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryFailed    = "failed" // gave up after the last retry
+)
@@ -4,21 +4,52 @@ import "time"
 
 // Repo represents a tracked Git repository.
 type Repo struct {
-	ID             string    `json:"id"           db:"id"`
-	UserID         string    `json:"user_id"      db:"user_id"`
-	Name           string    `json:"name"         db:"name"`
-	URL            string    `json:"url"          db:"url"`
-	DefaultBranch  string    `json:"default_branch" db:"default_branch"`
-	LocalPath      string    `json:"-"            db:"local_path"`
-	Status         string    `json:"status"       db:"status"` // cloning, ready, error
-	ReportLanguage string    `json:"report_language" db:"report_language"`
-	CreatedAt      time.Time `json:"created_at"   db:"created_at"`
-	UpdatedAt      time.Time `json:"updated_at"   db:"updated_at"`
+	ID             string       `json:"id"           db:"id"`
+	UserID         string       `json:"user_id"      db:"user_id"`
+	Name           string       `json:"name"         db:"name"`
+	URL            string       `json:"url"          db:"url"`
+	DefaultBranch  string       `json:"default_branch" db:"default_branch"`
+	LocalPath      string       `json:"-"            db:"local_path"`
+	Status         string       `json:"status"       db:"status"` // pending, cloning, ready, error, evicted
+	ReportLanguage string       `json:"report_language" db:"report_language"`
+	SyncInterval   int          `json:"sync_interval_minutes" db:"sync_interval_minutes"` // 0 = manual sync only
+	HeadCommit     string       `json:"head_commit"           db:"head_commit"`           // HEAD of the tracked branch at the last sync
+	LastSyncedAt   *time.Time   `json:"last_synced_at,omitempty" db:"last_synced_at"`
+	SyncError      string       `json:"sync_error,omitempty"  db:"sync_error"`
+	CloneOptions   CloneOptions `json:"clone_options"`
+	DiskBytes      int64        `json:"disk_bytes"   db:"disk_bytes"`     // size of the clone, counted against the owner's quota
+	LastUsedAt     time.Time    `json:"last_used_at" db:"last_used_at"`   // idle clones are evicted least recently used first
+	WebhookSecret  string       `json:"-"            db:"webhook_secret"` // verifies webhook deliveries for this repo
+	CreatedAt      time.Time    `json:"created_at"   db:"created_at"`
+	UpdatedAt      time.Time    `json:"updated_at"   db:"updated_at"`
 }
 
 // RepoStatus constants.
 const (
+	RepoStatusPending = "pending" // added without cloning
 	RepoStatusCloning = "cloning"
 	RepoStatusReady   = "ready"
 	RepoStatusError   = "error"
+	RepoStatusEvicted = "evicted" // clone removed to free disk space; cloned again when used
 )
+
+// CloneOptions make clones of large repositories cheaper. The zero value is a full clone.
+type CloneOptions struct {
+	Depth        int      `json:"depth"`         // commits of history fetched (0 = all); deepened on demand
+	Blobless     bool     `json:"blobless"`      // file contents are fetched when first needed
+	SingleBranch bool     `json:"single_branch"` // fetch the tracked branch only
+	SparsePaths  []string `json:"sparse_paths"`  // directories checked out, indexed and analyzed (empty = all)
+	Branch       string   `json:"-"`             // tracked branch, set when cloning
+}
+
+// GitAuth carries the credentials of a git network operation (clone, fetch, pull).
+// A nil GitAuth means none. Credentials are handed to git through its environment,
+// never through remote URLs or command arguments.
+type GitAuth struct {
+	SSHKey []byte // OpenSSH private key, used for SSH remotes
+
+	// HTTPS remotes under TokenURL authenticate as TokenUser with Token as the password
+	TokenURL  string // e.g. "https://github.com/"
+	TokenUser string
+	Token     string
+}
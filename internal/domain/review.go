@@ -0,0 +1,78 @@
+package domain
+
+import "time"
+
+// Review is an AI review of the changes in a pull request revision.
+type Review struct {
+	ID           string     `json:"id"`
+	RepoID       string     `json:"repo_id"`
+	Provider     string     `json:"provider"` // forge the pull request lives on
+	Number       int        `json:"number"`
+	Title        string     `json:"title"`
+	URL          string     `json:"url"`
+	BaseSHA      string     `json:"base_sha"` // merge base the diff starts from
+	HeadSHA      string     `json:"head_sha"`
+	Status       string     `json:"status"` // running, complete, failed
+	Summary      string     `json:"summary"`
+	Error        string     `json:"error,omitempty"`
+	FindingCount int        `json:"finding_count"`
+	CreatedAt    time.Time  `json:"created_at"`
+	CompletedAt  *time.Time `json:"completed_at,omitempty"`
+
+	CommentsPostedAt *time.Time `json:"comments_posted_at,omitempty"` // last time the findings were posted to the pull request
+	CommentsError    string     `json:"comments_error,omitempty"`     // why the last post failed
+}
+
+// Review status constants.
+const (
+	ReviewStatusRunning  = "running"
+	ReviewStatusComplete = "complete"
+	ReviewStatusFailed   = "failed"
+)
+
+// Finding is a single issue raised by a review, anchored to a file and, when the
+// line is part of the diff, to a line of the new version of the file.
+type Finding struct {
+	ID        string    `json:"id"`
+	ReviewID  string    `json:"review_id"`
+	RepoID    string    `json:"repo_id"`
+	FilePath  string    `json:"file_path"`
+	Line      int       `json:"line"` // 0 = applies to the whole file
+	Severity  string    `json:"severity"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+
+	Issue         *IssueLink `json:"issue,omitempty"` // tracker issue opened for the finding
+	IssueSyncedAt *time.Time `json:"issue_synced_at,omitempty"`
+}
+
+// Finding severity constants, most severe first.
+const (
+	SeverityCritical = "critical"
+	SeverityHigh     = "high"
+	SeverityMedium   = "medium"
+	SeverityLow      = "low"
+	SeverityInfo     = "info"
+)
+
+// Severities lists the finding severities, most severe first.
+var Severities = []string{SeverityCritical, SeverityHigh, SeverityMedium, SeverityLow, SeverityInfo}
+
+// ForgePullRequest identifies a pull request revision to comment on.
+type ForgePullRequest struct {
+	RepoURL string // URL of the repository the pull request targets
+	Number  int
+	BaseSHA string
+	HeadSHA string
+}
+
+// ForgeComment is a comment on a pull request: a discussion comment, or an inline
+// comment on a line of the new version of a file when Path is set.
+type ForgeComment struct {
+	ID     string // forge-specific, opaque
+	Author string // login of the user who wrote it, as returned by ForgeClient.Comments
+	Path   string
+	Line   int
+	Body   string
+}
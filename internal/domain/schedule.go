@@ -0,0 +1,30 @@
+package domain
+
+import "time"
+
+// Schedule runs analysis strategies on a repository on a recurring cron schedule.
+type Schedule struct {
+	ID            string     `json:"id"`
+	RepoID        string     `json:"repo_id"`
+	Strategies    []string   `json:"strategies"` // empty = every strategy
+	Cron          string     `json:"cron"`       // five-field expression or macro, e.g. "0 2 * * *", "@weekly"
+	Timezone      string     `json:"timezone"`   // IANA name the cron expression is evaluated in
+	SyncBeforeRun bool       `json:"sync_before_run"`
+	Enabled       bool       `json:"enabled"`
+	NextRunAt     *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastCommit    string     `json:"last_commit,omitempty"` // HEAD analyzed by the last completed run
+	LastJobID     string     `json:"last_job_id,omitempty"`
+	LastStatus    string     `json:"last_status,omitempty"` // started, complete, skipped, error
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// Schedule run status constants.
+const (
+	ScheduleRunStarted  = "started"
+	ScheduleRunComplete = "complete" // the started analysis finished
+	ScheduleRunSkipped  = "skipped"  // HEAD unchanged since the last completed run
+	ScheduleRunError    = "error"
+)
@@ -4,15 +4,19 @@ import "time"
 
 // Snapshot represents an immutable point-in-time capture of a repository at a specific commit.
 type Snapshot struct {
-	ID         string    `json:"id"          db:"id"`
-	RepoID     string    `json:"repo_id"     db:"repo_id"`
-	CommitHash string    `json:"commit_hash" db:"commit_hash"`
-	Branch     string    `json:"branch"      db:"branch"`
-	Message    string    `json:"message"     db:"message"`
-	Author     string    `json:"author"      db:"author"`
-	FileCount  int       `json:"file_count"  db:"file_count"`
-	Status     string    `json:"status"      db:"status"` // pending, vectorized, analyzed
-	CreatedAt  time.Time `json:"created_at"  db:"created_at"`
+	ID          string     `json:"id"                    db:"id"`
+	RepoID      string     `json:"repo_id"               db:"repo_id"`
+	CommitHash  string     `json:"commit_hash"           db:"commit_hash"`
+	Branch      string     `json:"branch"                db:"branch"`
+	Message     string     `json:"message"               db:"message"`
+	Author      string     `json:"author"                db:"author"`
+	FileCount   int        `json:"file_count"            db:"file_count"`
+	ChunkCount  int        `json:"chunk_count"           db:"chunk_count"`  // chunks in the RAG index
+	FailedFiles int        `json:"failed_files"          db:"failed_files"` // files that could not be indexed
+	IndexError  string     `json:"index_error,omitempty" db:"index_error"`  // why indexing failed, when status is failed
+	Status      string     `json:"status"                db:"status"`       // pending, vectorized, failed, pruned, analyzed
+	IndexedAt   *time.Time `json:"indexed_at,omitempty"  db:"indexed_at"`
+	CreatedAt   time.Time  `json:"created_at"            db:"created_at"`
 }
 
 // CommitInfo is a lightweight representation of a git commit for log output.
@@ -24,9 +28,27 @@ type CommitInfo struct {
 	Files     int       `json:"files_changed"`
 }
 
+// IndexFailure records a file of a snapshot that could not be indexed.
+type IndexFailure struct {
+	SnapshotID string    `json:"snapshot_id"`
+	FilePath   string    `json:"file_path"`
+	Stage      string    `json:"stage"` // chunk, embed, write
+	Error      string    `json:"error"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Index failure stages.
+const (
+	IndexStageChunk = "chunk"
+	IndexStageEmbed = "embed"
+	IndexStageWrite = "write"
+)
+
 // Snapshot status constants.
 const (
 	SnapshotStatusPending    = "pending"
 	SnapshotStatusVectorized = "vectorized"
+	SnapshotStatusFailed     = "failed"
+	SnapshotStatusPruned     = "pruned" // index removed by a purge or by retention
 	SnapshotStatusAnalyzed   = "analyzed"
 )
@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// SSHKey is a key pair generated for cloning over SSH: a deploy key of one repo, or a
+// key of the user used for every repo of theirs without a deploy key.
+type SSHKey struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"user_id"`
+	RepoID      string     `json:"repo_id,omitempty"` // empty = user key
+	Name        string     `json:"name"`
+	KeyType     string     `json:"key_type"`    // ssh-ed25519
+	PublicKey   string     `json:"public_key"`  // authorized_keys format, to add to the forge
+	Fingerprint string     `json:"fingerprint"` // SHA256:...
+	PrivateKey  []byte     `json:"-"`           // encrypted at rest
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
@@ -0,0 +1,37 @@
+package domain
+
+// WebhookEvent is a forge webhook delivery decoded into the fields CodeLens acts on.
+type WebhookEvent struct {
+	Provider    string       `json:"provider"` // github, gitlab, gitea
+	Kind        string       `json:"kind"`     // see WebhookEvent* constants
+	DeliveryID  string       `json:"delivery_id,omitempty"`
+	RepoURLs    []string     `json:"repo_urls"`       // clone, web and SSH URLs of the repository
+	Ref         string       `json:"ref,omitempty"`   // push: full ref name, e.g. refs/heads/main
+	After       string       `json:"after,omitempty"` // push: commit the ref now points to
+	PullRequest *PullRequest `json:"pull_request,omitempty"`
+}
+
+// Webhook event kinds.
+const (
+	WebhookEventPush        = "push"
+	WebhookEventPullRequest = "pull_request"
+	WebhookEventPing        = "ping"
+	WebhookEventIgnored     = "ignored" // an event or action CodeLens does not act on
+)
+
+// PullRequest identifies a pull (or merge) request revision to review.
+type PullRequest struct {
+	Number     int    `json:"number"`
+	Title      string `json:"title"`
+	URL        string `json:"url"`
+	Action     string `json:"action"`      // see PullRequestAction* constants
+	BaseBranch string `json:"base_branch"` // branch the request merges into
+	HeadSHA    string `json:"head_sha"`
+	HeadRef    string `json:"head_ref"` // ref the forge publishes the head under, fetchable from the base repo
+}
+
+// Pull request actions, normalized across forges.
+const (
+	PullRequestActionOpened  = "opened"  // opened or reopened
+	PullRequestActionUpdated = "updated" // new commits pushed
+)
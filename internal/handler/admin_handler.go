@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/ai"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// AdminHandler handles operator-only endpoints.
+type AdminHandler struct {
+	embeddings   *service.EmbeddingService
+	store        *store.PostgresStore
+	cacheMetrics *ai.CacheMetrics
+}
+
+// NewAdminHandler creates a new admin handler.
+func NewAdminHandler(embeddings *service.EmbeddingService, pgStore *store.PostgresStore, cacheMetrics *ai.CacheMetrics) *AdminHandler {
+	return &AdminHandler{embeddings: embeddings, store: pgStore, cacheMetrics: cacheMetrics}
+}
+
+// Register sets up admin routes, restricted to users with the admin role.
+func (h *AdminHandler) Register(router fiber.Router) {
+	admin := router.Group("/admin", middleware.RequireRole("admin"))
+	admin.Get("/embeddings", h.ListIndexes)
+	admin.Post("/embeddings/migrate", h.Migrate)
+	admin.Get("/embeddings/cache", h.CacheStats)
+	admin.Get("/vector-index", h.VectorIndex)
+	admin.Post("/vector-index/rebuild", h.RebuildVectorIndex)
+}
+
+// ListIndexes returns every embedding index (model, dimension, status, progress).
+func (h *AdminHandler) ListIndexes(c fiber.Ctx) error {
+	indexes, err := h.embeddings.Indexes(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"indexes": indexes})
+}
+
+// Migrate starts re-embedding all chunks with another model; searches switch to the
+// new index once it is complete. Progress is visible via ListIndexes.
+func (h *AdminHandler) Migrate(c fiber.Ctx) error {
+	var body struct {
+		Model     string `json:"model"`
+		Dimension int    `json:"dimension"` // optional; checked against the model's output
+	}
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	body.Model = strings.TrimSpace(body.Model)
+	if body.Model == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "model is required"})
+	}
+
+	index, err := h.embeddings.StartMigration(c.Context(), body.Model, body.Dimension)
+	switch {
+	case errors.Is(err, port.ErrMigrationRunning):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	case errors.Is(err, port.ErrDimensionMismatch):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	case err != nil:
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"index": index})
+}
+
+// CacheStats reports embedding cache hits/misses since startup and cached vectors per model.
+func (h *AdminHandler) CacheStats(c fiber.Ctx) error {
+	entries, err := h.store.CountCachedEmbeddings(c.Context())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{
+		"metrics": h.cacheMetrics.Stats(),
+		"entries": entries,
+	})
+}
+
+// Recall measurement bounds for VectorIndex.
+const (
+	defaultRecallK   = 10
+	maxRecallSamples = 200
+)
+
+// VectorIndex reports the ANN index type, parameters and row counts for the active
+// embedding index; ?recall_samples=N[&k=K] also measures recall against exact search.
+func (h *AdminHandler) VectorIndex(c fiber.Ctx) error {
+	samples, _ := strconv.Atoi(c.Query("recall_samples", "0"))
+	samples = min(max(samples, 0), maxRecallSamples)
+	k, _ := strconv.Atoi(c.Query("k", strconv.Itoa(defaultRecallK)))
+	if k <= 0 {
+		k = defaultRecallK
+	}
+
+	stats, err := h.embeddings.VectorIndexStats(c.Context(), samples, k)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(stats)
+}
+
+// RebuildVectorIndex rebuilds the active ANN index in the background (e.g. after tuning parameters).
+func (h *AdminHandler) RebuildVectorIndex(c fiber.Ctx) error {
+	if err := h.embeddings.RebuildVectorIndex(); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"ok": true, "message": "vector index rebuild started"})
+}
@@ -3,15 +3,16 @@ package handler
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
-	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
@@ -22,20 +23,22 @@ import (
 // AnalysisHandler handles analysis endpoints.
 type AnalysisHandler struct {
 	analysisService *service.AnalysisService
+	repoService     *service.RepoService
 	store           *store.PostgresStore
 	tracker         *JobTracker
 	ai              port.AIProvider
-	ragService      *service.RAGService
+	indexer         *IndexHandler // nil when RAG indexing is disabled
 }
 
 // NewAnalysisHandler creates a new analysis handler.
-func NewAnalysisHandler(analysisService *service.AnalysisService, pgStore *store.PostgresStore, tracker *JobTracker, ai port.AIProvider, ragSvc *service.RAGService) *AnalysisHandler {
+func NewAnalysisHandler(analysisService *service.AnalysisService, repoService *service.RepoService, pgStore *store.PostgresStore, tracker *JobTracker, ai port.AIProvider, indexer *IndexHandler) *AnalysisHandler {
 	return &AnalysisHandler{
 		analysisService: analysisService,
+		repoService:     repoService,
 		store:           pgStore,
 		tracker:         tracker,
 		ai:              ai,
-		ragService:      ragSvc,
+		indexer:         indexer,
 	}
 }
 
@@ -53,7 +56,8 @@ func (h *AnalysisHandler) ListStrategies(c fiber.Ctx) error {
 	})
 }
 
-// RunAnalysis accepts a job and returns 202 immediately. Runs all strategies in background.
+// RunAnalysis accepts a job and returns 202 immediately. Runs the requested strategies
+// (all of them by default) in background.
 func (h *AnalysisHandler) RunAnalysis(c fiber.Ctx) error {
 	uc := middleware.GetUserContext(c)
 	if uc == nil {
@@ -61,123 +65,74 @@ func (h *AnalysisHandler) RunAnalysis(c fiber.Ctx) error {
 	}
 
 	var body struct {
-		RepoID string `json:"repo_id"`
+		RepoID     string   `json:"repo_id"`
+		Strategies []string `json:"strategies"` // optional subset
 	}
 	if err := c.Bind().JSON(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	// Build request with actual repo data
-	req, repo, err := h.buildAnalysisRequest(body.RepoID)
+	job, err := h.StartAnalysis(body.RepoID, body.Strategies)
+	if errors.Is(err, port.ErrCloneRestoring) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	strategies := h.analysisService.ListStrategies()
-	jobID := uuid.New().String()
-
-	h.tracker.CreateJob(jobID, body.RepoID, len(strategies))
-
-	// Run analysis in background — NO HTTP connection held
-	go h.runAnalysisJob(jobID, body.RepoID, req, strategies, repo.ReportLanguage)
-
 	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
-		"job_id":     jobID,
-		"strategies": strategies,
-		"message":    "analysis started",
+		"job_id":       job.JobID,
+		"index_job_id": job.IndexJobID,
+		"strategies":   job.Strategies,
+		"message":      "analysis started",
 	})
 }
 
-// runAnalysisJob runs all strategies sequentially in background.
-func (h *AnalysisHandler) runAnalysisJob(jobID, repoID string, req port.AnalysisRequest, strategies []string, lang string) {
-	ctx := context.Background()
+// AnalysisJob identifies the background jobs started for an analysis.
+type AnalysisJob struct {
+	JobID      string
+	IndexJobID string // empty when RAG indexing is disabled
+	Strategies []string
+}
 
-	// Index code chunks for RAG embeddings in parallel (best-effort, non-blocking)
-	if h.ragService != nil {
-		repo, repoErr := h.store.GetRepoByID(repoID)
-		if repoErr == nil && repo.LocalPath != "" {
-			// Create a snapshot record so embeddings have a valid FK
-			snap, snapErr := h.store.CreateSnapshot(ctx, &domain.Snapshot{
-				RepoID:     repoID,
-				CommitHash: "analysis-" + jobID[:8],
-				Branch:     "HEAD",
-				Message:    "RAG indexing for analysis",
-				Author:     "system",
-				FileCount:  0,
-				Status:     domain.SnapshotStatusPending,
-			})
-			if snapErr != nil {
-				slog.Error("create snapshot for RAG failed", "error", snapErr)
-			} else {
-				localPath := repo.LocalPath
-				snapshotID := snap.ID
-				// Run indexing in parallel so analysis starts immediately
-				go func() {
-					indexCtx := context.Background()
-					// Blacklist: skip binary/non-useful files; include everything else
-					skipExts := map[string]bool{
-						// Images
-						".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".svg": true, ".webp": true, ".tiff": true,
-						// Video/Audio
-						".mp4": true, ".avi": true, ".mov": true, ".mp3": true, ".wav": true, ".flac": true, ".ogg": true, ".webm": true,
-						// Fonts
-						".ttf": true, ".otf": true, ".woff": true, ".woff2": true, ".eot": true,
-						// Archives
-						".zip": true, ".tar": true, ".gz": true, ".bz2": true, ".7z": true, ".rar": true, ".jar": true, ".war": true,
-						// Compiled/Binary
-						".exe": true, ".dll": true, ".so": true, ".dylib": true, ".o": true, ".a": true, ".class": true, ".pyc": true, ".wasm": true,
-						// Lock files & large generated
-						".lock": true,
-						// Data files
-						".sqlite": true, ".db": true, ".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true,
-						// Maps
-						".map": true,
-					}
-					skipFiles := map[string]bool{
-						"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
-						"go.sum": true, "Cargo.lock": true, "Gemfile.lock": true,
-						"composer.lock": true, "poetry.lock": true, "Pipfile.lock": true,
-					}
-					files := make(map[string]string)
-					_ = filepath.Walk(localPath, func(path string, info os.FileInfo, walkErr error) error {
-						if walkErr != nil || info.IsDir() {
-							base := filepath.Base(path)
-							if info != nil && info.IsDir() && (strings.HasPrefix(base, ".") || base == "node_modules" || base == "vendor" || base == "__pycache__" || base == "dist" || base == "build" || base == "target") {
-								return filepath.SkipDir
-							}
-							return nil
-						}
-						baseName := filepath.Base(path)
-						if skipFiles[baseName] {
-							return nil
-						}
-						ext := strings.ToLower(filepath.Ext(path))
-						if skipExts[ext] {
-							return nil
-						}
-						if info.Size() > 50000 {
-							return nil
-						}
-						relPath, _ := filepath.Rel(localPath, path)
-						content, readErr := os.ReadFile(path)
-						if readErr == nil {
-							files[relPath] = string(content)
-						}
-						return nil
-					})
-					if len(files) > 0 {
-						slog.Info("indexing code for RAG (parallel)", "repo_id", repoID, "snapshot_id", snapshotID, "files", len(files))
-						if err := h.ragService.IndexChunks(indexCtx, repoID, snapshotID, files); err != nil {
-							slog.Error("RAG indexing failed", "error", err)
-						} else {
-							slog.Info("RAG indexing complete", "repo_id", repoID, "files", len(files))
-						}
-					}
-				}()
-			}
+// StartAnalysis starts an analysis job for a repo, plus a RAG index job alongside it.
+// An empty strategies list runs every strategy. This is the single entry point for
+// analyses, whether requested over the API or by a schedule.
+func (h *AnalysisHandler) StartAnalysis(repoID string, strategies []string) (*AnalysisJob, error) {
+	available := h.analysisService.ListStrategies()
+	if len(strategies) == 0 {
+		strategies = available
+	}
+	for _, s := range strategies {
+		if !slices.Contains(available, s) {
+			return nil, fmt.Errorf("%w: %s", port.ErrStrategyNotFound, s)
 		}
 	}
 
+	// Build request with actual repo data
+	req, repo, err := h.buildAnalysisRequest(repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	job := &AnalysisJob{JobID: uuid.New().String(), Strategies: strategies}
+	h.tracker.CreateJob(job.JobID, repoID, JobKindAnalysis, len(strategies))
+
+	// RAG indexing runs alongside the analysis as its own job
+	if h.indexer != nil {
+		job.IndexJobID, _ = h.indexer.StartJob(repoID, IndexJobOptions{})
+	}
+
+	// Run analysis in background — NO HTTP connection held
+	go h.runAnalysisJob(job.JobID, repoID, req, strategies, repo.ReportLanguage)
+	return job, nil
+}
+
+// runAnalysisJob runs the given strategies sequentially in background.
+func (h *AnalysisHandler) runAnalysisJob(jobID, repoID string, req port.AnalysisRequest, strategies []string, lang string) {
+	ctx := context.Background()
+
+	failed := 0
 	for i, strategy := range strategies {
 		h.tracker.UpdateJob(jobID, strategy, i, "running")
 		slog.Info("running strategy", "job_id", jobID, "strategy", strategy, "progress", fmt.Sprintf("%d/%d", i+1, len(strategies)))
@@ -204,6 +159,7 @@ func (h *AnalysisHandler) runAnalysisJob(jobID, repoID string, req port.Analysis
 				strategy, maxRetries+1, err.Error())
 			_ = h.store.SaveAnalysisResultFull(ctx, repoID, strategy, failSummary, "{}", 0, "")
 			h.tracker.UpdateJob(jobID, strategy, i+1, "running")
+			failed++
 			continue
 		}
 
@@ -224,8 +180,13 @@ func (h *AnalysisHandler) runAnalysisJob(jobID, repoID string, req port.Analysis
 		h.tracker.UpdateJob(jobID, strategy, i+1, "running")
 	}
 
+	if failed == len(strategies) {
+		h.tracker.FailJob(jobID, fmt.Sprintf("all %d strategies failed", failed))
+		slog.Error("analysis job failed", "job_id", jobID)
+		return
+	}
 	h.tracker.UpdateJob(jobID, "", len(strategies), "complete")
-	slog.Info("analysis job complete", "job_id", jobID)
+	slog.Info("analysis job complete", "job_id", jobID, "failed_strategies", failed)
 }
 
 // translateReport uses Ollama to translate a markdown report.
@@ -259,9 +220,14 @@ func (h *AnalysisHandler) buildAnalysisRequest(repoID string) (port.AnalysisRequ
 		return port.AnalysisRequest{}, nil, fmt.Errorf("repo not found: %w", err)
 	}
 
-	if repo.LocalPath == "" || repo.Status != "ready" {
-		return port.AnalysisRequest{}, nil, fmt.Errorf("repo not cloned or not ready (status: %s)", repo.Status)
+	if err := h.repoService.UseClone(context.Background(), repo); err != nil {
+		return port.AnalysisRequest{}, nil, fmt.Errorf("%w (status: %s)", err, repo.Status)
+	}
+	release, err := h.repoService.HoldClone(repo.ID)
+	if err != nil {
+		return port.AnalysisRequest{}, nil, err
 	}
+	defer release()
 
 	var fileTree []string
 	var chunks []string
@@ -0,0 +1,245 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+	"github.com/google/uuid"
+)
+
+// IndexHandler runs RAG indexing jobs and reports the index state of repositories.
+type IndexHandler struct {
+	ragService  *service.RAGService
+	repoService *service.RepoService
+	store       *store.PostgresStore
+	vcs         port.VCSProvider
+	tracker     *JobTracker
+	startMu     sync.Mutex // makes the running-job check atomic with job creation and purges
+}
+
+// NewIndexHandler creates a new index handler.
+func NewIndexHandler(ragService *service.RAGService, repoService *service.RepoService, pgStore *store.PostgresStore, vcs port.VCSProvider, tracker *JobTracker) *IndexHandler {
+	return &IndexHandler{ragService: ragService, repoService: repoService, store: pgStore, vcs: vcs, tracker: tracker}
+}
+
+// Register sets up index routes.
+func (h *IndexHandler) Register(router fiber.Router) {
+	router.Get("/repos/:id/index", h.Status)
+	router.Post("/repos/:id/index", h.Reindex)
+	router.Delete("/repos/:id/index", h.Purge)
+}
+
+// Status returns the state of a repo's RAG index: the snapshot searches use, the tracked
+// HEAD and snapshot it is compared against, per-file failures, and the running index
+// job, if any.
+func (h *IndexHandler) Status(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	status, err := h.ragService.IndexStatus(c.Context(), repo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	resp := fiber.Map{"index": status}
+	if job, ok := h.tracker.RunningJob(repo.ID, JobKindIndex); ok {
+		resp["job"] = job
+	}
+	return c.JSON(resp)
+}
+
+// Reindex starts an index job for the repo's working copy HEAD, or for a branch,
+// tag or commit given as "ref". A snapshot that is already vectorized is skipped
+// unless "force" is set.
+func (h *IndexHandler) Reindex(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	var body struct {
+		Ref   string `json:"ref"`
+		Force bool   `json:"force"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&body); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+		}
+	}
+
+	if err := h.repoService.UseClone(c.Context(), repo); err != nil {
+		return cloneError(c, err)
+	}
+	commit := ""
+	if body.Ref != "" {
+		info, err := h.vcs.ResolveRef(c.Context(), repo.LocalPath, body.Ref)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": fmt.Sprintf("unknown ref %q", body.Ref)})
+		}
+		commit = info.Hash
+	}
+
+	jobID, started := h.StartJob(repo.ID, IndexJobOptions{Ref: body.Ref, Force: body.Force})
+	if !started {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "repo is already being indexed", "job_id": jobID})
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"job_id":  jobID,
+		"commit":  commit,
+		"message": "indexing started",
+	})
+}
+
+// Purge deletes the repo's RAG index, or only the snapshot given as ?snapshot=.
+// Reports and snapshot records are kept; purged snapshots can be indexed again.
+// No index job can start on the repo while the purge runs.
+func (h *IndexHandler) Purge(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	h.startMu.Lock()
+	defer h.startMu.Unlock()
+	if job, ok := h.tracker.RunningJob(repo.ID, JobKindIndex); ok {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "repo is being indexed", "job_id": job.ID})
+	}
+
+	snapshotID := c.Query("snapshot")
+	if snapshotID != "" {
+		snap, err := h.store.GetSnapshot(c.Context(), snapshotID)
+		if err != nil || snap.RepoID != repo.ID {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "snapshot not found"})
+		}
+	}
+
+	deleted, err := h.ragService.PurgeIndex(c.Context(), repo.ID, snapshotID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true, "embeddings_deleted": deleted})
+}
+
+// IndexJobOptions selects what an index job indexes.
+type IndexJobOptions struct {
+	Ref   string // branch, tag or commit to index from the repo's history; empty indexes the working copy at HEAD
+	Force bool   // re-index a snapshot even if it is already vectorized
+}
+
+// StartJob indexes a repo in the background and returns the job ID.
+// If the repo is already being indexed, the running job's ID is returned with started false.
+func (h *IndexHandler) StartJob(repoID string, opts IndexJobOptions) (string, bool) {
+	h.startMu.Lock()
+	defer h.startMu.Unlock()
+	if job, ok := h.tracker.RunningJob(repoID, JobKindIndex); ok {
+		return job.ID, false
+	}
+
+	jobID := uuid.New().String()
+	h.tracker.CreateJob(jobID, repoID, JobKindIndex, 100)
+	go h.runJob(jobID, repoID, opts)
+	return jobID, true
+}
+
+// runJob incrementally indexes a snapshot of the repo for RAG, reporting progress on job jobID.
+// Without a ref the working copy is indexed under its HEAD commit; with one, files are read
+// from history. Unchanged files reuse the vectors of the previous snapshot, and a snapshot
+// that is already vectorized is skipped unless forced.
+func (h *IndexHandler) runJob(jobID, repoID string, opts IndexJobOptions) {
+	ctx := context.Background()
+
+	repo, err := h.store.GetRepoByID(repoID)
+	if err != nil {
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+
+	// The clone must stay on disk while its files are read
+	release, err := h.repoService.HoldClone(repo.ID)
+	if err != nil {
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+	defer release()
+	if err := h.repoService.EnsureClone(ctx, repo); err != nil {
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+
+	auth, err := h.repoService.GitAuth(ctx, repo)
+	if err != nil {
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+
+	var head *domain.CommitInfo
+	if opts.Ref != "" {
+		head, err = h.vcs.ResolveRef(ctx, repo.LocalPath, opts.Ref)
+	} else if commits, logErr := h.vcs.Log(ctx, repo.LocalPath, 1, auth); logErr != nil || len(commits) == 0 {
+		err = fmt.Errorf("no commits: %v", logErr)
+	} else {
+		head = &commits[0]
+	}
+	if err != nil {
+		slog.Error("resolve commit for RAG indexing failed", "repo_id", repo.ID, "ref", opts.Ref, "error", err)
+		h.tracker.FailJob(jobID, fmt.Sprintf("resolve commit: %v", err))
+		return
+	}
+
+	branch := repo.DefaultBranch
+	if opts.Ref != "" {
+		branch = opts.Ref
+	}
+
+	// One snapshot per commit: re-running at the same commit returns the existing record
+	snap, err := h.store.CreateSnapshot(ctx, &domain.Snapshot{
+		RepoID:     repo.ID,
+		CommitHash: head.Hash,
+		Branch:     branch,
+		Message:    head.Message,
+		Author:     head.Author,
+		Status:     domain.SnapshotStatusPending,
+	})
+	if err != nil {
+		slog.Error("create snapshot for RAG failed", "error", err)
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+	if snap.Status == domain.SnapshotStatusVectorized && !opts.Force {
+		slog.Info("RAG index up to date", "repo_id", repo.ID, "commit", head.Hash)
+		h.tracker.UpdateJob(jobID, "", 100, "complete")
+		return
+	}
+
+	var files map[string]string
+	if opts.Ref != "" {
+		files, err = service.CollectIndexableFilesAt(ctx, h.vcs, repo.LocalPath, head.Hash, auth)
+	} else {
+		files = service.CollectIndexableFiles(repo.LocalPath)
+	}
+	if err != nil {
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+	slog.Info("indexing code for RAG", "repo_id", repo.ID, "snapshot_id", snap.ID, "files", len(files))
+	stats, err := h.ragService.IndexSnapshot(ctx, repo.ID, snap, files, func(p service.IndexProgress) {
+		h.tracker.SetIndexProgress(jobID, p)
+	})
+	if err != nil {
+		slog.Error("RAG indexing failed", "repo_id", repo.ID, "error", err)
+		h.tracker.FailJob(jobID, err.Error())
+		return
+	}
+	h.tracker.UpdateJob(jobID, "", 100, "complete")
+	slog.Info("RAG indexing complete", "repo_id", repo.ID, "files", stats.Files, "reused", stats.Reused,
+		"embedded", stats.Embedded, "chunks", stats.Chunks, "failed", len(stats.Failures))
+}
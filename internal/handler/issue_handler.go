@@ -0,0 +1,189 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// IssueHandler manages the issue tracker of repositories and opens findings as issues.
+type IssueHandler struct {
+	issueService *service.IssueService
+	store        *store.PostgresStore
+}
+
+// NewIssueHandler creates a new issue handler.
+func NewIssueHandler(issueService *service.IssueService, pgStore *store.PostgresStore) *IssueHandler {
+	return &IssueHandler{issueService: issueService, store: pgStore}
+}
+
+// Register sets up issue routes.
+func (h *IssueHandler) Register(router fiber.Router) {
+	router.Get("/repos/:id/issue-tracker", h.GetTracker)
+	router.Put("/repos/:id/issue-tracker", h.SaveTracker)
+	router.Delete("/repos/:id/issue-tracker", h.DeleteTracker)
+	router.Post("/repos/:id/reviews/:reviewId/issues", h.CreateIssues)
+	router.Post("/repos/:id/issues/sync", h.SyncIssues)
+}
+
+// trackerRequest is the body of tracker saves. Omitted fields keep their current value.
+type trackerRequest struct {
+	Kind          *string  `json:"kind"`
+	BaseURL       *string  `json:"base_url"`
+	Project       *string  `json:"project"`
+	Username      *string  `json:"username"`
+	Token         *string  `json:"token"`
+	IssueType     *string  `json:"issue_type"`
+	Labels        []string `json:"labels"`
+	TitleTemplate *string  `json:"title_template"`
+	BodyTemplate  *string  `json:"body_template"`
+}
+
+// apply copies the fields set in the request onto cfg.
+func (r *trackerRequest) apply(cfg *domain.IssueTrackerConfig) {
+	if r.Kind != nil {
+		cfg.Kind = *r.Kind
+	}
+	if r.BaseURL != nil {
+		cfg.BaseURL = strings.TrimRight(strings.TrimSpace(*r.BaseURL), "/")
+	}
+	if r.Project != nil {
+		cfg.Project = strings.TrimSpace(*r.Project)
+	}
+	if r.Username != nil {
+		cfg.Username = strings.TrimSpace(*r.Username)
+	}
+	if r.Token != nil {
+		cfg.Token, cfg.TokenSealed = strings.TrimSpace(*r.Token), nil
+	}
+	if r.IssueType != nil {
+		cfg.IssueType = *r.IssueType
+	}
+	if r.TitleTemplate != nil {
+		cfg.TitleTemplate = *r.TitleTemplate
+	}
+	if r.BodyTemplate != nil {
+		cfg.BodyTemplate = *r.BodyTemplate
+	}
+	if r.Labels != nil {
+		cfg.Labels = r.Labels
+	}
+}
+
+// GetTracker returns the issue tracker of a repo.
+func (h *IssueHandler) GetTracker(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	cfg, err := h.store.GetIssueTracker(c.Context(), repo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no issue tracker configured"})
+	}
+	return c.JSON(cfg)
+}
+
+// SaveTracker creates or updates the issue tracker of a repo. "kind" is required when
+// creating one; without "token", github and gitlab use the owner's OAuth token when the
+// owner logged in with that forge and issues go to its host. Tokens are stored encrypted.
+func (h *IssueHandler) SaveTracker(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	cfg, err := h.store.GetIssueTracker(c.Context(), repo.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		cfg, err = &domain.IssueTrackerConfig{RepoID: repo.ID}, nil
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	var body trackerRequest
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	body.apply(cfg)
+	if err := h.issueService.Prepare(cfg); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	saved, err := h.store.SaveIssueTracker(c.Context(), cfg)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(saved)
+}
+
+// DeleteTracker removes the issue tracker of a repo.
+func (h *IssueHandler) DeleteTracker(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	if err := h.store.DeleteIssueTracker(c.Context(), repo.ID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "no issue tracker configured"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// CreateIssues opens the findings in "finding_ids" as issues. Findings that already have
+// one are returned as they are; failures for single findings are listed in "error".
+func (h *IssueHandler) CreateIssues(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	review, err := h.store.GetReview(c.Context(), repo.ID, c.Params("reviewId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "review not found"})
+	}
+	var body struct {
+		FindingIDs []string `json:"finding_ids"`
+	}
+	if err := c.Bind().JSON(&body); err != nil || len(body.FindingIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "finding_ids is required"})
+	}
+
+	findings, err := h.issueService.CreateIssues(c.Context(), repo, review, body.FindingIDs)
+	if findings == nil {
+		if errors.Is(err, port.ErrNoIssueTracker) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": err.Error()})
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	resp := fiber.Map{"findings": findings}
+	if err != nil {
+		resp["error"] = err.Error()
+		return c.Status(fiber.StatusBadGateway).JSON(resp)
+	}
+	return c.JSON(resp)
+}
+
+// SyncIssues refreshes the state of the repo's open finding issues now.
+func (h *IssueHandler) SyncIssues(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	n, err := h.issueService.SyncIssues(c.Context(), repo.ID, time.Now())
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"checked": n})
+}
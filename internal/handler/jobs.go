@@ -8,28 +8,39 @@ import (
 	"sync"
 	"time"
 
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
 	"github.com/gofiber/fiber/v3"
 )
 
-// JobStatus represents the current state of an analysis job.
+// Job kinds.
+const (
+	JobKindAnalysis = "analysis" // runs the analysis strategies; progress counts strategies
+	JobKindIndex    = "index"    // builds the RAG index of a snapshot; progress is a percentage
+)
+
+// JobStatus represents the current state of a background job.
 type JobStatus struct {
-	ID          string    `json:"id"`
-	RepoID      string    `json:"repo_id"`
-	Status      string    `json:"status"` // running, complete, error
-	Progress    int       `json:"progress"`
-	Total       int       `json:"total"`
-	Current     string    `json:"current_strategy"`
-	Results     []string  `json:"completed_strategies"`
-	Error       string    `json:"error,omitempty"`
-	StartedAt   time.Time `json:"started_at"`
-	CompletedAt time.Time `json:"completed_at,omitempty"`
-}
-
-// JobTracker manages analysis jobs in memory.
+	ID          string                 `json:"id"`
+	RepoID      string                 `json:"repo_id"`
+	Kind        string                 `json:"kind"`   // analysis, index
+	Status      string                 `json:"status"` // running, complete, error
+	Progress    int                    `json:"progress"`
+	Total       int                    `json:"total"`
+	Current     string                 `json:"current_strategy"`
+	Results     []string               `json:"completed_strategies"`
+	Index       *service.IndexProgress `json:"index,omitempty"` // file, chunk and failure counts of index jobs
+	Error       string                 `json:"error,omitempty"`
+	StartedAt   time.Time              `json:"started_at"`
+	CompletedAt time.Time              `json:"completed_at,omitempty"`
+}
+
+// JobTracker manages background jobs in memory.
 type JobTracker struct {
 	mu   sync.RWMutex
 	jobs map[string]*JobStatus
 	subs map[string][]chan JobStatus // subscribers per job
+
+	onFinish []func(JobStatus) // called when a job completes or fails
 }
 
 // NewJobTracker creates a new job tracker.
@@ -40,13 +51,20 @@ func NewJobTracker() *JobTracker {
 	}
 }
 
+// OnFinish registers fn to be called, synchronously, with the final status of every job.
+// Register callbacks before any job starts.
+func (t *JobTracker) OnFinish(fn func(JobStatus)) {
+	t.onFinish = append(t.onFinish, fn)
+}
+
 // CreateJob creates a new job entry.
-func (t *JobTracker) CreateJob(id, repoID string, total int) {
+func (t *JobTracker) CreateJob(id, repoID, kind string, total int) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
 	t.jobs[id] = &JobStatus{
 		ID:        id,
 		RepoID:    repoID,
+		Kind:      kind,
 		Status:    "running",
 		Total:     total,
 		Results:   []string{},
@@ -68,17 +86,69 @@ func (t *JobTracker) UpdateJob(id string, strategy string, progress int, status
 	if strategy != "" && status != "error" {
 		job.Results = append(job.Results, strategy)
 	}
-	if status == "complete" || status == "error" {
+	finished := status == "complete" || status == "error"
+	if finished {
 		job.CompletedAt = time.Now()
 	}
 	snapshot := *job
 	subs := t.subs[id]
 	t.mu.Unlock()
 
-	// Notify subscribers
+	t.notify(subs, snapshot)
+	if finished {
+		t.finish(snapshot)
+	}
+}
+
+// SetIndexProgress records the progress of an index job and notifies subscribers.
+func (t *JobTracker) SetIndexProgress(id string, p service.IndexProgress) {
+	t.mu.Lock()
+	job, ok := t.jobs[id]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	job.Progress = p.Percent
+	job.Current = fmt.Sprintf("indexing %d/%d files", p.FilesDone, p.FilesTotal)
+	job.Index = &p
+	snapshot := *job
+	subs := t.subs[id]
+	t.mu.Unlock()
+
+	t.notify(subs, snapshot)
+}
+
+// FailJob marks a job as failed with an error message.
+func (t *JobTracker) FailJob(id string, errMsg string) {
+	t.mu.Lock()
+	job, ok := t.jobs[id]
+	if !ok {
+		t.mu.Unlock()
+		return
+	}
+	job.Status = "error"
+	job.Error = errMsg
+	job.CompletedAt = time.Now()
+	snapshot := *job
+	subs := t.subs[id]
+	t.mu.Unlock()
+
+	t.notify(subs, snapshot)
+	t.finish(snapshot)
+}
+
+// finish runs the OnFinish callbacks.
+func (t *JobTracker) finish(status JobStatus) {
+	for _, fn := range t.onFinish {
+		fn(status)
+	}
+}
+
+// notify sends a status to subscribers without blocking on slow ones.
+func (t *JobTracker) notify(subs []chan JobStatus, status JobStatus) {
 	for _, ch := range subs {
 		select {
-		case ch <- snapshot:
+		case ch <- status:
 		default:
 		}
 	}
@@ -96,6 +166,24 @@ func (t *JobTracker) GetJob(id string) (*JobStatus, bool) {
 	return &snapshot, true
 }
 
+// RunningJob returns the newest running job of a kind for a repo.
+func (t *JobTracker) RunningJob(repoID, kind string) (*JobStatus, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	var latest *JobStatus
+	for _, job := range t.jobs {
+		if job.RepoID == repoID && job.Kind == kind && job.Status == "running" &&
+			(latest == nil || job.StartedAt.After(latest.StartedAt)) {
+			latest = job
+		}
+	}
+	if latest == nil {
+		return nil, false
+	}
+	snapshot := *latest
+	return &snapshot, true
+}
+
 // Subscribe returns a channel that receives job updates.
 func (t *JobTracker) Subscribe(id string) chan JobStatus {
 	t.mu.Lock()
@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// NotificationHandler manages notification subscriptions and their delivery log, and
+// publishes job outcomes.
+type NotificationHandler struct {
+	notifications *service.NotificationService
+	store         *store.PostgresStore
+}
+
+// NewNotificationHandler creates a new notification handler.
+func NewNotificationHandler(notifications *service.NotificationService, pgStore *store.PostgresStore) *NotificationHandler {
+	return &NotificationHandler{notifications: notifications, store: pgStore}
+}
+
+// Register sets up notification routes.
+func (h *NotificationHandler) Register(router fiber.Router) {
+	n := router.Group("/notifications")
+	n.Get("/subscriptions", h.ListSubscriptions)
+	n.Post("/subscriptions", h.CreateSubscription)
+	n.Put("/subscriptions/:id", h.UpdateSubscription)
+	n.Delete("/subscriptions/:id", h.DeleteSubscription)
+	n.Post("/subscriptions/:id/test", h.TestSubscription)
+	n.Get("/deliveries", h.ListDeliveries)
+	n.Post("/deliveries/:id/retry", h.RetryDelivery)
+}
+
+// subscriptionRequest is the body of create and update requests. Omitted fields keep
+// their current value on update.
+type subscriptionRequest struct {
+	RepoID  *string  `json:"repo_id"`
+	Channel *string  `json:"channel"`
+	Target  *string  `json:"target"`
+	Secret  *string  `json:"secret"`
+	Events  []string `json:"events"`
+	Enabled *bool    `json:"enabled"`
+}
+
+// apply copies the fields set in the request onto sub.
+func (r *subscriptionRequest) apply(sub *domain.NotificationSubscription) {
+	if r.RepoID != nil {
+		sub.RepoID = *r.RepoID
+	}
+	if r.Channel != nil {
+		sub.Channel = *r.Channel
+	}
+	if r.Target != nil {
+		sub.Target = strings.TrimSpace(*r.Target)
+	}
+	if r.Secret != nil {
+		sub.Secret = *r.Secret
+	}
+	if r.Events != nil {
+		sub.Events = r.Events
+	}
+	if r.Enabled != nil {
+		sub.Enabled = *r.Enabled
+	}
+}
+
+// prepare validates sub and checks that its repo, if any, belongs to the caller.
+// On failure it returns false after writing the error response.
+func (h *NotificationHandler) prepare(c fiber.Ctx, sub *domain.NotificationSubscription) (bool, error) {
+	if sub.RepoID != "" {
+		repo, err := h.store.GetRepoByID(sub.RepoID)
+		if err != nil || repo.UserID != sub.UserID {
+			return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "repo not found"})
+		}
+	}
+	if err := h.notifications.Prepare(sub); err != nil {
+		return false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return true, nil
+}
+
+// ListSubscriptions returns the caller's subscriptions and the available channels and events.
+func (h *NotificationHandler) ListSubscriptions(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	subs, err := h.store.ListSubscriptionsByUser(c.Context(), uc.UserID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if subs == nil {
+		subs = []domain.NotificationSubscription{}
+	}
+	return c.JSON(fiber.Map{
+		"subscriptions": subs,
+		"channels":      h.notifications.Channels(),
+		"events":        domain.NotificationEvents,
+	})
+}
+
+// CreateSubscription adds a subscription. "channel" and "target" are required; without
+// "repo_id" it covers every repo of the caller, and without "events" every event.
+func (h *NotificationHandler) CreateSubscription(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var body subscriptionRequest
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if body.Channel == nil || body.Target == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "channel and target are required"})
+	}
+
+	sub := &domain.NotificationSubscription{UserID: uc.UserID, Enabled: true}
+	body.apply(sub)
+	if ok, err := h.prepare(c, sub); !ok {
+		return err
+	}
+
+	created, err := h.store.CreateSubscription(c.Context(), sub)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// UpdateSubscription changes one of the caller's subscriptions.
+func (h *NotificationHandler) UpdateSubscription(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	sub, err := h.store.GetSubscription(c.Context(), uc.UserID, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "subscription not found"})
+	}
+
+	var body subscriptionRequest
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	body.apply(sub)
+	if ok, err := h.prepare(c, sub); !ok {
+		return err
+	}
+
+	updated, err := h.store.UpdateSubscription(c.Context(), sub)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(updated)
+}
+
+// DeleteSubscription removes one of the caller's subscriptions with its delivery log.
+func (h *NotificationHandler) DeleteSubscription(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if err := h.store.DeleteSubscription(c.Context(), uc.UserID, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "subscription not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// TestSubscription sends a test event to one of the caller's subscriptions right away.
+func (h *NotificationHandler) TestSubscription(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	sub, err := h.store.GetSubscription(c.Context(), uc.UserID, c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "subscription not found"})
+	}
+	if err := h.notifications.Test(c.Context(), sub); err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// ListDeliveries returns the caller's most recent deliveries (?status=pending|delivered|failed, ?limit=).
+func (h *NotificationHandler) ListDeliveries(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	status := c.Query("status")
+	switch status {
+	case "", domain.DeliveryPending, domain.DeliveryDelivered, domain.DeliveryFailed:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "status must be pending, delivered or failed"})
+	}
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	deliveries, err := h.store.ListDeliveriesByUser(c.Context(), uc.UserID, status, limit)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if deliveries == nil {
+		deliveries = []domain.NotificationDelivery{}
+	}
+	return c.JSON(fiber.Map{"deliveries": deliveries})
+}
+
+// RetryDelivery queues an undelivered delivery of the caller again, due now.
+func (h *NotificationHandler) RetryDelivery(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if err := h.store.RetryDelivery(c.Context(), uc.UserID, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "undelivered delivery not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// OnJobFinished publishes the outcome of a job: analyses notify completion or failure
+// and check for score regressions; index jobs only notify failures.
+func (h *NotificationHandler) OnJobFinished(job JobStatus) {
+	if job.Kind == JobKindIndex && job.Status != "error" {
+		return
+	}
+	// Callbacks run on the job's goroutine: don't hold it up with database work
+	go h.publishJob(job)
+}
+
+// publishJob publishes the job.complete or job.failed event of a finished job.
+func (h *NotificationHandler) publishJob(job JobStatus) {
+	ctx := context.Background()
+	repo, err := h.store.GetRepoByID(job.RepoID)
+	if err != nil {
+		slog.Error("notification: load repo failed", "repo_id", job.RepoID, "error", err)
+		return
+	}
+	evt := &domain.NotificationEvent{
+		RepoID:   repo.ID,
+		RepoName: repo.Name,
+		UserID:   repo.UserID,
+		Data: map[string]any{
+			"job_id":     job.ID,
+			"kind":       job.Kind,
+			"strategies": job.Results,
+			"duration_s": int(job.CompletedAt.Sub(job.StartedAt).Seconds()),
+		},
+	}
+	if job.Status == "error" {
+		evt.Type = domain.EventJobFailed
+		evt.Title = fmt.Sprintf("%s job failed", job.Kind)
+		evt.Message = job.Error
+	} else {
+		evt.Type = domain.EventJobComplete
+		evt.Title = "Analysis complete"
+		evt.Message = fmt.Sprintf("%d strategies analyzed: %s", len(job.Results), strings.Join(job.Results, ", "))
+	}
+	h.notifications.Publish(ctx, evt)
+
+	if job.Kind == JobKindAnalysis && job.Status == "complete" {
+		h.notifications.CheckScoreRegressions(ctx, repo, job.Results, job.StartedAt)
+	}
+}
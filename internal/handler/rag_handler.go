@@ -30,31 +30,52 @@ func (h *RAGHandler) Query(c fiber.Ctx) error {
 	}
 
 	var body struct {
-		RepoID   string `json:"repo_id"`
-		Question string `json:"question"`
+		RepoID        string   `json:"repo_id"`
+		Question      string   `json:"question"`
+		LexicalWeight *float64 `json:"lexical_weight"` // optional, 0–1
+		SnapshotID    string   `json:"snapshot_id"`    // optional, defaults to the latest indexed snapshot
+		Rerank        *bool    `json:"rerank"`         // optional, overrides RAG_RERANK_ENABLED
+		Expand        bool     `json:"expand"`         // optional, multi-query retrieval
 	}
 	if err := c.Bind().JSON(&body); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
 	}
 
-	answer, chunks, err := h.ragService.Query(c.Context(), body.RepoID, body.Question)
+	result, err := h.ragService.Query(c.Context(), body.RepoID, body.Question, service.QueryOptions{
+		LexicalWeight: body.LexicalWeight,
+		SnapshotID:    body.SnapshotID,
+		Rerank:        body.Rerank,
+		Expand:        body.Expand,
+	})
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
 	}
 
-	// Build sources from chunks
-	sources := make([]fiber.Map, len(chunks))
-	for i, chunk := range chunks {
+	// Build numbered sources from chunks ([n] in the answer refers to sources[n-1])
+	sources := make([]fiber.Map, len(result.Sources))
+	for i, chunk := range result.Sources {
 		sources[i] = fiber.Map{
-			"file_path":   chunk.FilePath,
-			"content":     chunk.Content,
-			"similarity":  chunk.Similarity,
-			"chunk_index": chunk.ChunkIndex,
+			"n":            i + 1,
+			"file_path":    chunk.FilePath,
+			"content":      chunk.Content,
+			"similarity":   chunk.Similarity,
+			"score":        chunk.Score,
+			"rerank_score": chunk.RerankScore,
+			"chunk_index":  chunk.ChunkIndex,
+			"symbol":       chunk.SymbolName,
+			"symbol_kind":  chunk.SymbolKind,
+			"start_line":   chunk.StartLine,
+			"end_line":     chunk.EndLine,
+			"commit":       chunk.CommitHash,
 		}
 	}
 
 	return c.JSON(fiber.Map{
-		"answer":  answer,
-		"sources": sources,
+		"answer":         result.Answer,
+		"sources":        sources,
+		"citations":      result.Citations,
+		"uncited_claims": result.UncitedClaims,
+		"expansions":     result.Expansions,
+		"index_warning":  result.IndexWarning,
 	})
 }
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ReviewHandler serves the pull request reviews of repositories and their findings.
+type ReviewHandler struct {
+	reviewService *service.ReviewService
+	store         *store.PostgresStore
+}
+
+// NewReviewHandler creates a new review handler.
+func NewReviewHandler(reviewService *service.ReviewService, pgStore *store.PostgresStore) *ReviewHandler {
+	return &ReviewHandler{reviewService: reviewService, store: pgStore}
+}
+
+// Register sets up review routes.
+func (h *ReviewHandler) Register(router fiber.Router) {
+	router.Get("/repos/:id/reviews", h.List)
+	router.Get("/repos/:id/reviews/:reviewId", h.Get)
+	router.Post("/repos/:id/reviews/:reviewId/comments", h.PostComments)
+}
+
+// List returns a repo's most recent reviews.
+func (h *ReviewHandler) List(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	reviews, err := h.store.ListReviews(c.Context(), repo.ID, queryInt(c, "limit", 50))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if reviews == nil {
+		reviews = []domain.Review{}
+	}
+	return c.JSON(fiber.Map{"reviews": reviews})
+}
+
+// Get returns a review with its findings.
+func (h *ReviewHandler) Get(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	review, err := h.store.GetReview(c.Context(), repo.ID, c.Params("reviewId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "review not found"})
+	}
+	findings, err := h.store.ListFindings(c.Context(), review.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if findings == nil {
+		findings = []domain.Finding{}
+	}
+	return c.JSON(fiber.Map{"review": review, "findings": findings})
+}
+
+// PostComments posts (or updates) a review's summary and inline comments on its pull request.
+func (h *ReviewHandler) PostComments(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	review, err := h.store.GetReview(c.Context(), repo.ID, c.Params("reviewId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "review not found"})
+	}
+	if review.Status != domain.ReviewStatusComplete {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{"error": "review is not complete"})
+	}
+	if err := h.reviewService.PostComments(c.Context(), repo, review); err != nil {
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
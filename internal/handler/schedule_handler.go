@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// ScheduleHandler manages recurring analysis schedules of repositories.
+type ScheduleHandler struct {
+	scheduleService *service.ScheduleService
+	store           *store.PostgresStore
+}
+
+// NewScheduleHandler creates a new schedule handler.
+func NewScheduleHandler(scheduleService *service.ScheduleService, pgStore *store.PostgresStore) *ScheduleHandler {
+	return &ScheduleHandler{scheduleService: scheduleService, store: pgStore}
+}
+
+// Register sets up schedule routes.
+func (h *ScheduleHandler) Register(router fiber.Router) {
+	router.Get("/repos/:id/schedules", h.List)
+	router.Post("/repos/:id/schedules", h.Create)
+	router.Get("/repos/:id/schedules/:scheduleId", h.Get)
+	router.Put("/repos/:id/schedules/:scheduleId", h.Update)
+	router.Delete("/repos/:id/schedules/:scheduleId", h.Delete)
+}
+
+// scheduleRequest is the body of create and update requests. Omitted fields keep
+// their current value on update.
+type scheduleRequest struct {
+	Strategies    []string `json:"strategies"`
+	Cron          *string  `json:"cron"`
+	Timezone      *string  `json:"timezone"`
+	SyncBeforeRun *bool    `json:"sync_before_run"`
+	Enabled       *bool    `json:"enabled"`
+}
+
+// apply copies the fields set in the request onto sch.
+func (r *scheduleRequest) apply(sch *domain.Schedule) {
+	if r.Strategies != nil {
+		sch.Strategies = r.Strategies
+	}
+	if r.Cron != nil {
+		sch.Cron = *r.Cron
+	}
+	if r.Timezone != nil {
+		sch.Timezone = *r.Timezone
+	}
+	if r.SyncBeforeRun != nil {
+		sch.SyncBeforeRun = *r.SyncBeforeRun
+	}
+	if r.Enabled != nil {
+		sch.Enabled = *r.Enabled
+	}
+}
+
+// List returns the schedules of a repo.
+func (h *ScheduleHandler) List(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	schedules, err := h.store.ListSchedulesByRepo(c.Context(), repo.ID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if schedules == nil {
+		schedules = []domain.Schedule{}
+	}
+	return c.JSON(fiber.Map{"schedules": schedules})
+}
+
+// Create adds a schedule to a repo. Only "cron" is required; strategies default to all,
+// timezone to UTC, and sync_before_run and enabled to true.
+func (h *ScheduleHandler) Create(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	var body scheduleRequest
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if body.Cron == nil || *body.Cron == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "cron is required"})
+	}
+
+	sch := &domain.Schedule{RepoID: repo.ID, Timezone: "UTC", SyncBeforeRun: true, Enabled: true}
+	body.apply(sch)
+	if err := h.scheduleService.Prepare(sch); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	created, err := h.store.CreateSchedule(c.Context(), sch)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// Get returns a schedule of a repo.
+func (h *ScheduleHandler) Get(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	sch, err := h.store.GetSchedule(c.Context(), repo.ID, c.Params("scheduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "schedule not found"})
+	}
+	return c.JSON(sch)
+}
+
+// Update changes a schedule of a repo and recomputes its next run.
+func (h *ScheduleHandler) Update(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	sch, err := h.store.GetSchedule(c.Context(), repo.ID, c.Params("scheduleId"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "schedule not found"})
+	}
+
+	var body scheduleRequest
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	body.apply(sch)
+	if err := h.scheduleService.Prepare(sch); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	updated, err := h.store.UpdateSchedule(c.Context(), sch)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(updated)
+}
+
+// Delete removes a schedule of a repo.
+func (h *ScheduleHandler) Delete(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+
+	if err := h.store.DeleteSchedule(c.Context(), repo.ID, c.Params("scheduleId")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "schedule not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// maxSearchLimit caps the page size of semantic search.
+const maxSearchLimit = 100
+
+// commitPrefixRe matches the commit (prefix) a search may be pinned to.
+var commitPrefixRe = regexp.MustCompile(`^[0-9a-f]{4,40}$`)
+
+// SearchHandler handles raw semantic code search (no LLM call).
+type SearchHandler struct {
+	ragService *service.RAGService
+	store      *store.PostgresStore
+}
+
+// NewSearchHandler creates a new search handler.
+func NewSearchHandler(ragService *service.RAGService, pgStore *store.PostgresStore) *SearchHandler {
+	return &SearchHandler{ragService: ragService, store: pgStore}
+}
+
+// Register sets up search routes.
+func (h *SearchHandler) Register(router fiber.Router) {
+	router.Post("/search", h.Search)
+}
+
+// Search returns code chunks matching a query and filters, scoped to one repo
+// or (with all_repos) to every repo the user owns.
+func (h *SearchHandler) Search(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	var body struct {
+		Query         string   `json:"query"`
+		RepoID        string   `json:"repo_id"`
+		AllRepos      bool     `json:"all_repos"`
+		SnapshotID    string   `json:"snapshot_id"`
+		Commit        string   `json:"commit"`
+		Language      string   `json:"language"`
+		Path          string   `json:"path"` // glob, e.g. "internal/**/*.go"
+		SymbolKind    string   `json:"symbol_kind"`
+		MinSimilarity float64  `json:"min_similarity"`
+		LexicalWeight *float64 `json:"lexical_weight"`
+		Limit         int      `json:"limit"`
+		Offset        int      `json:"offset"`
+	}
+	if err := c.Bind().JSON(&body); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid request body"})
+	}
+
+	body.Query = strings.TrimSpace(body.Query)
+	if body.Query == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "query is required"})
+	}
+	if body.Limit <= 0 {
+		body.Limit = 20
+	}
+	if body.Limit > maxSearchLimit {
+		body.Limit = maxSearchLimit
+	}
+	if body.Offset < 0 {
+		body.Offset = 0
+	}
+	if body.Commit != "" {
+		body.Commit = strings.ToLower(body.Commit)
+		if !commitPrefixRe.MatchString(body.Commit) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "commit must be 4 to 40 hex characters"})
+		}
+	}
+
+	// Resolve which repos the user may search
+	var repoIDs []string
+	switch {
+	case body.AllRepos:
+		if body.SnapshotID != "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "snapshot_id cannot be combined with all_repos"})
+		}
+		repos, err := h.store.ListReposByUser(c.Context(), uc.UserID)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+		}
+		for _, r := range repos {
+			repoIDs = append(repoIDs, r.ID)
+		}
+	case body.RepoID != "":
+		repo, err := h.store.GetRepoByID(body.RepoID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "repo not found"})
+		}
+		if repo.UserID != uc.UserID {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "forbidden"})
+		}
+		repoIDs = []string{repo.ID}
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "repo_id or all_repos is required"})
+	}
+
+	if len(repoIDs) == 0 {
+		return c.JSON(fiber.Map{"results": []interface{}{}, "count": 0, "limit": body.Limit, "offset": body.Offset})
+	}
+
+	filter := domain.SearchFilter{
+		RepoIDs:       repoIDs,
+		SnapshotID:    body.SnapshotID,
+		CommitHash:    body.Commit,
+		Language:      body.Language,
+		PathGlob:      body.Path,
+		SymbolKind:    body.SymbolKind,
+		MinSimilarity: body.MinSimilarity,
+		Limit:         body.Limit,
+		Offset:        body.Offset,
+	}
+
+	results, err := h.ragService.Search(c.Context(), body.Query, filter, body.LexicalWeight)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if results == nil {
+		results = []domain.SimilarChunk{}
+	}
+
+	resp := fiber.Map{
+		"results": results,
+		"count":   len(results),
+		"limit":   body.Limit,
+		"offset":  body.Offset,
+	}
+	// Flag repos whose index is missing or outdated, unless a snapshot was chosen explicitly
+	if body.SnapshotID == "" && body.Commit == "" {
+		var warnings []fiber.Map
+		for _, repoID := range repoIDs {
+			status, err := h.ragService.IndexStatus(c.Context(), repoID)
+			if err != nil || status.Warning == "" {
+				continue
+			}
+			warnings = append(warnings, fiber.Map{"repo_id": repoID, "state": status.State, "warning": status.Warning})
+		}
+		if len(warnings) > 0 {
+			resp["index_warnings"] = warnings
+		}
+	}
+	return c.JSON(resp)
+}
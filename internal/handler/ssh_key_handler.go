@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/middleware"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// SSHKeyHandler generates and manages the SSH keys repos are cloned with over SSH:
+// user keys, used for every repo of the user, and per-repo deploy keys.
+type SSHKeyHandler struct {
+	keyService *service.SSHKeyService
+	store      *store.PostgresStore
+}
+
+// NewSSHKeyHandler creates a new SSH key handler.
+func NewSSHKeyHandler(keyService *service.SSHKeyService, pgStore *store.PostgresStore) *SSHKeyHandler {
+	return &SSHKeyHandler{keyService: keyService, store: pgStore}
+}
+
+// Register sets up SSH key routes.
+func (h *SSHKeyHandler) Register(router fiber.Router) {
+	router.Get("/ssh-keys", h.ListUserKeys)
+	router.Post("/ssh-keys", h.GenerateUserKey)
+	router.Delete("/ssh-keys/:id", h.DeleteKey)
+	router.Get("/repos/:id/deploy-keys", h.ListDeployKeys)
+	router.Post("/repos/:id/deploy-keys", h.GenerateDeployKey)
+}
+
+// keyRequest is the body of key generation.
+type keyRequest struct {
+	Name string `json:"name"`
+}
+
+// ListUserKeys returns the caller's user keys, newest first.
+func (h *SSHKeyHandler) ListUserKeys(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return h.list(c, uc.UserID, "")
+}
+
+// GenerateUserKey generates a user key. Its public key is to be added to the user's
+// account on the forge.
+func (h *SSHKeyHandler) GenerateUserKey(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	return h.generate(c, uc.UserID, "")
+}
+
+// ListDeployKeys returns the deploy keys of a repo, newest first. The newest one is
+// used to clone and sync it.
+func (h *SSHKeyHandler) ListDeployKeys(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+	return h.list(c, repo.UserID, repo.ID)
+}
+
+// GenerateDeployKey generates a deploy key for a repo. Its public key is to be added to
+// the repository's deploy keys on the forge.
+func (h *SSHKeyHandler) GenerateDeployKey(c fiber.Ctx) error {
+	repo, err := ownedRepo(c, h.store)
+	if repo == nil {
+		return err
+	}
+	return h.generate(c, repo.UserID, repo.ID)
+}
+
+// DeleteKey removes one of the caller's keys.
+func (h *SSHKeyHandler) DeleteKey(c fiber.Ctx) error {
+	uc := middleware.GetUserContext(c)
+	if uc == nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+	}
+
+	if err := h.store.DeleteSSHKey(c.Context(), uc.UserID, c.Params("id")); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "ssh key not found"})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"ok": true})
+}
+
+// list responds with the user keys (empty repoID) or deploy keys of a user.
+func (h *SSHKeyHandler) list(c fiber.Ctx, userID, repoID string) error {
+	keys, err := h.store.ListSSHKeys(c.Context(), userID, repoID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.JSON(fiber.Map{"keys": keys, "count": len(keys)})
+}
+
+// generate responds with a new key of a user.
+func (h *SSHKeyHandler) generate(c fiber.Ctx, userID, repoID string) error {
+	var req keyRequest
+	if len(c.Body()) > 0 {
+		if err := c.Bind().JSON(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "invalid body"})
+		}
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > 100 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "name must be at most 100 characters"})
+	}
+
+	key, err := h.keyService.Generate(c.Context(), userID, repoID, name)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	return c.Status(fiber.StatusCreated).JSON(key)
+}
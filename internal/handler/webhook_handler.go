@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/service"
+	"github.com/gofiber/fiber/v3"
+)
+
+// WebhookHandler receives push and pull-request webhooks from forges.
+// Routes are public: deliveries are authenticated by their signature, not a JWT.
+type WebhookHandler struct {
+	parsers  port.WebhookParserRegistry
+	webhooks *service.WebhookService
+}
+
+// NewWebhookHandler creates a new webhook handler. Deliveries are verified with the
+// webhook secrets of the repos they match.
+func NewWebhookHandler(parsers port.WebhookParserRegistry, webhooks *service.WebhookService) *WebhookHandler {
+	return &WebhookHandler{parsers: parsers, webhooks: webhooks}
+}
+
+// Register sets up webhook routes. They must be registered before the JWT-protected group.
+func (h *WebhookHandler) Register(app fiber.Router) {
+	app.Post("/api/v1/webhooks/:provider", h.Receive)
+}
+
+// Receive decodes a delivery and verifies it against the webhook secret of every repo it
+// matches, then starts the sync, analysis or review it calls for on the repos that
+// verified it, in the background, and returns 202 with their IDs. Events CodeLens does
+// not act on are acknowledged without verification, since nothing is done for them.
+func (h *WebhookHandler) Receive(c fiber.Ctx) error {
+	provider := c.Params("provider")
+	parser, ok := h.parsers[provider]
+	if !ok {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "unknown webhook provider"})
+	}
+
+	// Fiber reuses request buffers; copy headers since the event outlives the request
+	headers := http.Header{}
+	for k, values := range c.GetReqHeaders() {
+		for _, v := range values {
+			headers.Add(strings.Clone(k), strings.Clone(v))
+		}
+	}
+	body := c.Body()
+
+	evt, err := parser.Parse(headers, body)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if evt.Kind == domain.WebhookEventIgnored {
+		return c.JSON(fiber.Map{"ok": true, "event": evt.Kind})
+	}
+
+	repos, err := h.webhooks.Authorize(c.Context(), evt.RepoURLs, func(secret string) error {
+		return parser.Verify(headers, body, secret)
+	})
+	if errors.Is(err, port.ErrWebhookSignature) {
+		slog.Warn("webhook signature rejected", "provider", provider, "ip", c.IP())
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": err.Error()})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": err.Error()})
+	}
+	if evt.Kind == domain.WebhookEventPing {
+		return c.JSON(fiber.Map{"ok": true, "event": evt.Kind})
+	}
+
+	repoIDs := h.webhooks.Handle(evt, repos)
+	slog.Info("webhook received", "provider", provider, "event", evt.Kind, "delivery", evt.DeliveryID, "repos", len(repoIDs))
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{"event": evt.Kind, "repos": repoIDs})
+}
@@ -179,15 +179,17 @@ func (s *Server) callTool(ctx context.Context, params json.RawMessage) (interfac
 		}
 		json.Unmarshal(req.Arguments, &args)
 
-		answer, chunks, err := s.ragService.Query(ctx, args.RepoID, args.Query)
+		result, err := s.ragService.Query(ctx, args.RepoID, args.Query, service.QueryOptions{})
 		if err != nil {
 			return nil, err
 		}
 		return map[string]interface{}{
 			"content": []map[string]interface{}{
-				{"type": "text", "text": answer},
+				{"type": "text", "text": result.Answer},
 			},
-			"sources": chunks,
+			"sources":       result.Sources,
+			"citations":     result.Citations,
+			"index_warning": result.IndexWarning,
 		}, nil
 
 	case "analyze_repo":
@@ -74,6 +74,21 @@ func GetUserContext(c fiber.Ctx) *domain.UserContext {
 	return u
 }
 
+// RequireRole rejects requests whose authenticated user does not have the given role.
+// It must run after JWTMiddleware.
+func RequireRole(role string) fiber.Handler {
+	return func(c fiber.Ctx) error {
+		uc := GetUserContext(c)
+		if uc == nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		if uc.Role != role {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": role + " role required"})
+		}
+		return c.Next()
+	}
+}
+
 // --- JWT Claims & Helpers ---
 
 // Claims represents the JWT payload.
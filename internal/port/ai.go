@@ -20,3 +20,23 @@ type AIProvider interface {
 	// ChatStream sends a prompt and streams the response token-by-token via channel.
 	ChatStream(ctx context.Context, systemPrompt string, userPrompt string, contextChunks []string) (<-chan string, error)
 }
+
+// Reranker scores retrieved documents against a query in a second retrieval stage
+// (a cross-encoder style model or an LLM acting as relevance judge).
+type Reranker interface {
+	// Rerank returns one relevance score in [0, 1] per document, in input order.
+	Rerank(ctx context.Context, query string, documents []string) ([]float64, error)
+}
+
+// EmbedderFactory returns a provider whose Embed/EmbedBatch use the given embedding model.
+// It is used to serve and migrate embedding indexes built with a model other than the configured one.
+type EmbedderFactory func(model string) AIProvider
+
+// EmbeddingCache stores embedding vectors by content key (see the caching AI provider).
+type EmbeddingCache interface {
+	// GetCachedEmbeddings returns the vectors found for keys; missing keys are absent from the map.
+	GetCachedEmbeddings(ctx context.Context, keys []string) (map[string][]float32, error)
+
+	// PutCachedEmbeddings stores vectors produced by model, keeping existing entries.
+	PutCachedEmbeddings(ctx context.Context, model string, entries map[string][]float32) error
+}
@@ -0,0 +1,34 @@
+package port
+
+// CodeChunk is a slice of a source file prepared for embedding, carrying the
+// structural metadata needed to point back at the code it came from.
+type CodeChunk struct {
+	Content   string `json:"content"`
+	Symbol    string `json:"symbol,omitempty"` // function, method or type name
+	Kind      string `json:"kind"`             // see domain.SymbolKind* constants
+	Parent    string `json:"parent,omitempty"` // receiver type for methods
+	StartLine int    `json:"start_line"`       // 1-based, inclusive
+	EndLine   int    `json:"end_line"`         // 1-based, inclusive
+}
+
+// Chunker splits a source file into embedding-sized chunks.
+// Implementations understand the structure of one language (or none, for the fallback).
+type Chunker interface {
+	// Chunk splits content into chunks. An error means the file could not be parsed
+	// and the caller should fall back to a structure-agnostic chunker.
+	Chunk(filePath string, content string) ([]CodeChunk, error)
+}
+
+// DefaultChunker is the registry key of the structure-agnostic fallback chunker.
+const DefaultChunker = "*"
+
+// ChunkerRegistry holds Chunker implementations keyed by detected language.
+type ChunkerRegistry map[string]Chunker
+
+// For returns the chunker registered for language, or the DefaultChunker fallback.
+func (r ChunkerRegistry) For(language string) Chunker {
+	if c, ok := r[language]; ok {
+		return c
+	}
+	return r[DefaultChunker]
+}
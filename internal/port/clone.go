@@ -0,0 +1,40 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// CloneStore records the disk space taken by repository clones and their use,
+// so that idle clones can be evicted and per-user quotas enforced.
+type CloneStore interface {
+	// DeleteRepo deletes a repo with everything recorded about it.
+	DeleteRepo(ctx context.Context, id string) error
+
+	// TouchRepo records that a repo's clone was just used.
+	TouchRepo(ctx context.Context, id string) error
+
+	// SetRepoDiskUsage records the size of a repo's clone.
+	SetRepoDiskUsage(ctx context.Context, id string, bytes int64) error
+
+	// EvictRepo marks a ready repo's clone as evicted and reports whether it was.
+	EvictRepo(ctx context.Context, id string) (bool, error)
+
+	// RepoPathShared reports whether a repo other than id uses localPath as its clone.
+	RepoPathShared(ctx context.Context, localPath, id string) (bool, error)
+
+	// UserCloneUsage returns the disk space taken by a user's clones.
+	UserCloneUsage(ctx context.Context, userID string) (int64, error)
+
+	// ListUsersOverCloneQuota returns the users whose clones take more than quota bytes.
+	ListUsersOverCloneQuota(ctx context.Context, quota int64) ([]string, error)
+
+	// ListIdleRepos returns the ready repos last used before the given time, least
+	// recently used first: a user's when userID is set, everyone's otherwise.
+	ListIdleRepos(ctx context.Context, userID string, before time.Time) ([]domain.Repo, error)
+
+	// ListUnmeasuredClones returns the ready repos whose clone size was never recorded.
+	ListUnmeasuredClones(ctx context.Context) ([]domain.Repo, error)
+}
@@ -11,4 +11,19 @@ var (
 	ErrUserNotFound     = errors.New("user not found")
 	ErrRepoNotFound     = errors.New("repository not found")
 	ErrSnapshotNotFound = errors.New("snapshot not found")
+
+	ErrDimensionMismatch = errors.New("embedding dimension mismatch")
+	ErrNoActiveIndex     = errors.New("no active embedding index")
+	ErrMigrationRunning  = errors.New("embedding migration already running")
+	ErrIndexChanged      = errors.New("active embedding index changed while indexing; index again")
+
+	ErrRepoNotReady   = errors.New("repository not cloned or not ready")
+	ErrSyncInProgress = errors.New("repository sync already in progress")
+	ErrRepoBusy       = errors.New("repository is being cloned, synced or evicted, or used by a running job")
+	ErrCloneRestoring = errors.New("repository clone was evicted and is being restored; retry shortly")
+	ErrCloneQuota     = errors.New("clone disk quota exceeded; delete repositories to free space")
+
+	ErrWebhookSignature = errors.New("invalid webhook signature")
+
+	ErrNoIssueTracker = errors.New("no issue tracker configured for this repository")
 )
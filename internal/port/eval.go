@@ -0,0 +1,16 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// EvalRunStore persists RAG evaluation runs so that retrieval changes can be compared over time.
+type EvalRunStore interface {
+	// SaveEvalRun stores a run with its per-question results and sets its ID.
+	SaveEvalRun(ctx context.Context, run *domain.EvalRun) error
+
+	// ListEvalRuns returns the latest runs of a question set for a repo, newest first.
+	ListEvalRuns(ctx context.Context, repoID, setName string, limit int) ([]domain.EvalRun, error)
+}
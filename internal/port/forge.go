@@ -0,0 +1,51 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// ForgeClient comments on the pull requests of one forge on behalf of a user.
+type ForgeClient interface {
+	// Comments returns the discussion and inline comments of a pull request.
+	Comments(ctx context.Context, token string, pr *domain.ForgePullRequest) ([]domain.ForgeComment, error)
+
+	// CreateComment adds a comment to a pull request; an inline one when c.Path is set.
+	CreateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error
+
+	// UpdateComment replaces the body of a comment returned by Comments.
+	UpdateComment(ctx context.Context, token string, pr *domain.ForgePullRequest, c *domain.ForgeComment) error
+
+	// CurrentUser returns the login a token authenticates as on the forge hosting repoURL.
+	CurrentUser(ctx context.Context, token, repoURL string) (string, error)
+}
+
+// ForgeClientRegistry holds ForgeClient implementations keyed by forge name.
+type ForgeClientRegistry map[string]ForgeClient
+
+// ForgeProvider lists and clones the repositories of one forge host with the OAuth
+// token of a user who logged in through the auth provider of the same name.
+type ForgeProvider interface {
+	// Matches reports whether a repository URL is hosted on this forge.
+	Matches(repoURL string) bool
+
+	// ListRepos returns one page (1-based) of the repositories the token can access,
+	// most recently updated first.
+	ListRepos(ctx context.Context, token string, page, perPage int) ([]domain.ForgeRepo, error)
+
+	// CloneURL returns the URL to clone a repository from, stripped of any credentials.
+	// Non-HTTP URLs are returned as they are.
+	CloneURL(repoURL string) (string, error)
+
+	// TokenAuth returns the credentials git sends a user's OAuth token to the forge with
+	// over HTTPS, or nil for non-HTTP URLs.
+	TokenAuth(repoURL, token string) *domain.GitAuth
+
+	// DefaultBranch returns the default branch of a repository.
+	DefaultBranch(ctx context.Context, token, repoURL string) (string, error)
+}
+
+// ForgeProviderRegistry holds ForgeProvider implementations keyed by forge name, which
+// is also the name of the forge's AuthProvider.
+type ForgeProviderRegistry map[string]ForgeProvider
@@ -0,0 +1,24 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// IssueTracker opens issues in one kind of tracker and reads their state back.
+// repoURL is the URL of the repository the findings come from; forge trackers open
+// issues in it unless the config names another project.
+type IssueTracker interface {
+	// Validate checks that a tracker config has what this tracker needs.
+	Validate(cfg *domain.IssueTrackerConfig) error
+
+	// CreateIssue opens an issue and returns the link to it.
+	CreateIssue(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL string, draft *domain.IssueDraft) (*domain.IssueLink, error)
+
+	// IssueState returns whether an issue is open or closed (domain.IssueState*).
+	IssueState(ctx context.Context, cfg *domain.IssueTrackerConfig, repoURL, key string) (string, error)
+}
+
+// IssueTrackerRegistry holds IssueTracker implementations keyed by kind.
+type IssueTrackerRegistry map[string]IssueTracker
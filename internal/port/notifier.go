@@ -0,0 +1,19 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// Notifier sends notification events over one channel (webhook, chat, email).
+type Notifier interface {
+	// Validate checks that a subscription's target is usable by this channel.
+	Validate(target string) error
+
+	// Send delivers evt to the subscription's target. An error means the send may be retried.
+	Send(ctx context.Context, sub *domain.NotificationSubscription, evt *domain.NotificationEvent) error
+}
+
+// NotifierRegistry holds Notifier implementations keyed by channel name.
+type NotifierRegistry map[string]Notifier
@@ -0,0 +1,21 @@
+package port
+
+import (
+	"context"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// SnapshotStore reads repository snapshots and records the state of their RAG index.
+type SnapshotStore interface {
+	// ListSnapshots returns a repo's snapshots, newest first.
+	ListSnapshots(ctx context.Context, repoID string, limit int) ([]domain.Snapshot, error)
+
+	// TrackedHead returns the HEAD commit recorded at the repo's last clone or sync
+	// ("" when unknown) and the branch it tracks.
+	TrackedHead(ctx context.Context, repoID string) (commit, branch string, err error)
+
+	// UpdateSnapshotIndex records the state of a snapshot's index: its status (pending
+	// while re-indexed, vectorized or failed), counts, and the error when indexing failed.
+	UpdateSnapshotIndex(ctx context.Context, id, status string, files, chunks, failed int, indexErr string) error
+}
@@ -9,21 +9,51 @@ import (
 // VCSProvider abstracts version control system operations.
 // Implementations handle cloning, log retrieval, and diff generation.
 type VCSProvider interface {
-	// Clone clones a repository from url into dest directory.
-	Clone(ctx context.Context, url string, dest string) error
+	// Clone clones a repository from url into dest directory. opts (optional) make
+	// it shallow, blobless, single-branch or sparse; auth (optional) holds the
+	// credentials of the remote.
+	Clone(ctx context.Context, url string, dest string, opts *domain.CloneOptions, auth *domain.GitAuth) error
 
-	// Pull fetches the latest changes for an existing local repository.
-	Pull(ctx context.Context, repoPath string) error
+	// Deepen fetches up to commits more commits of history into a shallow clone, and
+	// reports false when the clone already has its full history.
+	Deepen(ctx context.Context, repoPath string, commits int, auth *domain.GitAuth) (bool, error)
 
-	// Log returns the commit history of a repository.
-	Log(ctx context.Context, repoPath string, limit int) ([]domain.CommitInfo, error)
+	// Pull fetches branch from origin and moves the local working copy to it.
+	// The working copy is treated as a read-only mirror: local changes are discarded,
+	// and force-pushed history is followed. An empty branch pulls the current one.
+	Pull(ctx context.Context, repoPath, branch string, auth *domain.GitAuth) error
 
-	// Diff returns the unified diff between two commits.
-	Diff(ctx context.Context, repoPath, fromHash, toHash string) (string, error)
+	// ScrubRemote removes any credentials from the URL of a clone's origin remote and
+	// reports whether there were any.
+	ScrubRemote(ctx context.Context, repoPath string) (bool, error)
 
-	// ListFiles returns all file paths in the repository at a given commit.
+	// Log returns the commit history of a repository. auth (optional) is used by
+	// blobless clones to fetch missing objects.
+	Log(ctx context.Context, repoPath string, limit int, auth *domain.GitAuth) ([]domain.CommitInfo, error)
+
+	// CountCommits returns the number of commits reachable from HEAD: in a shallow
+	// clone, those fetched so far.
+	CountCommits(ctx context.Context, repoPath string) (int, error)
+
+	// ResolveRef returns the commit a branch, tag or (abbreviated) hash points to.
+	ResolveRef(ctx context.Context, repoPath, ref string) (*domain.CommitInfo, error)
+
+	// Fetch fetches refspecs from origin without touching the working copy,
+	// e.g. "+refs/pull/7/head:refs/codelens/pull/7".
+	Fetch(ctx context.Context, repoPath string, auth *domain.GitAuth, refspecs ...string) error
+
+	// MergeBase returns the best common ancestor of two commits.
+	MergeBase(ctx context.Context, repoPath, a, b string) (string, error)
+
+	// Diff returns the unified diff between two commits. auth (optional) is used to
+	// fetch missing file contents of blobless clones.
+	Diff(ctx context.Context, repoPath, fromHash, toHash string, auth *domain.GitAuth) (string, error)
+
+	// ListFiles returns all file paths in the repository at a given commit, limited
+	// to the checked-out paths of sparse clones.
 	ListFiles(ctx context.Context, repoPath string, commitHash string) ([]string, error)
 
-	// ReadFile reads a file's content at a specific commit hash.
-	ReadFile(ctx context.Context, repoPath string, commitHash string, filePath string) ([]byte, error)
+	// ReadFile reads a file's content at a specific commit hash. auth (optional) is
+	// used by blobless clones to fetch the file.
+	ReadFile(ctx context.Context, repoPath string, commitHash string, filePath string, auth *domain.GitAuth) ([]byte, error)
 }
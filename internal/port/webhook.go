@@ -0,0 +1,23 @@
+package port
+
+import (
+	"net/http"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// WebhookParser verifies and decodes webhook deliveries sent by one forge.
+// Implementations are pure functions of the request, so recorded deliveries can be replayed.
+type WebhookParser interface {
+	// Verify checks the delivery's signature (or token) headers against the webhook secret
+	// of a repo. It returns ErrWebhookSignature when they do not match.
+	Verify(headers http.Header, body []byte, secret string) error
+
+	// Parse decodes a delivery, which is verified afterwards against the secrets of the
+	// repos its URLs match. Events CodeLens does not act on are returned with kind
+	// domain.WebhookEventIgnored rather than as errors.
+	Parse(headers http.Header, body []byte) (*domain.WebhookEvent, error)
+}
+
+// WebhookParserRegistry holds WebhookParser implementations keyed by forge name.
+type WebhookParserRegistry map[string]WebhookParser
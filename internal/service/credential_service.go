@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// CredentialService resolves the credentials git reaches a repo's remote with: the
+// repo's SSH key for SSH remotes, and its owner's OAuth token for HTTPS remotes on the
+// forge the owner logged in with.
+type CredentialService struct {
+	store  *store.PostgresStore
+	keys   *SSHKeyService // optional
+	forges port.ForgeProviderRegistry
+}
+
+// NewCredentialService creates a new credential service.
+func NewCredentialService(s *store.PostgresStore, keys *SSHKeyService, forges port.ForgeProviderRegistry) *CredentialService {
+	return &CredentialService{store: s, keys: keys, forges: forges}
+}
+
+// GitAuth returns the credentials for a repo's remote, or nil if it has none.
+func (s *CredentialService) GitAuth(ctx context.Context, repo *domain.Repo) (*domain.GitAuth, error) {
+	lower := strings.ToLower(repo.URL)
+	if !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "http://") {
+		return s.keys.GitAuth(ctx, repo)
+	}
+
+	user, err := s.store.GetUserByID(ctx, repo.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("load repo owner: %w", err)
+	}
+	forge, ok := s.forges[user.Provider]
+	if !ok || user.AccessToken == "" || !forge.Matches(repo.URL) {
+		return nil, nil // public repo, or on another forge
+	}
+	return forge.TokenAuth(repo.URL, user.AccessToken), nil
+}
+
+// ForgeToken returns a user's OAuth token for calls to the API of the forge named
+// provider about the repository at repoURL. As with GitAuth, the token is only handed
+// out when the user logged in with that forge and the repository is hosted on it, so it
+// never reaches another host.
+func (s *CredentialService) ForgeToken(ctx context.Context, userID, provider, repoURL string) (string, error) {
+	user, err := s.store.GetUserByID(ctx, userID)
+	if err != nil {
+		return "", fmt.Errorf("load repo owner: %w", err)
+	}
+	return ownerToken(s.forges, user, provider, repoURL)
+}
+
+// ownerToken implements ForgeToken for a loaded owner.
+func ownerToken(forges port.ForgeProviderRegistry, user *domain.User, provider, repoURL string) (string, error) {
+	forge, ok := forges[user.Provider]
+	switch {
+	case !ok || user.Provider != provider:
+		return "", fmt.Errorf("repo owner did not log in with %s", provider)
+	case !forge.Matches(repoURL):
+		return "", fmt.Errorf("repo is not hosted on the %s instance its owner logged in with", provider)
+	case user.AccessToken == "":
+		return "", fmt.Errorf("repo owner has no forge access token")
+	}
+	return user.AccessToken, nil
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// reembedBatchSize is how many chunks a migration embeds per model call.
+const reembedBatchSize = 64
+
+// EmbeddingService tracks which embedding model and dimension back the vector store
+// and migrates the stored chunks from one model to another.
+type EmbeddingService struct {
+	vectorStore *store.VectorStore
+	embedderFor port.EmbedderFactory
+
+	mu        sync.Mutex
+	embedders map[string]port.AIProvider // by model name
+	migrating atomic.Bool
+}
+
+// NewEmbeddingService creates an embedding service. embedderFor builds a provider for a model name.
+func NewEmbeddingService(vectorStore *store.VectorStore, embedderFor port.EmbedderFactory) *EmbeddingService {
+	return &EmbeddingService{
+		vectorStore: vectorStore,
+		embedderFor: embedderFor,
+		embedders:   make(map[string]port.AIProvider),
+	}
+}
+
+// Init loads the active embedding index, creating it for configuredModel on first start.
+// If the active index was built with another model, it keeps being served (queries are
+// embedded with that model) until a migration switches to the configured one.
+func (s *EmbeddingService) Init(ctx context.Context, configuredModel string) (*domain.EmbeddingIndex, error) {
+	idx, err := s.vectorStore.EnsureActiveIndex(ctx, configuredModel)
+	if err != nil {
+		return nil, err
+	}
+	if idx.Model != configuredModel {
+		slog.Warn("active embedding index uses a different model than configured; start a migration to switch",
+			"active_model", idx.Model, "configured_model", configuredModel)
+	}
+	slog.Info("embedding index", "id", idx.ID, "model", idx.Model, "dimension", idx.Dimension)
+
+	// Bring the ANN index in line with the configuration without blocking startup;
+	// searches keep using the existing index until a rebuilt one is swapped in
+	go s.RefreshVectorIndex(context.Background())
+	return idx, nil
+}
+
+// RefreshVectorIndex rebuilds the active dimension's ANN index if it is missing, its
+// configuration changed, or the data outgrew it. Call it after bulk loads.
+func (s *EmbeddingService) RefreshVectorIndex(ctx context.Context) {
+	idx := s.vectorStore.ActiveIndex()
+	if idx == nil {
+		return
+	}
+	if _, err := s.vectorStore.EnsureDimensionIndex(ctx, idx.Dimension); err != nil {
+		slog.Error("vector index refresh failed", "dimension", idx.Dimension, "error", err)
+	}
+}
+
+// RebuildVectorIndex rebuilds the active dimension's ANN index in the background.
+func (s *EmbeddingService) RebuildVectorIndex() error {
+	idx := s.vectorStore.ActiveIndex()
+	if idx == nil {
+		return port.ErrNoActiveIndex
+	}
+	go func() {
+		if err := s.vectorStore.RebuildDimensionIndex(context.Background(), idx.Dimension); err != nil {
+			slog.Error("vector index rebuild failed", "dimension", idx.Dimension, "error", err)
+		}
+	}()
+	return nil
+}
+
+// VectorIndexStats reports the ANN index of the active embedding index; when samples > 0
+// it also measures recall@k of the index against exact search over that many random queries.
+func (s *EmbeddingService) VectorIndexStats(ctx context.Context, samples, k int) (*domain.VectorIndexStats, error) {
+	stats, err := s.vectorStore.VectorIndexStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if samples > 0 && stats.ActiveRows > 0 {
+		recall, err := s.vectorStore.MeasureRecall(ctx, samples, k)
+		if err != nil {
+			return nil, err
+		}
+		stats.Recall = &recall
+		stats.RecallK = k
+		stats.RecallSamples = samples
+	}
+	return stats, nil
+}
+
+// Embedder returns a provider that embeds with the active index's model, and that index.
+func (s *EmbeddingService) Embedder() (port.AIProvider, *domain.EmbeddingIndex, error) {
+	idx := s.vectorStore.ActiveIndex()
+	if idx == nil {
+		return nil, nil, port.ErrNoActiveIndex
+	}
+	return s.embedder(idx.Model), idx, nil
+}
+
+func (s *EmbeddingService) embedder(model string) port.AIProvider {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.embedders[model]
+	if !ok {
+		p = s.embedderFor(model)
+		s.embedders[model] = p
+	}
+	return p
+}
+
+// Indexes lists every embedding index with its status and migration progress.
+func (s *EmbeddingService) Indexes(ctx context.Context) ([]domain.EmbeddingIndex, error) {
+	return s.vectorStore.ListEmbeddingIndexes(ctx)
+}
+
+// StartMigration re-embeds every stored chunk with model in the background and switches
+// searches to the new index once it is complete. The model's dimension is probed; a
+// non-zero dimension must match it. Only one migration runs at a time.
+func (s *EmbeddingService) StartMigration(ctx context.Context, model string, dimension int) (*domain.EmbeddingIndex, error) {
+	if !s.migrating.CompareAndSwap(false, true) {
+		return nil, port.ErrMigrationRunning
+	}
+	started := false
+	defer func() {
+		if !started {
+			s.migrating.Store(false)
+		}
+	}()
+
+	from := s.vectorStore.ActiveIndex()
+	if from == nil {
+		return nil, port.ErrNoActiveIndex
+	}
+
+	provider := s.embedder(model)
+	probe, err := provider.Embed(ctx, "embedding dimension probe")
+	if err != nil {
+		return nil, fmt.Errorf("probe model %s: %w", model, err)
+	}
+	if dimension != 0 && dimension != len(probe) {
+		return nil, fmt.Errorf("%w: model %s returns %d dimensions, %d requested", port.ErrDimensionMismatch, model, len(probe), dimension)
+	}
+
+	to, err := s.vectorStore.CreateEmbeddingIndex(ctx, model, len(probe))
+	if err != nil {
+		return nil, err
+	}
+
+	started = true
+	go func() {
+		defer s.migrating.Store(false)
+		s.migrate(context.Background(), from, to, provider)
+	}()
+	return to, nil
+}
+
+// migrate copies every chunk of index from into index to with vectors from provider,
+// builds the ANN index for the new dimension, and activates it. Chunks written to the
+// old index during the switch are caught up before its vectors are dropped.
+func (s *EmbeddingService) migrate(ctx context.Context, from, to *domain.EmbeddingIndex, provider port.AIProvider) {
+	log := slog.With("from_index", from.ID, "to_index", to.ID, "model", to.Model, "dimension", to.Dimension)
+	log.Info("embedding migration started")
+
+	total, err := s.vectorStore.CountIndexEmbeddings(ctx, from.ID)
+	if err != nil {
+		s.failMigration(ctx, to, err)
+		return
+	}
+
+	embedded, err := s.reembed(ctx, from, to, provider, total, 0)
+	if err != nil {
+		s.failMigration(ctx, to, err)
+		return
+	}
+
+	// Build the ANN index after the bulk load rather than maintaining it row by row
+	if _, err := s.vectorStore.EnsureDimensionIndex(ctx, to.Dimension); err != nil {
+		s.failMigration(ctx, to, err)
+		return
+	}
+
+	if _, err := s.vectorStore.ActivateEmbeddingIndex(ctx, to.ID); err != nil {
+		s.failMigration(ctx, to, err)
+		return
+	}
+	log.Info("embedding index switched", "chunks", embedded)
+
+	// Catch up on chunks indexed into the old index between the last batch and the switch
+	if _, err := s.reembed(ctx, from, to, provider, total, embedded); err != nil {
+		log.Error("embedding migration catch-up failed; old index kept", "error", err)
+		return
+	}
+	if err := s.vectorStore.DeleteIndexEmbeddings(ctx, from.ID); err != nil {
+		log.Error("failed to drop retired embeddings", "error", err)
+		return
+	}
+	log.Info("embedding migration completed")
+}
+
+// reembed embeds pending chunks of from into to until none are left, recording progress.
+func (s *EmbeddingService) reembed(ctx context.Context, from, to *domain.EmbeddingIndex, provider port.AIProvider, total, embedded int) (int, error) {
+	for {
+		batch, err := s.vectorStore.PendingReembed(ctx, from.ID, to.ID, reembedBatchSize)
+		if err != nil {
+			return embedded, err
+		}
+		if len(batch) == 0 {
+			return embedded, nil
+		}
+
+		ids := make([]string, len(batch))
+		texts := make([]string, len(batch))
+		for i, e := range batch {
+			ids[i] = e.ID
+			texts[i] = e.Content
+		}
+
+		vectors, err := provider.EmbedBatch(ctx, texts)
+		if err != nil {
+			return embedded, fmt.Errorf("embed batch: %w", err)
+		}
+		if err := s.vectorStore.StoreReembedded(ctx, to, ids, vectors); err != nil {
+			return embedded, err
+		}
+
+		embedded += len(batch)
+		if embedded > total {
+			total = embedded
+		}
+		if err := s.vectorStore.UpdateEmbeddingIndexProgress(ctx, to.ID, total, embedded); err != nil {
+			return embedded, fmt.Errorf("update progress: %w", err)
+		}
+	}
+}
+
+func (s *EmbeddingService) failMigration(ctx context.Context, to *domain.EmbeddingIndex, cause error) {
+	slog.Error("embedding migration failed", "to_index", to.ID, "model", to.Model, "error", cause)
+	if err := s.vectorStore.FailEmbeddingIndex(ctx, to.ID, cause.Error()); err != nil {
+		slog.Error("failed to mark embedding index failed", "to_index", to.ID, "error", err)
+	}
+}
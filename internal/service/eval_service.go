@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultEvalK is the recall cutoff used when a golden set does not specify one.
+const defaultEvalK = 5
+
+// judgeContextChars truncates each retrieved chunk shown to the faithfulness judge.
+const judgeContextChars = 1500
+
+// RAGQuerier answers questions about a repository. RAGService implements it;
+// the evaluation harness depends only on this so it can run against fakes.
+type RAGQuerier interface {
+	Query(ctx context.Context, repoID, question string, opts QueryOptions) (*RAGAnswer, error)
+}
+
+// EvalOptions configures a single evaluation run.
+type EvalOptions struct {
+	Query  QueryOptions      // retrieval options applied to every question
+	Label  string            // free-form tag stored with the run
+	Config map[string]string // models and settings recorded for comparison
+}
+
+// EvalService runs golden question sets through the RAG pipeline and scores
+// retrieval (recall@k, MRR) and answers (faithfulness, fact recall via an LLM judge).
+type EvalService struct {
+	rag   RAGQuerier
+	judge port.AIProvider
+	store port.EvalRunStore
+}
+
+// NewEvalService creates an evaluation service. store may be nil to skip persisting runs.
+func NewEvalService(rag RAGQuerier, judge port.AIProvider, store port.EvalRunStore) *EvalService {
+	return &EvalService{rag: rag, judge: judge, store: store}
+}
+
+// LoadEvalSet reads and validates a golden question set from a YAML file.
+func LoadEvalSet(filePath string) (*domain.EvalSet, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("read eval set: %w", err)
+	}
+
+	var set domain.EvalSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse eval set %s: %w", filePath, err)
+	}
+
+	if set.Name == "" {
+		set.Name = strings.TrimSuffix(path.Base(filePath), path.Ext(filePath))
+	}
+	if set.K <= 0 {
+		set.K = defaultEvalK
+	}
+	if len(set.Cases) == 0 {
+		return nil, fmt.Errorf("eval set %s has no questions", set.Name)
+	}
+	for i := range set.Cases {
+		c := &set.Cases[i]
+		if strings.TrimSpace(c.Question) == "" {
+			return nil, fmt.Errorf("eval set %s: question %d is empty", set.Name, i+1)
+		}
+		if c.ID == "" {
+			c.ID = fmt.Sprintf("q%d", i+1)
+		}
+	}
+	return &set, nil
+}
+
+// Run evaluates every question of the set and stores the run when a store is configured.
+// A failing question is recorded with its error and scored zero instead of aborting the run.
+func (s *EvalService) Run(ctx context.Context, set *domain.EvalSet, opts EvalOptions) (*domain.EvalRun, error) {
+	if set.RepoID == "" {
+		return nil, fmt.Errorf("eval set %s: repo_id is required", set.Name)
+	}
+
+	run := &domain.EvalRun{
+		RepoID:    set.RepoID,
+		SetName:   set.Name,
+		Label:     opts.Label,
+		Config:    opts.Config,
+		K:         set.K,
+		CaseCount: len(set.Cases),
+	}
+
+	var retrievalCases, factCases int
+	for _, c := range set.Cases {
+		slog.Info("eval question", "set", set.Name, "id", c.ID)
+		result := s.evalCase(ctx, set.RepoID, set.K, c, opts.Query)
+		run.Results = append(run.Results, result)
+
+		if len(c.ExpectedFiles) > 0 {
+			retrievalCases++
+			run.RecallAtK += result.Recall
+			run.MRR += result.ReciprocalRank
+		}
+		if len(c.Facts) > 0 {
+			factCases++
+			run.FactRecall += result.FactRecall
+		}
+		run.Faithfulness += result.Faithfulness
+	}
+
+	if retrievalCases > 0 {
+		run.RecallAtK /= float64(retrievalCases)
+		run.MRR /= float64(retrievalCases)
+	}
+	if factCases > 0 {
+		run.FactRecall /= float64(factCases)
+	}
+	run.Faithfulness /= float64(len(set.Cases))
+
+	if s.store != nil {
+		if err := s.store.SaveEvalRun(ctx, run); err != nil {
+			return run, fmt.Errorf("save eval run: %w", err)
+		}
+	}
+	return run, nil
+}
+
+// PreviousRun returns the run of the same set that preceded run, or nil if there is none.
+func (s *EvalService) PreviousRun(ctx context.Context, run *domain.EvalRun) (*domain.EvalRun, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	runs, err := s.store.ListEvalRuns(ctx, run.RepoID, run.SetName, 2)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range runs {
+		if r.ID != run.ID {
+			return &r, nil
+		}
+	}
+	return nil, nil
+}
+
+// evalCase answers one question and scores it.
+func (s *EvalService) evalCase(ctx context.Context, repoID string, k int, c domain.EvalCase, opts QueryOptions) domain.EvalResult {
+	result := domain.EvalResult{CaseID: c.ID, Question: c.Question}
+
+	answer, err := s.rag.Query(ctx, repoID, c.Question, opts)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.Answer = answer.Answer
+	result.RetrievedFiles = rankedFiles(answer.Sources)
+	result.Recall = recallAtK(result.RetrievedFiles, c.ExpectedFiles, k)
+	result.ReciprocalRank = reciprocalRank(result.RetrievedFiles, c.ExpectedFiles)
+
+	faithfulness, facts, err := s.judgeAnswer(ctx, c, answer)
+	if err != nil {
+		slog.Warn("eval judge failed", "id", c.ID, "error", err)
+		result.Error = err.Error()
+		return result
+	}
+	result.Faithfulness = faithfulness
+	result.FactRecall = facts
+	return result
+}
+
+// rankedFiles returns the distinct file paths of the retrieved chunks in rank order.
+// Retrieval metrics are computed over files, since golden sets name files, not chunks.
+func rankedFiles(sources []domain.SimilarChunk) []string {
+	seen := map[string]bool{}
+	files := []string{}
+	for _, src := range sources {
+		p := normalizeEvalPath(src.FilePath)
+		if !seen[p] {
+			seen[p] = true
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// recallAtK is the fraction of expected files found among the top k retrieved files.
+func recallAtK(retrieved, expected []string, k int) float64 {
+	if len(expected) == 0 {
+		return 0
+	}
+	if len(retrieved) > k {
+		retrieved = retrieved[:k]
+	}
+	found := 0
+	for _, e := range expected {
+		for _, r := range retrieved {
+			if r == normalizeEvalPath(e) {
+				found++
+				break
+			}
+		}
+	}
+	return float64(found) / float64(len(expected))
+}
+
+// reciprocalRank is 1/rank of the first retrieved file that is expected, or 0 if none is.
+func reciprocalRank(retrieved, expected []string) float64 {
+	for i, r := range retrieved {
+		for _, e := range expected {
+			if r == normalizeEvalPath(e) {
+				return 1 / float64(i+1)
+			}
+		}
+	}
+	return 0
+}
+
+func normalizeEvalPath(p string) string {
+	return strings.TrimPrefix(path.Clean(strings.TrimSpace(p)), "./")
+}
+
+// judgeAnswer asks the judge model how well the answer is supported by the retrieved
+// context (faithfulness) and which expected facts it states (fact recall), both in [0, 1].
+func (s *EvalService) judgeAnswer(ctx context.Context, c domain.EvalCase, answer *RAGAnswer) (float64, float64, error) {
+	systemPrompt := `You are a strict evaluator of answers produced by a code question-answering system.
+Given the question, the retrieved source code, the answer and a list of expected facts:
+- "faithfulness": grade from 0 to 10 how much of the answer is supported by the retrieved source code (10 = every claim is supported, 0 = invented).
+- "facts": for each expected fact, in order, true if the answer states it (in any wording), otherwise false.
+Respond with ONLY a JSON object: {"faithfulness": 8, "facts": [true, false]}`
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Question: %s\n\n", c.Question)
+	sb.WriteString("Retrieved source code:\n")
+	for i, src := range answer.Sources {
+		content := src.Content
+		if len(content) > judgeContextChars {
+			content = content[:judgeContextChars] + "\n..."
+		}
+		fmt.Fprintf(&sb, "--- [%d] %s ---\n%s\n\n", i+1, src.FilePath, content)
+	}
+	fmt.Fprintf(&sb, "Answer:\n%s\n\n", answer.Answer)
+	sb.WriteString("Expected facts:\n")
+	for i, f := range c.Facts {
+		fmt.Fprintf(&sb, "%d. %s\n", i+1, f)
+	}
+	fmt.Fprintf(&sb, "\nReturn a JSON object with exactly %d fact verdicts.", len(c.Facts))
+
+	response, err := s.judge.Chat(ctx, systemPrompt, sb.String(), nil)
+	if err != nil {
+		return 0, 0, fmt.Errorf("judge: %w", err)
+	}
+
+	object, ok := extractJSONObject(response)
+	if !ok {
+		return 0, 0, fmt.Errorf("judge: no JSON object in response")
+	}
+
+	var verdict struct {
+		Faithfulness float64 `json:"faithfulness"`
+		Facts        []bool  `json:"facts"`
+	}
+	if err := json.Unmarshal([]byte(object), &verdict); err != nil {
+		return 0, 0, fmt.Errorf("judge decode: %w", err)
+	}
+
+	faithfulness := min(max(verdict.Faithfulness, 0), 10) / 10
+
+	if len(c.Facts) == 0 {
+		return faithfulness, 0, nil
+	}
+	stated := 0
+	for i, ok := range verdict.Facts {
+		if i < len(c.Facts) && ok {
+			stated++
+		}
+	}
+	return faithfulness, float64(stated) / float64(len(c.Facts)), nil
+}
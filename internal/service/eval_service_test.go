@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// stubRAG answers each question with fixed sources, keyed by question.
+type stubRAG struct {
+	files map[string][]string
+}
+
+func (r *stubRAG) Query(_ context.Context, _, question string, _ QueryOptions) (*RAGAnswer, error) {
+	files, ok := r.files[question]
+	if !ok {
+		return nil, errors.New("no index")
+	}
+	answer := &RAGAnswer{Answer: "answer to " + question}
+	for _, f := range files {
+		answer.Sources = append(answer.Sources, domain.SimilarChunk{
+			Embedding: domain.Embedding{FilePath: f, Content: "func x() {}"},
+		})
+	}
+	return answer, nil
+}
+
+// stubJudge is an AIProvider whose chat replies are fixed verdicts, picked by the
+// question found in the user prompt.
+type stubJudge struct {
+	verdicts map[string]string
+}
+
+func (j *stubJudge) ModelName() string { return "stub-judge" }
+
+func (j *stubJudge) Embed(context.Context, string) ([]float32, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (j *stubJudge) EmbedBatch(context.Context, []string) ([][]float32, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (j *stubJudge) Chat(_ context.Context, _, userPrompt string, _ []string) (string, error) {
+	for question, verdict := range j.verdicts {
+		if strings.Contains(userPrompt, "Question: "+question+"\n") {
+			return verdict, nil
+		}
+	}
+	return "", errors.New("unexpected prompt")
+}
+
+func (j *stubJudge) ChatStream(context.Context, string, string, []string) (<-chan string, error) {
+	return nil, errors.New("not implemented")
+}
+
+var _ port.AIProvider = (*stubJudge)(nil)
+
+const testEvalSet = `name: stub-set
+repo_id: repo-1
+k: 2
+questions:
+  - id: first
+    question: Where are tokens checked?
+    expected_files:
+      - ./internal/middleware/jwt.go
+    facts:
+      - Tokens come from the Authorization header
+      - Signatures use the JWT secret
+  - question: How is search fused?
+    expected_files:
+      - internal/adapter/store/vector.go
+      - internal/service/rag_service.go
+    facts:
+      - Results are fused with RRF
+  - question: What fails?
+    expected_files:
+      - main.go
+`
+
+func TestEvalServiceRunMetrics(t *testing.T) {
+	setPath := filepath.Join(t.TempDir(), "golden.yaml")
+	if err := os.WriteFile(setPath, []byte(testEvalSet), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	set, err := LoadEvalSet(setPath)
+	if err != nil {
+		t.Fatalf("LoadEvalSet: %v", err)
+	}
+	if set.Cases[1].ID != "q2" {
+		t.Fatalf("default case ID = %q, want q2", set.Cases[1].ID)
+	}
+
+	rag := &stubRAG{files: map[string][]string{
+		// Expected file ranked second: recall 1, reciprocal rank 1/2.
+		"Where are tokens checked?": {"internal/handler/auth.go", "internal/middleware/jwt.go", "internal/middleware/jwt.go"},
+		// One of two expected files within k=2, the other ranked third: recall 1/2, reciprocal rank 1.
+		"How is search fused?": {"internal/adapter/store/vector.go", "README.md", "internal/service/rag_service.go"},
+	}}
+	judge := &stubJudge{verdicts: map[string]string{
+		"Where are tokens checked?": `<think>the answer cites jwt.go</think>{"faithfulness": 8, "facts": [true, false]}`,
+		"How is search fused?":      "Verdict:\n```json\n{\"faithfulness\": 12, \"facts\": [true]}\n```",
+	}}
+
+	run, err := NewEvalService(rag, judge, nil).Run(context.Background(), set, EvalOptions{Label: "stub"})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if run.SetName != "stub-set" || run.RepoID != "repo-1" || run.K != 2 || run.CaseCount != 3 || run.Label != "stub" {
+		t.Errorf("run header = %+v", run)
+	}
+
+	results := run.Results
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+	assertMetric(t, "first recall", results[0].Recall, 1)
+	assertMetric(t, "first reciprocal rank", results[0].ReciprocalRank, 0.5)
+	assertMetric(t, "first faithfulness", results[0].Faithfulness, 0.8)
+	assertMetric(t, "first fact recall", results[0].FactRecall, 0.5)
+	if got := strings.Join(results[0].RetrievedFiles, ","); got != "internal/handler/auth.go,internal/middleware/jwt.go" {
+		t.Errorf("first retrieved files = %s", got)
+	}
+
+	assertMetric(t, "q2 recall", results[1].Recall, 0.5)
+	assertMetric(t, "q2 reciprocal rank", results[1].ReciprocalRank, 1)
+	assertMetric(t, "q2 faithfulness (clamped)", results[1].Faithfulness, 1)
+	assertMetric(t, "q2 fact recall", results[1].FactRecall, 1)
+
+	if results[2].Error == "" {
+		t.Error("failing question recorded without error")
+	}
+	assertMetric(t, "q3 recall", results[2].Recall, 0)
+
+	// Retrieval metrics average over the 3 questions with expected files, fact recall
+	// over the 2 with facts, faithfulness over all questions.
+	assertMetric(t, "recall@k", run.RecallAtK, (1+0.5+0)/3)
+	assertMetric(t, "MRR", run.MRR, (0.5+1+0)/3)
+	assertMetric(t, "fact recall", run.FactRecall, (0.5+1)/2)
+	assertMetric(t, "faithfulness", run.Faithfulness, (0.8+1+0)/3)
+}
+
+func TestLoadEvalSetRejectsEmptyQuestion(t *testing.T) {
+	setPath := filepath.Join(t.TempDir(), "bad.yaml")
+	if err := os.WriteFile(setPath, []byte("repo_id: r\nquestions:\n  - question: \"  \"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadEvalSet(setPath); err == nil {
+		t.Fatal("LoadEvalSet accepted an empty question")
+	}
+}
+
+func assertMetric(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s = %v, want %v", name, got, want)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// RetentionPolicy decides which superseded snapshot indexes the garbage collector prunes.
+type RetentionPolicy struct {
+	KeepSnapshots int           // newest vectorized snapshots kept per repo (at least 1)
+	MinAge        time.Duration // snapshots younger than this are kept regardless
+	Interval      time.Duration // time between collections; 0 disables the collector
+}
+
+// PurgeIndex deletes a repo's RAG index: every indexed snapshot, or only snapshotID
+// when set. Purged snapshots are marked pruned and can be indexed again.
+// Returns the number of embeddings deleted.
+func (s *RAGService) PurgeIndex(ctx context.Context, repoID, snapshotID string) (int64, error) {
+	ids := []string{snapshotID}
+	if snapshotID == "" {
+		var err error
+		if ids, err = s.vectorStore.IndexedSnapshotIDs(ctx, repoID); err != nil {
+			return 0, err
+		}
+	}
+
+	deleted, err := s.vectorStore.PurgeSnapshots(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("purge index: %w", err)
+	}
+	slog.Info("purged RAG index", "repo_id", repoID, "snapshots", len(ids), "embeddings", deleted)
+	return deleted, nil
+}
+
+// CollectIndexGarbage prunes the embeddings of snapshots superseded under the policy.
+// Unchanged files of the newest snapshot are reused from it on the next index run,
+// so the kept snapshots are never pruned while indexing depends on them.
+func (s *RAGService) CollectIndexGarbage(ctx context.Context, policy RetentionPolicy) (int64, error) {
+	keep := max(policy.KeepSnapshots, 1)
+	ids, err := s.vectorStore.SupersededSnapshotIDs(ctx, keep, time.Now().Add(-policy.MinAge))
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	deleted, err := s.vectorStore.PurgeSnapshots(ctx, ids)
+	if err != nil {
+		return 0, fmt.Errorf("prune snapshots: %w", err)
+	}
+	slog.Info("pruned superseded RAG snapshots", "snapshots", len(ids), "embeddings", deleted)
+	return deleted, nil
+}
+
+// RunIndexGC collects index garbage every policy.Interval until ctx is cancelled.
+func (s *RAGService) RunIndexGC(ctx context.Context, policy RetentionPolicy) {
+	if policy.Interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if _, err := s.CollectIndexGarbage(ctx, policy); err != nil {
+			slog.Error("index garbage collection failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,354 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// IndexPipelineConfig sizes the indexing pipeline.
+type IndexPipelineConfig struct {
+	Workers    int // concurrent EmbedBatch calls
+	BatchSize  int // max chunks per embedding request
+	BatchChars int // max total characters per embedding request
+	WriteBatch int // rows per COPY into the vector store
+}
+
+// withDefaults fills unset fields with conservative values.
+func (c IndexPipelineConfig) withDefaults() IndexPipelineConfig {
+	if c.Workers <= 0 {
+		c.Workers = 4
+	}
+	if c.BatchSize <= 0 {
+		c.BatchSize = 64
+	}
+	if c.BatchChars <= 0 {
+		c.BatchChars = 64000
+	}
+	if c.WriteBatch <= 0 {
+		c.WriteBatch = 500
+	}
+	return c
+}
+
+// IndexProgress reports how far an indexing run has got. A file counts as done once all
+// its chunks are embedded (or reused, or the file has failed); Chunks counts rows written.
+type IndexProgress struct {
+	FilesDone  int `json:"files_done"`
+	FilesTotal int `json:"files_total"`
+	Chunks     int `json:"chunks"` // chunks written so far; the final count leaves out failed files
+	Failed     int `json:"failed"` // files that failed so far
+	Percent    int `json:"percent"`
+}
+
+// IndexProgressFunc receives progress updates from the indexing pipeline.
+// It is called from a single goroutine and must not block for long.
+type IndexProgressFunc func(IndexProgress)
+
+// indexFile is a file travelling through the pipeline.
+type indexFile struct {
+	path     string
+	content  string
+	language string
+	hash     string
+	chunks   int // set by the chunker before any of its chunks are batched
+}
+
+// pendingChunk is one chunk of a file waiting to be embedded.
+type pendingChunk struct {
+	file  *indexFile
+	index int
+	chunk port.CodeChunk
+}
+
+// embedResult is an embedded batch, or a file that produced no chunks (empty set).
+type embedResult struct {
+	batch   []pendingChunk
+	vectors [][]float32
+	err     error
+	empty   *indexFile
+	stage   string // failure stage when empty is set with err
+}
+
+// pipelineResult summarizes a pipeline run.
+type pipelineResult struct {
+	chunks   int
+	failures []domain.IndexFailure
+}
+
+// runIndexPipeline chunks, embeds and writes files for a snapshot:
+//
+//	producer → chunker → batcher → N embed workers → COPY writer
+//
+// Every stage is connected by a small bounded channel, so a slow embedding model or
+// database throttles the stages before it instead of buffering the whole repository.
+// Batches pack chunks from many files up to BatchSize chunks / BatchChars characters.
+// A failing file is recorded and its partial chunks removed; the run continues.
+// Rows are written to the embedding index whose model embedded them. A dimension
+// mismatch, or a switch of the active index mid-run, aborts the run, since every
+// later write would fail.
+func (s *RAGService) runIndexPipeline(ctx context.Context, repoID, snapshotID, commitHash string, files map[string]string, progress IndexProgress, onProgress IndexProgressFunc) (*pipelineResult, error) {
+	cfg := s.indexing.withDefaults()
+
+	embedder, idx, err := s.embeddings.Embedder()
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	fileCh := make(chan *indexFile, cfg.Workers)
+	chunkCh := make(chan pendingChunk, cfg.BatchSize)
+	batchCh := make(chan []pendingChunk, cfg.Workers)
+	resultCh := make(chan embedResult, cfg.Workers)
+
+	send := func(ch chan<- embedResult, r embedResult) bool {
+		select {
+		case ch <- r:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	// Producer: files in a stable order so progress and logs are reproducible
+	go func() {
+		defer close(fileCh)
+		paths := make([]string, 0, len(files))
+		for p := range files {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		for _, p := range paths {
+			f := &indexFile{path: p, content: files[p], language: detectLanguage(p), hash: hashContent(files[p])}
+			select {
+			case fileCh <- f:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	// The chunker and the embed workers both send to resultCh; it is closed once all
+	// of them have returned, including early returns on cancellation
+	var senders sync.WaitGroup
+
+	// Chunker: files without chunks go straight to the writer so they count as done
+	senders.Add(1)
+	go func() {
+		defer senders.Done()
+		defer close(chunkCh)
+		for f := range fileCh {
+			chunks, err := s.chunkFile(f.path, f.language, f.content)
+			f.chunks = len(chunks)
+			if err != nil || len(chunks) == 0 {
+				if !send(resultCh, embedResult{empty: f, err: err, stage: domain.IndexStageChunk}) {
+					return
+				}
+				continue
+			}
+			for i, c := range chunks {
+				select {
+				case chunkCh <- pendingChunk{file: f, index: i, chunk: c}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	// Batcher: pack chunks from many files into size-limited requests
+	go func() {
+		defer close(batchCh)
+		var batch []pendingChunk
+		chars := 0
+		flush := func() bool {
+			if len(batch) == 0 {
+				return true
+			}
+			select {
+			case batchCh <- batch:
+			case <-ctx.Done():
+				return false
+			}
+			batch, chars = nil, 0
+			return true
+		}
+		for pc := range chunkCh {
+			if len(batch) > 0 && chars+len(pc.chunk.Content) > cfg.BatchChars {
+				if !flush() {
+					return
+				}
+			}
+			batch = append(batch, pc)
+			chars += len(pc.chunk.Content)
+			if len(batch) >= cfg.BatchSize && !flush() {
+				return
+			}
+		}
+		flush()
+	}()
+
+	// Embed workers
+	for range cfg.Workers {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for batch := range batchCh {
+				texts := make([]string, len(batch))
+				for i, pc := range batch {
+					texts[i] = pc.chunk.Content
+				}
+				vectors, err := embedder.EmbedBatch(ctx, texts)
+				if err == nil && len(vectors) != len(batch) {
+					err = fmt.Errorf("got %d vectors for %d chunks", len(vectors), len(batch))
+				}
+				if !send(resultCh, embedResult{batch: batch, vectors: vectors, err: err}) {
+					return
+				}
+			}
+		}()
+	}
+
+	go func() {
+		senders.Wait()
+		close(resultCh)
+	}()
+
+	return s.writeIndexResults(ctx, cancel, idx, repoID, snapshotID, commitHash, cfg, resultCh, progress, onProgress)
+}
+
+// writeIndexResults is the pipeline's writer stage: it turns embedded batches into rows,
+// bulk-loads them into idx with COPY, tracks per-file completion and reports progress.
+func (s *RAGService) writeIndexResults(ctx context.Context, cancel context.CancelFunc, idx *domain.EmbeddingIndex, repoID, snapshotID, commitHash string, cfg IndexPipelineConfig, resultCh <-chan embedResult, progress IndexProgress, onProgress IndexProgressFunc) (*pipelineResult, error) {
+	result := &pipelineResult{}
+	processed := make(map[*indexFile]int)
+	written := make(map[*indexFile]int) // rows written per file
+	failed := make(map[*indexFile]domain.IndexFailure)
+	var rows []domain.Embedding
+	var rowFiles []*indexFile
+	var fatal error
+
+	report := func() {
+		if progress.FilesTotal > 0 {
+			progress.Percent = progress.FilesDone * 100 / progress.FilesTotal
+		}
+		progress.Failed = len(failed)
+		if onProgress != nil {
+			onProgress(progress)
+		}
+	}
+	fail := func(f *indexFile, stage string, err error) {
+		if _, ok := failed[f]; !ok {
+			failed[f] = domain.IndexFailure{SnapshotID: snapshotID, FilePath: f.path, Stage: stage, Error: err.Error()}
+		}
+	}
+	// complete marks n more chunks of f as processed and completes the file when all are
+	complete := func(f *indexFile, n int) {
+		processed[f] += n
+		if processed[f] >= f.chunks {
+			progress.FilesDone++
+			report()
+		}
+	}
+	flush := func() {
+		if len(rows) == 0 {
+			return
+		}
+		if err := s.vectorStore.CopyEmbeddings(ctx, idx, rows); err != nil {
+			if errors.Is(err, port.ErrDimensionMismatch) || errors.Is(err, port.ErrIndexChanged) {
+				fatal = err
+				cancel()
+			}
+			for _, f := range rowFiles {
+				fail(f, domain.IndexStageWrite, err)
+			}
+		} else {
+			progress.Chunks += len(rows)
+			for _, f := range rowFiles {
+				written[f]++
+			}
+		}
+		rows, rowFiles = rows[:0], rowFiles[:0]
+	}
+
+	for r := range resultCh {
+		if fatal != nil {
+			continue // drain so upstream stages can exit
+		}
+
+		if r.empty != nil {
+			if r.err != nil {
+				fail(r.empty, r.stage, r.err)
+			}
+			complete(r.empty, 0)
+			continue
+		}
+
+		counts := make(map[*indexFile]int)
+		for i, pc := range r.batch {
+			counts[pc.file]++
+			if r.err != nil {
+				fail(pc.file, domain.IndexStageEmbed, r.err)
+				continue
+			}
+			rows = append(rows, domain.Embedding{
+				SnapshotID:   snapshotID,
+				RepoID:       repoID,
+				FilePath:     pc.file.path,
+				ChunkIndex:   pc.index,
+				Content:      pc.chunk.Content,
+				Language:     pc.file.language,
+				SymbolName:   pc.chunk.Symbol,
+				SymbolKind:   pc.chunk.Kind,
+				ParentSymbol: pc.chunk.Parent,
+				StartLine:    pc.chunk.StartLine,
+				EndLine:      pc.chunk.EndLine,
+				CommitHash:   commitHash,
+				ContentHash:  pc.file.hash,
+				Vector:       r.vectors[i],
+			})
+			rowFiles = append(rowFiles, pc.file)
+		}
+		if len(rows) >= cfg.WriteBatch {
+			flush()
+		}
+		for f, n := range counts {
+			complete(f, n)
+		}
+	}
+	flush()
+
+	if fatal != nil {
+		return nil, fmt.Errorf("index chunks: %w", fatal)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Drop partially written files so incremental indexing never reuses them
+	var failedPaths []string
+	for f, failure := range failed {
+		result.failures = append(result.failures, failure)
+		failedPaths = append(failedPaths, failure.FilePath)
+		progress.Chunks -= written[f]
+	}
+	sort.Slice(result.failures, func(i, j int) bool { return result.failures[i].FilePath < result.failures[j].FilePath })
+	if err := s.vectorStore.DeleteFileEmbeddings(ctx, snapshotID, failedPaths); err != nil {
+		return nil, fmt.Errorf("drop failed files: %w", err)
+	}
+	if err := s.vectorStore.SaveIndexFailures(ctx, result.failures); err != nil {
+		slog.Error("record index failures failed", "snapshot_id", snapshotID, "error", err)
+	}
+
+	result.chunks = progress.Chunks
+	report()
+	return result, nil
+}
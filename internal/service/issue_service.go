@@ -0,0 +1,354 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/forge"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/secretbox"
+)
+
+// Default issue templates, used when a tracker config leaves them empty.
+const (
+	defaultIssueTitleTemplate = `[{{.Finding.Severity}}] {{.Finding.Title}}`
+	defaultIssueBodyTemplate  = `**Severity:** {{.Finding.Severity}}
+**Location:** ` + "`{{.Location}}`" + `{{if .Review.URL}}
+**Found in:** {{.Review.URL}} at ` + "`{{.Review.HeadSHA}}`" + `{{end}}
+
+{{.Finding.Body}}
+
+---
+Opened by CodeLens from a review of {{.Repo.Name}}.
+`
+)
+
+const (
+	issueTitleMax  = 250 // runes; GitHub and Jira cap titles at 255
+	issueSyncBatch = 100 // issues checked per sync round
+)
+
+// issueTemplateData is what issue title and body templates are executed with. Templates
+// are written by users, so it holds copies of the fields meant for issues only, and
+// nothing about the server such as the clone path.
+type issueTemplateData struct {
+	Repo struct {
+		Name          string
+		URL           string
+		DefaultBranch string
+	}
+	Review struct {
+		Number  int
+		Title   string
+		URL     string
+		BaseSHA string
+		HeadSHA string
+	}
+	Finding struct {
+		FilePath string
+		Line     int
+		Severity string
+		Title    string
+		Body     string
+	}
+	Location string // path, or path:line
+}
+
+// newIssueTemplateData returns the template data of a finding.
+func newIssueTemplateData(repo *domain.Repo, review *domain.Review, f *domain.Finding) *issueTemplateData {
+	var data issueTemplateData
+	data.Repo.Name, data.Repo.URL, data.Repo.DefaultBranch = repo.Name, repo.URL, repo.DefaultBranch
+	data.Review.Number, data.Review.Title, data.Review.URL = review.Number, review.Title, review.URL
+	data.Review.BaseSHA, data.Review.HeadSHA = review.BaseSHA, review.HeadSHA
+	data.Finding.FilePath, data.Finding.Line = f.FilePath, f.Line
+	data.Finding.Severity, data.Finding.Title, data.Finding.Body = f.Severity, f.Title, f.Body
+	data.Location = findingLocation(f)
+	return &data
+}
+
+// IssueService opens review findings as issues in a repo's tracker and keeps the state
+// of those issues in sync on the findings.
+type IssueService struct {
+	store    *store.PostgresStore
+	trackers port.IssueTrackerRegistry
+	creds    *CredentialService
+	box      *secretbox.Box // encrypts tracker tokens at rest; nil = tokens cannot be saved
+}
+
+// NewIssueService creates a new issue service. Trackers without a token of their own use
+// the repo owner's OAuth token from creds.
+func NewIssueService(s *store.PostgresStore, trackers port.IssueTrackerRegistry, creds *CredentialService, box *secretbox.Box) *IssueService {
+	return &IssueService{store: s, trackers: trackers, creds: creds, box: box}
+}
+
+// Prepare validates a tracker config and its templates, and seals a new token.
+func (s *IssueService) Prepare(cfg *domain.IssueTrackerConfig) error {
+	tracker, ok := s.trackers[cfg.Kind]
+	if !ok {
+		return fmt.Errorf("unknown issue tracker %q", cfg.Kind)
+	}
+	if err := tracker.Validate(cfg); err != nil {
+		return err
+	}
+
+	// Render a sample finding so that field typos fail now, not when opening issues
+	sample := newIssueTemplateData(
+		&domain.Repo{Name: "repo", URL: "https://example.com/repo", DefaultBranch: "main"},
+		&domain.Review{Number: 1, URL: "https://example.com/pull/1", HeadSHA: "0000000"},
+		&domain.Finding{FilePath: "main.go", Line: 1, Severity: domain.SeverityHigh, Title: "title", Body: "body"},
+	)
+	if _, err := renderIssue(cfg, sample); err != nil {
+		return err
+	}
+	if cfg.Labels == nil {
+		cfg.Labels = []string{}
+	}
+
+	if cfg.Token != "" {
+		if s.box == nil {
+			return fmt.Errorf("tracker tokens are stored encrypted: set SECRET_KEY to save one")
+		}
+		sealed, err := s.box.Seal([]byte(cfg.Token))
+		if err != nil {
+			return fmt.Errorf("encrypt token: %w", err)
+		}
+		cfg.Token, cfg.TokenSealed = "", sealed
+	}
+	return nil
+}
+
+// SealStoredTokens encrypts the tracker tokens saved in plaintext before tokens were
+// encrypted, and returns how many it sealed. It does nothing without a key.
+func (s *IssueService) SealStoredTokens(ctx context.Context) (int, error) {
+	if s.box == nil {
+		return 0, nil
+	}
+	tokens, err := s.store.ListPlaintextTrackerTokens(ctx)
+	if err != nil {
+		return 0, err
+	}
+	sealed := 0
+	for repoID, token := range tokens {
+		sealedToken, err := s.box.Seal([]byte(token))
+		if err != nil {
+			return sealed, fmt.Errorf("encrypt token: %w", err)
+		}
+		if err := s.store.SealTrackerToken(ctx, repoID, token, sealedToken); err != nil {
+			return sealed, err
+		}
+		sealed++
+	}
+	return sealed, nil
+}
+
+// CreateIssues opens an issue for each of the selected findings of a review that does
+// not have one yet and links it to the finding. It returns the selected findings with
+// their links; issues that could not be opened are reported in the error.
+func (s *IssueService) CreateIssues(ctx context.Context, repo *domain.Repo, review *domain.Review, findingIDs []string) ([]domain.Finding, error) {
+	cfg, tracker, err := s.tracker(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	all, err := s.store.ListFindings(ctx, review.ID)
+	if err != nil {
+		return nil, err
+	}
+	var selected []domain.Finding
+	for _, f := range all {
+		if slices.Contains(findingIDs, f.ID) {
+			selected = append(selected, f)
+		}
+	}
+	if len(selected) != len(findingIDs) {
+		return nil, fmt.Errorf("%d of the selected findings are not part of this review", len(findingIDs)-len(selected))
+	}
+
+	var errs []error
+	for i := range selected {
+		f := &selected[i]
+		if f.Issue != nil {
+			continue
+		}
+		draft, err := renderIssue(cfg, newIssueTemplateData(repo, review, f))
+		if err != nil {
+			return nil, err
+		}
+		draft.Labels = append(slices.Clone(cfg.Labels), "severity:"+f.Severity)
+
+		link, err := tracker.CreateIssue(ctx, cfg, repo.URL, draft)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("finding %s: %w", f.ID, err))
+			continue
+		}
+		if err := s.store.LinkFindingIssue(ctx, f.ID, link); err != nil {
+			errs = append(errs, fmt.Errorf("finding %s: issue %s opened but not linked: %w", f.ID, link.URL, err))
+			continue
+		}
+		f.Issue = link
+		slog.Info("issue opened for finding", "repo_id", repo.ID, "finding_id", f.ID, "issue", link.URL)
+	}
+	return selected, errors.Join(errs...)
+}
+
+// SyncIssues refreshes the state of the open issues of a repo's findings (every repo
+// when repoID is empty) last synced before syncedBefore, and returns how many were checked.
+func (s *IssueService) SyncIssues(ctx context.Context, repoID string, syncedBefore time.Time) (int, error) {
+	findings, err := s.store.ListOpenIssueFindings(ctx, repoID, syncedBefore, issueSyncBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	type repoTracker struct {
+		repo    *domain.Repo
+		cfg     *domain.IssueTrackerConfig
+		tracker port.IssueTracker
+		err     error
+	}
+	trackers := map[string]*repoTracker{}
+	checked := 0
+	for _, f := range findings {
+		rt, ok := trackers[f.RepoID]
+		if !ok {
+			rt = &repoTracker{}
+			if rt.repo, rt.err = s.store.GetRepoByID(f.RepoID); rt.err == nil {
+				rt.cfg, rt.tracker, rt.err = s.tracker(ctx, rt.repo)
+			}
+			trackers[f.RepoID] = rt
+		}
+		if rt.err == nil && rt.cfg.Kind != f.Issue.Tracker {
+			rt.err = fmt.Errorf("issue tracker changed from %s to %s", f.Issue.Tracker, rt.cfg.Kind)
+		}
+
+		state := f.Issue.State
+		if rt.err != nil {
+			slog.Warn("issue sync skipped", "finding_id", f.ID, "error", rt.err)
+		} else if state, err = rt.tracker.IssueState(ctx, rt.cfg, rt.repo.URL, f.Issue.Key); err != nil {
+			slog.Warn("issue sync failed", "finding_id", f.ID, "issue", f.Issue.URL, "error", err)
+			state = f.Issue.State
+		}
+		// Recorded even when unchanged or unknown, so that the finding waits for the next round
+		if err := s.store.SetFindingIssueState(ctx, f.ID, state); err != nil {
+			return checked, err
+		}
+		checked++
+	}
+	return checked, nil
+}
+
+// RunSync syncs the state of open issues every interval until ctx is cancelled.
+func (s *IssueService) RunSync(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := s.SyncIssues(ctx, "", time.Now().Add(-interval))
+			if err != nil {
+				slog.Error("issue sync failed", "error", err)
+			} else if n > 0 {
+				slog.Info("issue states synced", "issues", n)
+			}
+		}
+	}
+}
+
+// tracker loads the tracker config of a repo with its token decrypted, falling back to
+// the repo owner's OAuth token when it has none of its own. The OAuth token is only used
+// for a tracker on the forge the owner logged in with, and for a repository on its host.
+func (s *IssueService) tracker(ctx context.Context, repo *domain.Repo) (*domain.IssueTrackerConfig, port.IssueTracker, error) {
+	cfg, err := s.store.GetIssueTracker(ctx, repo.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, port.ErrNoIssueTracker
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	tracker, ok := s.trackers[cfg.Kind]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown issue tracker %q", cfg.Kind)
+	}
+	switch {
+	case len(cfg.TokenSealed) > 0:
+		if s.box == nil {
+			return nil, nil, fmt.Errorf("tracker token is encrypted and SECRET_KEY is not set")
+		}
+		token, err := s.box.Open(cfg.TokenSealed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("decrypt tracker token: %w", err)
+		}
+		cfg.Token = string(token)
+	case cfg.Token == "":
+		target, err := forge.IssueRepoURL(cfg, repo.URL)
+		if err != nil {
+			return nil, nil, err
+		}
+		token, err := s.creds.ForgeToken(ctx, repo.UserID, cfg.Kind, target)
+		if err != nil {
+			return nil, nil, fmt.Errorf("issue tracker has no token: %w", err)
+		}
+		cfg.Token = token
+	}
+	return cfg, tracker, nil
+}
+
+// renderIssue executes the title and body templates of a tracker config.
+func renderIssue(cfg *domain.IssueTrackerConfig, data *issueTemplateData) (*domain.IssueDraft, error) {
+	titleTmpl, bodyTmpl := cfg.TitleTemplate, cfg.BodyTemplate
+	if titleTmpl == "" {
+		titleTmpl = defaultIssueTitleTemplate
+	}
+	if bodyTmpl == "" {
+		bodyTmpl = defaultIssueBodyTemplate
+	}
+
+	title, err := executeTemplate("title_template", titleTmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	body, err := executeTemplate("body_template", bodyTmpl, data)
+	if err != nil {
+		return nil, err
+	}
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return nil, fmt.Errorf("title_template renders an empty title")
+	}
+	return &domain.IssueDraft{Title: truncateRunes(title, issueTitleMax), Body: body}, nil
+}
+
+// executeTemplate parses and executes a text template, naming it in errors.
+func executeTemplate(name, text string, data any) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// findingLocation formats where a finding is: path, or path:line.
+func findingLocation(f *domain.Finding) string {
+	if f.Line > 0 {
+		return fmt.Sprintf("%s:%d", f.FilePath, f.Line)
+	}
+	return f.FilePath
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/forge"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/secretbox"
+)
+
+func testIssueService(t *testing.T, box *secretbox.Box) *IssueService {
+	t.Helper()
+	return NewIssueService(nil, port.IssueTrackerRegistry{"github": forge.NewGitHubClient()}, nil, box)
+}
+
+func TestIssueTemplatesSeeOnlyIssueFields(t *testing.T) {
+	s := testIssueService(t, nil)
+	for _, field := range []string{".Repo.LocalPath", ".Repo.UserID", ".Review.Error", ".Finding.ReviewID"} {
+		cfg := &domain.IssueTrackerConfig{Kind: "github", BodyTemplate: "{{" + field + "}}"}
+		if err := s.Prepare(cfg); err == nil {
+			t.Errorf("body template reading %s accepted", field)
+		}
+	}
+
+	repo := &domain.Repo{Name: "app", URL: "https://github.com/acme/app", DefaultBranch: "main", LocalPath: "/var/lib/codelens/clones/u/app"}
+	review := &domain.Review{Number: 7, URL: "https://github.com/acme/app/pull/7", HeadSHA: "abc1234"}
+	f := &domain.Finding{FilePath: "main.go", Line: 10, Severity: domain.SeverityHigh, Title: "SQL injection"}
+	cfg := &domain.IssueTrackerConfig{
+		Kind:          "github",
+		TitleTemplate: "{{.Finding.Severity}}: {{.Finding.Title}} in {{.Repo.Name}}#{{.Review.Number}}",
+		BodyTemplate:  "{{.Location}} on {{.Repo.DefaultBranch}} at {{.Review.HeadSHA}}",
+	}
+	draft, err := renderIssue(cfg, newIssueTemplateData(repo, review, f))
+	if err != nil {
+		t.Fatalf("renderIssue: %v", err)
+	}
+	if draft.Title != "high: SQL injection in app#7" || draft.Body != "main.go:10 on main at abc1234" {
+		t.Errorf("draft = %+v", draft)
+	}
+
+	// The default templates render without the clone path
+	draft, err = renderIssue(&domain.IssueTrackerConfig{}, newIssueTemplateData(repo, review, f))
+	if err != nil || strings.Contains(draft.Body, repo.LocalPath) {
+		t.Errorf("default draft = %+v, %v", draft, err)
+	}
+}
+
+func TestPrepareSealsToken(t *testing.T) {
+	box, err := secretbox.New("test-secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &domain.IssueTrackerConfig{Kind: "github", Token: "ghp_tracker"}
+	if err := testIssueService(t, box).Prepare(cfg); err != nil {
+		t.Fatalf("Prepare: %v", err)
+	}
+	if cfg.Token != "" {
+		t.Errorf("plaintext token kept: %q", cfg.Token)
+	}
+	if token, err := box.Open(cfg.TokenSealed); err != nil || string(token) != "ghp_tracker" {
+		t.Errorf("sealed token opens to %q, %v", token, err)
+	}
+
+	// Without a key, a token cannot be saved; a tracker without one still can
+	if err := testIssueService(t, nil).Prepare(&domain.IssueTrackerConfig{Kind: "github", Token: "ghp_tracker"}); err == nil {
+		t.Error("token accepted without an encryption key")
+	}
+	if err := testIssueService(t, nil).Prepare(&domain.IssueTrackerConfig{Kind: "github"}); err != nil {
+		t.Errorf("tracker without token: %v", err)
+	}
+}
+
+func TestOwnerTokenStaysOnIssueRepoHost(t *testing.T) {
+	repoURL := "https://github.com/acme/app"
+	// The owner's GitHub token never goes to a project on another host
+	forges := port.ForgeProviderRegistry{"github": forge.NewGitHubClient()}
+	owner := &domain.User{Provider: "github", AccessToken: "gho_secret"}
+	cfg := &domain.IssueTrackerConfig{Kind: "github", BaseURL: "https://attacker.example", Project: "acme/app"}
+	target, err := forge.IssueRepoURL(cfg, repoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ownerToken(forges, owner, cfg.Kind, target); err == nil {
+		t.Errorf("owner token %q handed to %s", token, cfg.BaseURL)
+	}
+	jira := &domain.IssueTrackerConfig{Kind: "jira", BaseURL: "https://acme.atlassian.net", Project: "APP"}
+	target, err = forge.IssueRepoURL(jira, repoURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token, err := ownerToken(forges, owner, jira.Kind, target); err == nil {
+		t.Errorf("owner token %q handed to Jira", token)
+	}
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// deliveryBackoff is the wait before each retry of a failed delivery; once it is
+// exhausted the delivery is marked failed.
+var deliveryBackoff = []time.Duration{time.Minute, 5 * time.Minute, 30 * time.Minute, 2 * time.Hour, 6 * time.Hour}
+
+const (
+	deliveryBatch   = 50               // deliveries claimed per dispatch round
+	deliveryLease   = 2 * time.Minute  // how long a claimed delivery is hidden from other dispatchers
+	deliveryTimeout = 30 * time.Second // per send
+)
+
+// NotificationService fans repository events out to the matching subscriptions and
+// delivers them through the delivery log, retrying failed sends with backoff.
+type NotificationService struct {
+	store     *store.PostgresStore
+	notifiers port.NotifierRegistry
+	scoreDrop float64 // minimum score drop reported as a regression
+	wake      chan struct{}
+}
+
+// NewNotificationService creates a new notification service. A strategy score that
+// drops by at least scoreDrop since the previous run is reported as a regression.
+func NewNotificationService(s *store.PostgresStore, notifiers port.NotifierRegistry, scoreDrop float64) *NotificationService {
+	return &NotificationService{store: s, notifiers: notifiers, scoreDrop: scoreDrop, wake: make(chan struct{}, 1)}
+}
+
+// Channels returns the names of the configured channels.
+func (s *NotificationService) Channels() []string {
+	channels := make([]string, 0, len(s.notifiers))
+	for name := range s.notifiers {
+		channels = append(channels, name)
+	}
+	slices.Sort(channels)
+	return channels
+}
+
+// Prepare validates a subscription's channel, target and events.
+func (s *NotificationService) Prepare(sub *domain.NotificationSubscription) error {
+	notifier, ok := s.notifiers[sub.Channel]
+	if !ok {
+		return fmt.Errorf("unknown or unconfigured channel %q (available: %v)", sub.Channel, s.Channels())
+	}
+	if err := notifier.Validate(sub.Target); err != nil {
+		return err
+	}
+	if sub.Events == nil {
+		sub.Events = []string{}
+	}
+	for _, e := range sub.Events {
+		if !slices.Contains(domain.NotificationEvents, e) {
+			return fmt.Errorf("unknown event %q (available: %v)", e, domain.NotificationEvents)
+		}
+	}
+	return nil
+}
+
+// Publish queues evt for every subscription of the repo owner that covers it.
+// Errors are logged: notifications never fail the operation that raised them.
+func (s *NotificationService) Publish(ctx context.Context, evt *domain.NotificationEvent) {
+	if s == nil {
+		return
+	}
+	if evt.UserID == "" || evt.RepoName == "" {
+		repo, err := s.store.GetRepoByID(evt.RepoID)
+		if err != nil {
+			slog.Error("notification: load repo failed", "repo_id", evt.RepoID, "error", err)
+			return
+		}
+		evt.UserID, evt.RepoName = repo.UserID, repo.Name
+	}
+	if evt.OccurredAt.IsZero() {
+		evt.OccurredAt = time.Now().UTC()
+	}
+
+	subs, err := s.store.ListSubscriptionsForEvent(ctx, evt.UserID, evt.RepoID, evt.Type)
+	if err != nil {
+		slog.Error("notification: list subscriptions failed", "event", evt.Type, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	ids := make([]string, len(subs))
+	for i, sub := range subs {
+		ids[i] = sub.ID
+	}
+	payload, _ := json.Marshal(evt)
+	if err := s.store.EnqueueDeliveries(ctx, ids, evt.Type, payload); err != nil {
+		slog.Error("notification: enqueue failed", "event", evt.Type, "error", err)
+		return
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Test sends a test event to a subscription right away, bypassing the delivery log.
+func (s *NotificationService) Test(ctx context.Context, sub *domain.NotificationSubscription) error {
+	notifier, ok := s.notifiers[sub.Channel]
+	if !ok {
+		return fmt.Errorf("channel %q is not configured", sub.Channel)
+	}
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	return notifier.Send(ctx, sub, &domain.NotificationEvent{
+		Type:       "test",
+		RepoID:     sub.RepoID,
+		RepoName:   "codelens",
+		Title:      "Test notification",
+		Message:    "This subscription is set up correctly.",
+		OccurredAt: time.Now().UTC(),
+	})
+}
+
+// RunDispatcher sends due deliveries every interval, and as soon as new ones are
+// published, until ctx is cancelled.
+func (s *NotificationService) RunDispatcher(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// A full batch means more may be due: keep going before waiting again
+		for s.dispatch(ctx) == deliveryBatch && ctx.Err() == nil {
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// dispatch sends one batch of due deliveries and returns how many were claimed.
+func (s *NotificationService) dispatch(ctx context.Context) int {
+	deliveries, err := s.store.ClaimDueDeliveries(ctx, deliveryBatch, deliveryLease)
+	if err != nil {
+		slog.Error("notification: claim deliveries failed", "error", err)
+		return 0
+	}
+
+	subs := map[string]*domain.NotificationSubscription{}
+	for i := range deliveries {
+		d := &deliveries[i]
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = s.store.GetSubscriptionByID(ctx, d.SubscriptionID); err != nil {
+				slog.Error("notification: load subscription failed", "delivery_id", d.ID, "error", err)
+				continue // retried when the lease expires
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		s.deliver(ctx, sub, d)
+	}
+	return len(deliveries)
+}
+
+// deliver sends one delivery and records the outcome.
+func (s *NotificationService) deliver(ctx context.Context, sub *domain.NotificationSubscription, d *domain.NotificationDelivery) {
+	err := s.send(ctx, sub, d)
+	if err == nil {
+		if err := s.store.MarkDeliveryDelivered(ctx, d.ID); err != nil {
+			slog.Error("notification: record delivery failed", "delivery_id", d.ID, "error", err)
+		}
+		return
+	}
+
+	var next *time.Time
+	var permanent *permanentError
+	if d.Attempts < len(deliveryBackoff) && !errors.As(err, &permanent) {
+		t := time.Now().Add(deliveryBackoff[d.Attempts])
+		next = &t
+	}
+	slog.Warn("notification delivery failed", "delivery_id", d.ID, "channel", sub.Channel,
+		"attempt", d.Attempts+1, "retry", next != nil, "error", err)
+	if err := s.store.MarkDeliveryFailed(ctx, d.ID, err.Error(), next); err != nil {
+		slog.Error("notification: record delivery failed", "delivery_id", d.ID, "error", err)
+	}
+}
+
+// permanentError is a delivery failure that retrying cannot fix.
+type permanentError struct{ msg string }
+
+func (e *permanentError) Error() string { return e.msg }
+
+// send decodes a delivery's event and hands it to the subscription's channel.
+func (s *NotificationService) send(ctx context.Context, sub *domain.NotificationSubscription, d *domain.NotificationDelivery) error {
+	if !sub.Enabled {
+		return &permanentError{"subscription disabled"}
+	}
+	notifier, ok := s.notifiers[sub.Channel]
+	if !ok {
+		return &permanentError{fmt.Sprintf("channel %q is not configured", sub.Channel)}
+	}
+	var evt domain.NotificationEvent
+	if err := json.Unmarshal(d.Payload, &evt); err != nil {
+		return &permanentError{fmt.Sprintf("decode event: %v", err)}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+	return notifier.Send(ctx, sub, &evt)
+}
+
+// CheckScoreRegressions publishes a score.regression event for every strategy whose
+// score from a run since since dropped by at least the configured amount.
+func (s *NotificationService) CheckScoreRegressions(ctx context.Context, repo *domain.Repo, strategies []string, since time.Time) {
+	if s == nil || s.scoreDrop <= 0 || len(strategies) == 0 {
+		return
+	}
+	scores, err := s.store.RecentAnalysisScores(ctx, repo.ID, strategies)
+	if err != nil {
+		slog.Error("notification: load scores failed", "repo_id", repo.ID, "error", err)
+		return
+	}
+
+	for _, strategy := range strategies {
+		history := scores[strategy]
+		if len(history) < 2 || history[0].CreatedAt.Before(since) {
+			continue // no previous score, or this run failed
+		}
+		latest, previous := history[0].Score, history[1].Score
+		if previous-latest < s.scoreDrop {
+			continue
+		}
+		s.Publish(ctx, &domain.NotificationEvent{
+			Type:     domain.EventScoreRegression,
+			RepoID:   repo.ID,
+			RepoName: repo.Name,
+			UserID:   repo.UserID,
+			Title:    fmt.Sprintf("%s score dropped to %.1f", strategy, latest),
+			Message:  fmt.Sprintf("The %s score of %s went from %.1f to %.1f.", strategy, repo.Name, previous, latest),
+			Data:     map[string]any{"strategy": strategy, "score": latest, "previous_score": previous},
+		})
+	}
+}
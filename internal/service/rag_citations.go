@@ -0,0 +1,131 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// citationRe matches citation markers such as [2] or [1, 3].
+var citationRe = regexp.MustCompile(`\[(\d+(?:\s*,\s*\d+)*)\]`)
+
+// minClaimWords is the shortest sentence treated as a factual claim that needs a citation.
+const minClaimWords = 6
+
+// numberedSources formats retrieved chunks as [n]-numbered context blocks with file, lines and commit.
+func numberedSources(chunks []domain.SimilarChunk) []string {
+	parts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		header := fmt.Sprintf("[%d] %s", i+1, chunk.FilePath)
+		if chunk.StartLine > 0 {
+			header += fmt.Sprintf(" lines %d-%d", chunk.StartLine, chunk.EndLine)
+		}
+		if chunk.SymbolName != "" {
+			header += fmt.Sprintf(" (%s %s)", chunk.SymbolKind, chunk.SymbolName)
+		}
+		if chunk.CommitHash != "" {
+			header += " @ " + shortHash(chunk.CommitHash)
+		}
+		parts[i] = header + "\n" + chunk.Content
+	}
+	return parts
+}
+
+// resolveCitations maps every valid [n] marker in answer to its source chunk
+// (in order of first appearance) and returns the sentences that carry no marker.
+// Markers pointing outside the source list are ignored.
+func resolveCitations(answer string, chunks []domain.SimilarChunk) ([]domain.Citation, []string) {
+	citations := []domain.Citation{}
+	seen := map[int]bool{}
+	for _, m := range citationRe.FindAllStringSubmatch(answer, -1) {
+		for _, num := range strings.Split(m[1], ",") {
+			n, err := strconv.Atoi(strings.TrimSpace(num))
+			if err != nil || n < 1 || n > len(chunks) || seen[n] {
+				continue
+			}
+			seen[n] = true
+			chunk := chunks[n-1]
+			citations = append(citations, domain.Citation{
+				N:      n,
+				File:   chunk.FilePath,
+				Lines:  domain.LineRange{Start: chunk.StartLine, End: chunk.EndLine},
+				Commit: chunk.CommitHash,
+			})
+		}
+	}
+
+	uncited := []string{}
+	for _, claim := range claims(answer) {
+		if !citationRe.MatchString(claim) {
+			uncited = append(uncited, claim)
+		}
+	}
+	return citations, uncited
+}
+
+// claims splits prose into sentences long enough to be factual statements,
+// skipping fenced code blocks, headings and table rows.
+func claims(text string) []string {
+	var out []string
+	inFence := false
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || trimmed == "" || strings.HasPrefix(trimmed, "#") || strings.HasPrefix(trimmed, "|") {
+			continue
+		}
+		trimmed = strings.TrimLeft(trimmed, "-*> ")
+		for _, sentence := range splitSentences(trimmed) {
+			if len(strings.Fields(sentence)) >= minClaimWords {
+				out = append(out, sentence)
+			}
+		}
+	}
+	return out
+}
+
+// splitSentences cuts a line at ". ", "! " and "? " boundaries, keeping any
+// citation markers that follow the punctuation with their sentence.
+func splitSentences(line string) []string {
+	var out []string
+	start := 0
+	for i := 0; i < len(line); i++ {
+		if line[i] != '.' && line[i] != '!' && line[i] != '?' {
+			continue
+		}
+		end := i + 1
+		// Absorb trailing markers: "... done. [2]" belongs to the same sentence
+		for {
+			rest := strings.TrimLeft(line[end:], " ")
+			loc := citationRe.FindStringIndex(rest)
+			if loc == nil || loc[0] != 0 {
+				break
+			}
+			end = len(line) - len(rest) + loc[1]
+		}
+		if end < len(line) && line[end] != ' ' {
+			continue // e.g. "store.go" or "v1.2"
+		}
+		out = append(out, strings.TrimSpace(line[start:end]))
+		start = end
+		i = end - 1
+	}
+	if tail := strings.TrimSpace(line[start:]); tail != "" {
+		out = append(out, tail)
+	}
+	return out
+}
+
+// shortHash abbreviates a commit hash for display.
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return hash
+}
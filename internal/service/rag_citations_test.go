@@ -0,0 +1,87 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+func TestSplitSentences(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{"One. Two! Three?", []string{"One.", "Two!", "Three?"}},
+		{"It lives in store.go and needs v1.2 or later.", []string{"It lives in store.go and needs v1.2 or later."}},
+		{"Cloned first. [1] Then indexed. [2, 3] Done", []string{"Cloned first. [1]", "Then indexed. [2, 3]", "Done"}},
+		{"Twice cited. [1] [2] Next one.", []string{"Twice cited. [1] [2]", "Next one."}},
+		{"Wait... what", []string{"Wait...", "what"}},
+		{"no punctuation", []string{"no punctuation"}},
+		{"", nil},
+	}
+	for _, tt := range tests {
+		if got := splitSentences(tt.line); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("splitSentences(%q) = %q, want %q", tt.line, got, tt.want)
+		}
+	}
+}
+
+func TestResolveCitations(t *testing.T) {
+	chunks := []domain.SimilarChunk{
+		{Embedding: domain.Embedding{FilePath: "a.go", StartLine: 1, EndLine: 10, CommitHash: "c1"}},
+		{Embedding: domain.Embedding{FilePath: "b.go", StartLine: 20, EndLine: 30, CommitHash: "c1"}},
+		{Embedding: domain.Embedding{FilePath: "c.go", StartLine: 5, EndLine: 6, CommitHash: "c1"}},
+	}
+	cite := func(n int) domain.Citation {
+		c := chunks[n-1]
+		return domain.Citation{N: n, File: c.FilePath, Lines: domain.LineRange{Start: c.StartLine, End: c.EndLine}, Commit: c.CommitHash}
+	}
+
+	tests := []struct {
+		name    string
+		answer  string
+		want    []domain.Citation
+		uncited []string
+	}{
+		{
+			name:    "first appearance order, duplicates once",
+			answer:  "The handler validates the request body first [2]. It then stores the repository record [1, 2].",
+			want:    []domain.Citation{cite(2), cite(1)},
+			uncited: []string{},
+		},
+		{
+			name:    "out of range markers ignored",
+			answer:  "The cache is keyed by model and text [0] [4] [3].",
+			want:    []domain.Citation{cite(3)},
+			uncited: []string{},
+		},
+		{
+			name:    "marker after the period",
+			answer:  "Snapshots are pruned by the background collector. [1] Old reports remain available to every user afterwards.",
+			want:    []domain.Citation{cite(1)},
+			uncited: []string{"Old reports remain available to every user afterwards."},
+		},
+		{
+			name: "code, headings, tables and short sentences skipped",
+			answer: "# Summary of the indexing pipeline here\n" +
+				"```go\nfunc main() { fmt.Println(\"no citation needed here at all\") }\n```\n" +
+				"| col | another column with many words in it |\n" +
+				"Yes. Short one.\n" +
+				"- Every chunk carries its own line range too.",
+			want:    []domain.Citation{},
+			uncited: []string{"Every chunk carries its own line range too."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, uncited := resolveCitations(tt.answer, chunks)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("citations = %+v, want %+v", got, tt.want)
+			}
+			if !reflect.DeepEqual(uncited, tt.uncited) {
+				t.Errorf("uncited = %q, want %q", uncited, tt.uncited)
+			}
+		})
+	}
+}
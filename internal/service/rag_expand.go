@@ -0,0 +1,82 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/ai"
+)
+
+// Limits on what the expansion step may add to a query.
+const (
+	maxExpansionQueries     = 3
+	maxExpansionIdentifiers = 8
+)
+
+// identifierLexicalWeight favors exact full-text matches when searching for guessed identifiers.
+const identifierLexicalWeight = 0.8
+
+// QueryExpansion holds the reformulations and guessed identifiers used for multi-query retrieval.
+type QueryExpansion struct {
+	Queries     []string `json:"queries"`
+	Identifiers []string `json:"identifiers"`
+}
+
+// expandQuery asks the chat model to rewrite a (possibly vague) question into
+// code-oriented search queries and to guess identifiers likely to appear in the answer.
+func (s *RAGService) expandQuery(ctx context.Context, question string) (*QueryExpansion, error) {
+	systemPrompt := fmt.Sprintf(`You help a code search engine find source code relevant to a developer's question.
+Rewrite the question into up to %d alternative search queries phrased the way the relevant code, comments or docs would be written,
+and guess up to %d identifiers (function, method, type, file or config names) likely to appear in the relevant code.
+Respond with ONLY a JSON object: {"queries": ["..."], "identifiers": ["..."]}`, maxExpansionQueries, maxExpansionIdentifiers)
+
+	response, err := s.ai.Chat(ctx, systemPrompt, question, nil)
+	if err != nil {
+		return nil, fmt.Errorf("expand query: %w", err)
+	}
+
+	object, ok := extractJSONObject(response)
+	if !ok {
+		return nil, fmt.Errorf("expand query: no JSON object in response")
+	}
+
+	var exp QueryExpansion
+	if err := json.Unmarshal([]byte(object), &exp); err != nil {
+		return nil, fmt.Errorf("expand query decode: %w", err)
+	}
+
+	exp.Queries = cleanTerms(exp.Queries, maxExpansionQueries, question)
+	exp.Identifiers = cleanTerms(exp.Identifiers, maxExpansionIdentifiers, "")
+	return &exp, nil
+}
+
+// extractJSONObject returns the outermost {…} of a model response, ignoring any <think> block and surrounding prose.
+func extractJSONObject(response string) (string, bool) {
+	response = ai.StripThinking(response)
+	start, end := strings.Index(response, "{"), strings.LastIndex(response, "}")
+	if start < 0 || end < start {
+		return "", false
+	}
+	return response[start : end+1], true
+}
+
+// cleanTerms trims, de-duplicates and caps model output, dropping entries equal to exclude.
+func cleanTerms(terms []string, max int, exclude string) []string {
+	seen := map[string]bool{strings.ToLower(strings.TrimSpace(exclude)): true}
+	out := []string{}
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		key := strings.ToLower(t)
+		if t == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, t)
+		if len(out) == max {
+			break
+		}
+	}
+	return out
+}
@@ -0,0 +1,223 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// IndexStats summarizes an incremental indexing run.
+type IndexStats struct {
+	Files    int                   `json:"files"`    // files in the snapshot
+	Reused   int                   `json:"reused"`   // unchanged files whose chunks were copied from the previous snapshot
+	Embedded int                   `json:"embedded"` // new or changed files sent through the embedding pipeline
+	Chunks   int                   `json:"chunks"`   // chunks in the snapshot, reused and new
+	Failures []domain.IndexFailure `json:"failures"` // files that could not be indexed
+}
+
+// IndexSnapshot indexes files for a snapshot incrementally. Files whose content hash
+// matches the repo's latest indexed snapshot reuse its chunk vectors; only new or
+// changed files go through the embedding pipeline. Files absent from the map are dropped.
+// Re-indexing the latest snapshot itself keeps its unchanged files in place, and the
+// snapshot stays pending meanwhile so that searches fall back to another one.
+// onProgress (optional) receives progress across reused and embedded files.
+// The snapshot moves to vectorized when indexing completes, or to failed with the error.
+func (s *RAGService) IndexSnapshot(ctx context.Context, repoID string, snap *domain.Snapshot, files map[string]string, onProgress IndexProgressFunc) (*IndexStats, error) {
+	stats, err := s.indexSnapshot(ctx, repoID, snap, files, onProgress)
+	if err != nil {
+		if updErr := s.snapshots.UpdateSnapshotIndex(ctx, snap.ID, domain.SnapshotStatusFailed, len(files), 0, 0, err.Error()); updErr != nil {
+			slog.Error("mark snapshot failed", "snapshot_id", snap.ID, "error", updErr)
+		}
+		return nil, err
+	}
+	if err := s.snapshots.UpdateSnapshotIndex(ctx, snap.ID, domain.SnapshotStatusVectorized, stats.Files, stats.Chunks, len(stats.Failures), ""); err != nil {
+		return nil, err
+	}
+	return stats, nil
+}
+
+// indexSnapshot reuses unchanged files and runs the rest through the indexing pipeline.
+func (s *RAGService) indexSnapshot(ctx context.Context, repoID string, snap *domain.Snapshot, files map[string]string, onProgress IndexProgressFunc) (*IndexStats, error) {
+	stats := &IndexStats{Files: len(files)}
+
+	prevID, err := s.vectorStore.LatestSnapshotID(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+
+	// A snapshot that did not complete may hold partial files: it starts clean
+	inPlace := prevID == snap.ID && snap.Status == domain.SnapshotStatusVectorized
+	if prevID == snap.ID && !inPlace {
+		prevID = ""
+	}
+
+	prevHashes := map[string]string{}
+	if prevID != "" {
+		if prevHashes, err = s.vectorStore.FileHashes(ctx, prevID); err != nil {
+			return nil, err
+		}
+	}
+
+	var reuse []string
+	changed := make(map[string]string)
+	for path, content := range files {
+		if h, ok := prevHashes[path]; ok && h == hashContent(content) {
+			reuse = append(reuse, path)
+		} else {
+			changed[path] = content
+		}
+	}
+
+	if inPlace {
+		if err := s.snapshots.UpdateSnapshotIndex(ctx, snap.ID, domain.SnapshotStatusPending, snap.FileCount, snap.ChunkCount, snap.FailedFiles, ""); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.vectorStore.DeleteIndexFailures(ctx, snap.ID); err != nil {
+		return nil, fmt.Errorf("reset index failures: %w", err)
+	}
+
+	var copied int64
+	switch {
+	case inPlace:
+		if copied, err = s.vectorStore.KeepFileEmbeddings(ctx, snap.ID, reuse); err != nil {
+			return nil, err
+		}
+		slog.Info("kept unchanged embeddings", "repo_id", repoID, "snapshot_id", snap.ID, "files", len(reuse), "chunks", copied)
+	default:
+		// Start clean in case a previous run on this snapshot was interrupted
+		if err := s.vectorStore.DeleteEmbeddingsBySnapshot(ctx, snap.ID); err != nil {
+			return nil, fmt.Errorf("reset snapshot embeddings: %w", err)
+		}
+		if len(reuse) > 0 {
+			if copied, err = s.vectorStore.CopyFileEmbeddings(ctx, prevID, snap.ID, snap.CommitHash, reuse); err != nil {
+				return nil, err
+			}
+			slog.Info("reused unchanged embeddings", "repo_id", repoID, "from_snapshot", prevID, "files", len(reuse), "chunks", copied)
+		}
+	}
+	stats.Reused = len(reuse)
+	stats.Embedded = len(changed)
+
+	progress := IndexProgress{FilesDone: len(reuse), FilesTotal: len(files)}
+	result, err := s.runIndexPipeline(ctx, repoID, snap.ID, snap.CommitHash, changed, progress, onProgress)
+	if err != nil {
+		return nil, err
+	}
+	stats.Chunks = int(copied) + result.chunks
+	stats.Failures = result.failures
+
+	s.embeddings.RefreshVectorIndex(ctx)
+	return stats, nil
+}
+
+// hashContent returns the hex sha256 of a file's content.
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// maxIndexFileSize skips files larger than this many bytes (generated code, fixtures, dumps).
+const maxIndexFileSize = 50000
+
+// Blacklist: skip binary/non-useful files; include everything else
+var indexSkipExts = map[string]bool{
+	// Images
+	".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".bmp": true, ".ico": true, ".svg": true, ".webp": true, ".tiff": true,
+	// Video/Audio
+	".mp4": true, ".avi": true, ".mov": true, ".mp3": true, ".wav": true, ".flac": true, ".ogg": true, ".webm": true,
+	// Fonts
+	".ttf": true, ".otf": true, ".woff": true, ".woff2": true, ".eot": true,
+	// Archives
+	".zip": true, ".tar": true, ".gz": true, ".bz2": true, ".7z": true, ".rar": true, ".jar": true, ".war": true,
+	// Compiled/Binary
+	".exe": true, ".dll": true, ".so": true, ".dylib": true, ".o": true, ".a": true, ".class": true, ".pyc": true, ".wasm": true,
+	// Lock files & large generated
+	".lock": true,
+	// Data files
+	".sqlite": true, ".db": true, ".pdf": true, ".doc": true, ".docx": true, ".xls": true, ".xlsx": true, ".ppt": true,
+	// Maps
+	".map": true,
+}
+
+var indexSkipFiles = map[string]bool{
+	"package-lock.json": true, "yarn.lock": true, "pnpm-lock.yaml": true,
+	"go.sum": true, "Cargo.lock": true, "Gemfile.lock": true,
+	"composer.lock": true, "poetry.lock": true, "Pipfile.lock": true,
+}
+
+// indexSkipDir reports whether a directory is never indexed (hidden, dependencies, build output).
+func indexSkipDir(name string) bool {
+	return strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor" || name == "__pycache__" || name == "dist" || name == "build" || name == "target"
+}
+
+// indexableName reports whether a file name passes the lock file and extension blacklists.
+func indexableName(name string) bool {
+	return !indexSkipFiles[name] && !indexSkipExts[strings.ToLower(filepath.Ext(name))]
+}
+
+// CollectIndexableFiles walks a working copy and returns the text files worth embedding,
+// keyed by path relative to localPath.
+func CollectIndexableFiles(localPath string) map[string]string {
+	files := make(map[string]string)
+	_ = filepath.Walk(localPath, func(path string, info os.FileInfo, walkErr error) error {
+		if walkErr != nil || info.IsDir() {
+			if info != nil && info.IsDir() && indexSkipDir(filepath.Base(path)) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !indexableName(filepath.Base(path)) || info.Size() > maxIndexFileSize {
+			return nil
+		}
+		relPath, _ := filepath.Rel(localPath, path)
+		content, readErr := os.ReadFile(path)
+		if readErr == nil {
+			files[relPath] = string(content)
+		}
+		return nil
+	})
+	return files
+}
+
+// CollectIndexableFilesAt returns the files worth embedding as of a commit, read from
+// the repository's history rather than its working copy. The same filters apply as
+// for CollectIndexableFiles. auth (optional) is used by blobless clones to fetch files.
+func CollectIndexableFilesAt(ctx context.Context, vcs port.VCSProvider, repoPath, commitHash string, auth *domain.GitAuth) (map[string]string, error) {
+	paths, err := vcs.ListFiles(ctx, repoPath, commitHash)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]string)
+	for _, p := range paths {
+		segments := strings.Split(p, "/")
+		skip := !indexableName(segments[len(segments)-1])
+		for _, dir := range segments[:len(segments)-1] {
+			skip = skip || indexSkipDir(dir)
+		}
+		if skip {
+			continue
+		}
+		content, err := vcs.ReadFile(ctx, repoPath, commitHash, p, auth)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			slog.Warn("read file at commit failed", "path", p, "commit", commitHash, "error", err)
+			continue
+		}
+		if len(content) <= maxIndexFileSize {
+			files[p] = string(content)
+		}
+	}
+	return files, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sort"
 	"strings"
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
@@ -13,154 +14,334 @@ import (
 
 // RAGService handles retrieval-augmented generation over vectorized code.
 type RAGService struct {
-	ai          port.AIProvider
+	ai          port.AIProvider // chat model; embeddings come from the active index's model
+	embeddings  *EmbeddingService
 	vectorStore *store.VectorStore
+	snapshots   port.SnapshotStore
+	chunkers    port.ChunkerRegistry
+	rerank      RerankConfig
+	indexing    IndexPipelineConfig
 }
 
+// RerankConfig configures the optional reranking stage: retrieval over-fetches
+// Candidates chunks, the Reranker scores each against the question, and the best TopN are kept.
+type RerankConfig struct {
+	Reranker   port.Reranker // nil disables reranking entirely
+	Enabled    bool          // default for queries that do not set QueryOptions.Rerank
+	Candidates int           // 0 = defaultRerankCandidates
+	TopN       int           // 0 = defaultRetrieveLimit
+}
+
+// defaultRerankCandidates is how many chunks are over-fetched for the reranker when
+// RerankConfig.Candidates is not set.
+const defaultRerankCandidates = 50
+
 // NewRAGService creates a new RAG service.
-func NewRAGService(ai port.AIProvider, vectorStore *store.VectorStore) *RAGService {
-	return &RAGService{ai: ai, vectorStore: vectorStore}
+// chunkers must contain a port.DefaultChunker fallback for languages without a structural chunker.
+func NewRAGService(ai port.AIProvider, embeddings *EmbeddingService, vectorStore *store.VectorStore, snapshots port.SnapshotStore, chunkers port.ChunkerRegistry, rerank RerankConfig, indexing IndexPipelineConfig) *RAGService {
+	return &RAGService{ai: ai, embeddings: embeddings, vectorStore: vectorStore, snapshots: snapshots, chunkers: chunkers, rerank: rerank, indexing: indexing}
 }
 
-// Query performs a semantic search + AI chat over a repository's code.
-func (s *RAGService) Query(ctx context.Context, repoID, question string) (string, []domain.SimilarChunk, error) {
-	slog.Info("RAG query", "repo_id", repoID, "question", question)
+// defaultLexicalWeight is the share of the hybrid ranking given to full-text matches
+// when a query does not set its own weighting.
+const defaultLexicalWeight = 0.4
 
-	// 1. Embed the question
-	queryVector, err := s.ai.Embed(ctx, question)
-	if err != nil {
-		return "", nil, fmt.Errorf("embed query: %w", err)
-	}
+// QueryOptions tunes retrieval for a single RAG query.
+type QueryOptions struct {
+	// LexicalWeight (0–1) is the share of the fused ranking given to full-text matches;
+	// vector similarity gets the remainder. Nil uses defaultLexicalWeight.
+	LexicalWeight *float64 `json:"lexical_weight,omitempty"`
 
-	// 2. Retrieve similar code chunks
-	chunks, err := s.vectorStore.SearchSimilar(ctx, repoID, queryVector, 10)
-	if err != nil {
-		return "", nil, fmt.Errorf("search similar: %w", err)
+	// SnapshotID scopes retrieval to one indexed snapshot. Empty uses the latest one.
+	SnapshotID string `json:"snapshot_id,omitempty"`
+
+	// Rerank turns the reranking stage on or off for this query. Nil uses the configured default.
+	Rerank *bool `json:"rerank,omitempty"`
+
+	// Expand rewrites the question into several queries plus likely identifiers,
+	// retrieves for each and merges the results before answering.
+	Expand bool `json:"expand,omitempty"`
+}
+
+// lexicalWeight returns the clamped lexical weight for these options.
+func (o QueryOptions) lexicalWeight() float64 {
+	if o.LexicalWeight == nil {
+		return defaultLexicalWeight
 	}
+	w := *o.LexicalWeight
+	if w < 0 {
+		return 0
+	}
+	if w > 1 {
+		return 1
+	}
+	return w
+}
 
-	if len(chunks) == 0 {
-		return "No relevant code found for this query.", nil, nil
+// defaultRetrieveLimit is how many chunks are sent to the chat model without reranking.
+const defaultRetrieveLimit = 10
+
+// retrieve embeds the question and returns the best chunks from hybrid search
+// over the requested snapshot (the latest one by default), expanded into several
+// queries and reranked when enabled. The expansion is nil unless opts.Expand is set.
+func (s *RAGService) retrieve(ctx context.Context, repoID, question string, opts QueryOptions) ([]domain.SimilarChunk, *QueryExpansion, error) {
+	filter := domain.SearchFilter{
+		RepoIDs:    []string{repoID},
+		SnapshotID: opts.SnapshotID,
+		Limit:      defaultRetrieveLimit,
 	}
 
-	// 3. Build context from retrieved chunks
-	contextParts := make([]string, len(chunks))
-	for i, chunk := range chunks {
-		contextParts[i] = fmt.Sprintf("// File: %s (similarity: %.2f)\n%s", chunk.FilePath, chunk.Similarity, chunk.Content)
+	rerank := s.rerankEnabled(opts)
+	if rerank {
+		filter.Limit = s.rerank.Candidates
+		if filter.Limit <= 0 {
+			filter.Limit = defaultRerankCandidates
+		}
 	}
 
-	// 4. Generate AI response with context
-	systemPrompt := `You are CodeLens AI, an expert code analyst. Answer questions about the codebase using the provided code context. 
-Be precise, reference specific files and functions, and provide code examples when relevant.
-Always cite the source file when referencing code.`
+	var chunks []domain.SimilarChunk
+	var expansion *QueryExpansion
+	var err error
+	if opts.Expand {
+		chunks, expansion, err = s.multiQuery(ctx, question, filter, opts)
+	} else {
+		chunks, err = s.Search(ctx, question, filter, opts.LexicalWeight)
+	}
+	if err != nil || !rerank {
+		return chunks, expansion, err
+	}
+	return s.rerankChunks(ctx, question, chunks), expansion, nil
+}
 
-	response, err := s.ai.Chat(ctx, systemPrompt, question, contextParts)
+// multiQuery retrieves for the question, each reformulation and the guessed identifiers,
+// then merges the result lists with reciprocal rank fusion. If expansion fails,
+// it degrades to a single search for the original question.
+func (s *RAGService) multiQuery(ctx context.Context, question string, filter domain.SearchFilter, opts QueryOptions) ([]domain.SimilarChunk, *QueryExpansion, error) {
+	expansion, err := s.expandQuery(ctx, question)
 	if err != nil {
-		return "", nil, fmt.Errorf("chat: %w", err)
+		slog.Warn("query expansion failed, using original question", "error", err)
+		chunks, err := s.Search(ctx, question, filter, opts.LexicalWeight)
+		return chunks, nil, err
+	}
+
+	var lists [][]domain.SimilarChunk
+	for _, q := range append([]string{question}, expansion.Queries...) {
+		hits, err := s.Search(ctx, q, filter, opts.LexicalWeight)
+		if err != nil {
+			return nil, nil, err
+		}
+		lists = append(lists, hits)
+	}
+	if len(expansion.Identifiers) > 0 {
+		weight := identifierLexicalWeight
+		hits, err := s.Search(ctx, strings.Join(expansion.Identifiers, " "), filter, &weight)
+		if err != nil {
+			return nil, nil, err
+		}
+		lists = append(lists, hits)
 	}
 
-	return response, chunks, nil
+	weights := make([]float64, len(lists))
+	for i := range weights {
+		weights[i] = 1
+	}
+	slog.Info("multi-query retrieval", "queries", len(expansion.Queries)+1, "identifiers", len(expansion.Identifiers))
+	return store.FuseRRF(lists, weights, 0, filter.Limit), expansion, nil
 }
 
-// QueryStream performs RAG with streaming response.
-func (s *RAGService) QueryStream(ctx context.Context, repoID, question string) (<-chan string, []domain.SimilarChunk, error) {
-	// 1. Embed the question
-	queryVector, err := s.ai.Embed(ctx, question)
-	if err != nil {
-		return nil, nil, fmt.Errorf("embed query: %w", err)
+// rerankEnabled reports whether the reranking stage applies to a query.
+func (s *RAGService) rerankEnabled(opts QueryOptions) bool {
+	if s.rerank.Reranker == nil {
+		return false
 	}
+	if opts.Rerank != nil {
+		return *opts.Rerank
+	}
+	return s.rerank.Enabled
+}
 
-	// 2. Retrieve similar code chunks
-	chunks, err := s.vectorStore.SearchSimilar(ctx, repoID, queryVector, 10)
-	if err != nil {
-		return nil, nil, fmt.Errorf("search similar: %w", err)
+// rerankChunks scores candidates with the reranker and keeps the best TopN.
+// If the reranker fails, the hybrid ranking is kept as-is.
+func (s *RAGService) rerankChunks(ctx context.Context, question string, chunks []domain.SimilarChunk) []domain.SimilarChunk {
+	topN := s.rerank.TopN
+	if topN <= 0 {
+		topN = defaultRetrieveLimit
 	}
 
-	// 3. Build context
-	contextParts := make([]string, len(chunks))
+	docs := make([]string, len(chunks))
 	for i, chunk := range chunks {
-		contextParts[i] = fmt.Sprintf("// File: %s\n%s", chunk.FilePath, chunk.Content)
+		docs[i] = fmt.Sprintf("// File: %s lines %d-%d %s\n%s", chunk.FilePath, chunk.StartLine, chunk.EndLine, chunk.SymbolName, chunk.Content)
 	}
 
-	systemPrompt := `You are CodeLens AI, an expert code analyst. Answer questions about the codebase using the provided code context.
-Be precise, reference specific files and functions.`
+	scores, err := s.rerank.Reranker.Rerank(ctx, question, docs)
+	if err != nil {
+		slog.Warn("rerank failed, keeping hybrid order", "error", err)
+	} else {
+		for i := range chunks {
+			chunks[i].RerankScore = scores[i]
+		}
+		sort.SliceStable(chunks, func(i, j int) bool { return chunks[i].RerankScore > chunks[j].RerankScore })
+	}
 
-	// 4. Stream AI response
-	stream, err := s.ai.ChatStream(ctx, systemPrompt, question, contextParts)
+	if len(chunks) > topN {
+		chunks = chunks[:topN]
+	}
+	return chunks
+}
+
+// Search embeds query and runs a filtered hybrid search, returning raw chunks without calling the chat model.
+// A nil lexicalWeight uses the default weighting.
+func (s *RAGService) Search(ctx context.Context, query string, filter domain.SearchFilter, lexicalWeight *float64) ([]domain.SimilarChunk, error) {
+	embedder, _, err := s.embeddings.Embedder()
+	if err != nil {
+		return nil, err
+	}
+	queryVector, err := embedder.Embed(ctx, query)
 	if err != nil {
-		return nil, nil, fmt.Errorf("chat stream: %w", err)
+		return nil, fmt.Errorf("embed query: %w", err)
 	}
 
-	return stream, chunks, nil
+	weight := QueryOptions{LexicalWeight: lexicalWeight}.lexicalWeight()
+	chunks, err := s.vectorStore.SearchHybrid(ctx, filter, query, queryVector, weight)
+	if err != nil {
+		return nil, fmt.Errorf("search hybrid: %w", err)
+	}
+	return chunks, nil
 }
 
-// IndexChunks vectorizes and stores code chunks for a snapshot.
-func (s *RAGService) IndexChunks(ctx context.Context, repoID, snapshotID string, files map[string]string) error {
-	slog.Info("indexing chunks", "repo_id", repoID, "files", len(files))
+// RAGAnswer is the result of a RAG query: the model's answer, the numbered sources
+// it was given, and the [n] citations resolved back to file/line/commit.
+type RAGAnswer struct {
+	Answer        string                `json:"answer"`
+	Sources       []domain.SimilarChunk `json:"sources"`
+	Citations     []domain.Citation     `json:"citations"`
+	UncitedClaims []string              `json:"uncited_claims"`
+	Expansions    *QueryExpansion       `json:"expansions,omitempty"`    // set when QueryOptions.Expand is on
+	IndexWarning  string                `json:"index_warning,omitempty"` // set when the repo's index is missing, incomplete or stale
+}
 
-	for filePath, content := range files {
-		chunks := chunkCode(content, 512)
-		if len(chunks) == 0 {
-			continue
-		}
+// ragSystemPrompt instructs the model to answer only from numbered sources and cite them as [n].
+const ragSystemPrompt = `You are CodeLens AI, an expert code analyst. Answer questions about the codebase using ONLY the provided sources.
+Each source is numbered [n] and shows its file, line range and commit.
+Be precise, reference specific files and functions, and provide code examples when relevant.
+Every factual statement about the code MUST end with the number of the source that supports it, e.g. "Tokens are validated in the middleware [2]." or "[1][3]".
+Do not cite sources you did not use, and do not invent source numbers. If the sources do not contain the answer, say so.`
 
-		vectors, err := s.ai.EmbedBatch(ctx, chunks)
-		if err != nil {
-			slog.Error("embed batch failed", "file", filePath, "error", err)
-			continue
-		}
+// Query performs a hybrid (lexical + semantic) search + AI chat over a repository's code.
+func (s *RAGService) Query(ctx context.Context, repoID, question string, opts QueryOptions) (*RAGAnswer, error) {
+	slog.Info("RAG query", "repo_id", repoID, "question", question, "lexical_weight", opts.lexicalWeight())
 
-		embeddings := make([]domain.Embedding, len(chunks))
-		for i, chunk := range chunks {
-			embeddings[i] = domain.Embedding{
-				SnapshotID: snapshotID,
-				RepoID:     repoID,
-				FilePath:   filePath,
-				ChunkIndex: i,
-				Content:    chunk,
-				Language:   detectLanguage(filePath),
-				Vector:     vectors[i],
-			}
-		}
+	// 1–2. Embed the question (and its expansions) and retrieve matching code chunks
+	chunks, expansion, err := s.retrieve(ctx, repoID, question, opts)
+	if err != nil {
+		return nil, err
+	}
 
-		if err := s.vectorStore.StoreBatchEmbeddings(ctx, embeddings); err != nil {
-			slog.Error("store embeddings failed", "file", filePath, "error", err)
-			continue
-		}
+	// An explicit snapshot is the caller's choice; otherwise flag a missing or outdated index
+	warning := ""
+	if opts.SnapshotID == "" {
+		warning = s.indexWarning(ctx, repoID)
+	}
+
+	if len(chunks) == 0 {
+		return &RAGAnswer{Answer: "No relevant code found for this query.", Expansions: expansion, IndexWarning: warning}, nil
+	}
+
+	// 3. Generate AI response over the numbered sources
+	response, err := s.ai.Chat(ctx, ragSystemPrompt, question, numberedSources(chunks))
+	if err != nil {
+		return nil, fmt.Errorf("chat: %w", err)
 	}
 
-	return nil
+	// 4. Resolve [n] markers and flag statements without one
+	citations, uncited := resolveCitations(response, chunks)
+
+	return &RAGAnswer{
+		Answer:        response,
+		Sources:       chunks,
+		Citations:     citations,
+		UncitedClaims: uncited,
+		Expansions:    expansion,
+		IndexWarning:  warning,
+	}, nil
+}
+
+// RAGStream is a streaming RAG answer. Tokens yields the answer as the model generates
+// it and is closed at the end; Done then delivers the whole answer, with its [n]
+// citations resolved as in Query.
+type RAGStream struct {
+	Tokens <-chan string
+	Done   <-chan *RAGAnswer
 }
 
-// chunkCode splits code into overlapping chunks of approximately maxTokens words.
-func chunkCode(content string, maxTokens int) []string {
-	lines := strings.Split(content, "\n")
-	var chunks []string
-	var current []string
-	currentLen := 0
+// QueryStream performs RAG with a streaming response. Sources are numbered the same
+// way as in Query.
+func (s *RAGService) QueryStream(ctx context.Context, repoID, question string, opts QueryOptions) (*RAGStream, error) {
+	// 1–2. Embed the question and retrieve matching code chunks
+	chunks, expansion, err := s.retrieve(ctx, repoID, question, opts)
+	if err != nil {
+		return nil, err
+	}
+	warning := ""
+	if opts.SnapshotID == "" {
+		warning = s.indexWarning(ctx, repoID)
+	}
 
-	for _, line := range lines {
-		wordCount := len(strings.Fields(line))
-		if currentLen+wordCount > maxTokens && len(current) > 0 {
-			chunks = append(chunks, strings.Join(current, "\n"))
-			// Keep last 3 lines for overlap
-			overlap := 3
-			if len(current) < overlap {
-				overlap = len(current)
+	tokens := make(chan string)
+	done := make(chan *RAGAnswer, 1)
+	answer := &RAGAnswer{Sources: chunks, Expansions: expansion, IndexWarning: warning}
+
+	if len(chunks) == 0 {
+		answer.Answer = "No relevant code found for this query."
+		go func() {
+			defer close(tokens)
+			select {
+			case tokens <- answer.Answer:
+			case <-ctx.Done():
 			}
-			current = current[len(current)-overlap:]
-			currentLen = 0
-			for _, l := range current {
-				currentLen += len(strings.Fields(l))
+			done <- answer
+		}()
+		return &RAGStream{Tokens: tokens, Done: done}, nil
+	}
+
+	// 3. Stream AI response over the numbered sources
+	stream, err := s.ai.ChatStream(ctx, ragSystemPrompt, question, numberedSources(chunks))
+	if err != nil {
+		return nil, fmt.Errorf("chat stream: %w", err)
+	}
+
+	// 4. Forward the tokens, then resolve [n] markers over the whole answer
+	go func() {
+		var b strings.Builder
+		for token := range stream {
+			b.WriteString(token)
+			select {
+			case tokens <- token:
+			case <-ctx.Done():
 			}
 		}
-		current = append(current, line)
-		currentLen += wordCount
+		close(tokens)
+		answer.Answer = b.String()
+		answer.Citations, answer.UncitedClaims = resolveCitations(answer.Answer, chunks)
+		done <- answer
+	}()
+	return &RAGStream{Tokens: tokens, Done: done}, nil
+}
+
+// chunkFile splits a file with the chunker registered for its language,
+// falling back to the default chunker when the file cannot be parsed.
+func (s *RAGService) chunkFile(filePath, language, content string) ([]port.CodeChunk, error) {
+	chunks, err := s.chunkers.For(language).Chunk(filePath, content)
+	if err == nil {
+		return chunks, nil
 	}
 
-	if len(current) > 0 {
-		chunks = append(chunks, strings.Join(current, "\n"))
+	slog.Warn("structural chunking failed, using fallback", "file", filePath, "language", language, "error", err)
+	chunks, err = s.chunkers[port.DefaultChunker].Chunk(filePath, content)
+	if err != nil {
+		return nil, fmt.Errorf("fallback chunking: %w", err)
 	}
-	return chunks
+	return chunks, nil
 }
 
 // detectLanguage infers the programming language from file extension.
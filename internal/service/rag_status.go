@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+)
+
+// Index states reported by IndexStatus.
+const (
+	IndexStateMissing = "missing" // no snapshot has been indexed, or its index was purged
+	IndexStatePending = "pending" // the first index is being built
+	IndexStateReady   = "ready"   // the newest snapshot (and HEAD, when known) is indexed
+	IndexStateStale   = "stale"   // searches use an older commit than the newest snapshot or HEAD
+	IndexStateFailed  = "failed"  // indexing failed and no earlier index exists
+)
+
+// maxStatusFailures caps the per-file failures returned with an index status.
+const maxStatusFailures = 50
+
+// IndexStatus describes the RAG index of a repository.
+type IndexStatus struct {
+	RepoID   string                `json:"repo_id"`
+	State    string                `json:"state"`
+	Head     string                `json:"head_commit,omitempty"` // tracked HEAD at the last clone or sync, when known
+	Serving  *domain.Snapshot      `json:"serving,omitempty"`     // vectorized snapshot used by searches
+	Latest   *domain.Snapshot      `json:"latest,omitempty"`      // snapshot of HEAD, or newest of the tracked branch
+	Failures []domain.IndexFailure `json:"failures,omitempty"`    // files of Serving that could not be indexed
+	Warning  string                `json:"warning,omitempty"`
+}
+
+// IndexStatus reports whether a repo's RAG index is missing, being built, failed,
+// or stale relative to the repo's tracked HEAD. Snapshots indexed at other refs
+// serve searches only when the tracked branch has no index.
+func (s *RAGService) IndexStatus(ctx context.Context, repoID string) (*IndexStatus, error) {
+	head, branch, err := s.snapshots.TrackedHead(ctx, repoID)
+	if err != nil {
+		return nil, err
+	}
+	snaps, err := s.snapshots.ListSnapshots(ctx, repoID, 20)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &IndexStatus{RepoID: repoID, Head: head}
+	status.Latest = trackedSnapshot(snaps, head, branch, "")
+	status.Serving = trackedSnapshot(snaps, head, branch, domain.SnapshotStatusVectorized)
+	if status.Latest == nil && len(snaps) > 0 {
+		status.Latest = &snaps[0]
+	}
+
+	switch {
+	case status.Serving == nil && (status.Latest == nil || status.Latest.Status == domain.SnapshotStatusPruned):
+		status.State = IndexStateMissing
+		status.Warning = "this repository has no RAG index yet; run an analysis to build it"
+	case status.Serving == nil && status.Latest.Status == domain.SnapshotStatusFailed:
+		status.State = IndexStateFailed
+		status.Warning = fmt.Sprintf("indexing failed: %s", status.Latest.IndexError)
+	case status.Serving == nil:
+		status.State = IndexStatePending
+		status.Warning = "the RAG index is still being built; results may be incomplete"
+	case head != "" && status.Serving.CommitHash != head:
+		status.State = IndexStateStale
+		status.Warning = fmt.Sprintf("the RAG index is at commit %s but HEAD is %s; results may be outdated", shortHash(status.Serving.CommitHash), shortHash(head))
+	case status.Latest.ID != status.Serving.ID:
+		status.State = IndexStateStale
+		status.Warning = fmt.Sprintf("the RAG index is at commit %s; newer commit %s is %s", shortHash(status.Serving.CommitHash), shortHash(status.Latest.CommitHash), status.Latest.Status)
+	default:
+		status.State = IndexStateReady
+	}
+
+	if status.Serving != nil && status.Serving.FailedFiles > 0 {
+		failures, err := s.vectorStore.ListIndexFailures(ctx, status.Serving.ID, maxStatusFailures)
+		if err != nil {
+			return nil, err
+		}
+		status.Failures = failures
+	}
+	return status, nil
+}
+
+// trackedSnapshot picks from snaps (newest first) the snapshot of the tracked HEAD,
+// or else the newest of the tracked branch, or else the newest of any ref. A non-empty
+// status restricts the choice to snapshots in that status. It mirrors the order in
+// which the vector store picks the snapshot that serves searches.
+func trackedSnapshot(snaps []domain.Snapshot, head, branch, status string) *domain.Snapshot {
+	var onBranch, other *domain.Snapshot
+	for i := range snaps {
+		snap := &snaps[i]
+		switch {
+		case status != "" && snap.Status != status:
+		case head != "" && snap.CommitHash == head:
+			return snap
+		case snap.Branch == branch && onBranch == nil:
+			onBranch = snap
+		case other == nil:
+			other = snap
+		}
+	}
+	if onBranch != nil {
+		return onBranch
+	}
+	return other
+}
+
+// indexWarning returns the index status warning for a repo, or "" when the index
+// is ready. Lookup errors are logged and yield no warning.
+func (s *RAGService) indexWarning(ctx context.Context, repoID string) string {
+	status, err := s.IndexStatus(ctx, repoID)
+	if err != nil {
+		slog.Warn("index status lookup failed", "repo_id", repoID, "error", err)
+		return ""
+	}
+	return status.Warning
+}
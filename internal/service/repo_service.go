@@ -2,9 +2,14 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
-	"path/filepath"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
 	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
@@ -14,25 +19,31 @@ import (
 // RepoService manages repository lifecycle — cloning, indexing, and listing.
 type RepoService struct {
 	store    *store.PostgresStore
+	clones   port.CloneStore
 	vcs      port.VCSProvider
 	basePath string
+	creds    *CredentialService
+	policy   ClonePolicy
+	syncing  sync.Map // repo IDs being cloned, synced, evicted or deleted
+
+	holdMu    sync.Mutex
+	holds     map[string]int  // running jobs per repo ID using its clone
+	exclusive map[string]bool // repo IDs whose clone is being synced, evicted or deleted
 }
 
-// NewRepoService creates a new repository service.
-func NewRepoService(s *store.PostgresStore, vcs port.VCSProvider, basePath string) *RepoService {
-	return &RepoService{store: s, vcs: vcs, basePath: basePath}
+// NewRepoService creates a new repository service. Remotes are reached with the
+// credentials from creds; policy bounds the disk space taken by clones.
+func NewRepoService(s *store.PostgresStore, vcs port.VCSProvider, basePath string, creds *CredentialService, policy ClonePolicy) *RepoService {
+	return &RepoService{store: s, clones: s, vcs: vcs, basePath: basePath, creds: creds, policy: policy}
 }
 
 // AddRepo clones and registers a new repository.
 func (s *RepoService) AddRepo(ctx context.Context, userID, name, url string) (*domain.Repo, error) {
-	localPath := filepath.Join(s.basePath, userID, name)
-
 	repo := &domain.Repo{
 		UserID:        userID,
 		Name:          name,
 		URL:           url,
 		DefaultBranch: "main",
-		LocalPath:     localPath,
 		Status:        domain.RepoStatusCloning,
 	}
 
@@ -42,16 +53,7 @@ func (s *RepoService) AddRepo(ctx context.Context, userID, name, url string) (*d
 	}
 
 	// Clone asynchronously
-	go func() {
-		slog.Info("cloning repository", "repo_id", repo.ID, "url", url)
-		if err := s.vcs.Clone(context.Background(), url, localPath); err != nil {
-			slog.Error("clone failed", "repo_id", repo.ID, "error", err)
-			_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, domain.RepoStatusError, localPath)
-			return
-		}
-		_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, domain.RepoStatusReady, localPath)
-		slog.Info("clone complete", "repo_id", repo.ID)
-	}()
+	go func() { _ = s.CloneRepo(repo) }()
 
 	return repo, nil
 }
@@ -63,32 +65,157 @@ func (s *RepoService) ListRepos(ctx context.Context, userID string) ([]domain.Re
 
 // CloneRepo clones a repository from its URL to the local filesystem.
 func (s *RepoService) CloneRepo(repo *domain.Repo) error {
-	localPath := filepath.Join(s.basePath, repo.UserID, repo.Name)
+	return s.cloneRepo(context.Background(), repo)
+}
 
-	slog.Info("cloning repository", "repo_id", repo.ID, "url", repo.URL)
-	if err := s.vcs.Clone(context.Background(), repo.URL, localPath); err != nil {
+// cloneRepo clones a new or evicted repo within its owner's quota, evicting idle
+// clones to make room, and records its status, path and size. repo is updated in place.
+func (s *RepoService) cloneRepo(ctx context.Context, repo *domain.Repo) error {
+	if _, busy := s.syncing.LoadOrStore(repo.ID, struct{}{}); busy {
+		return port.ErrRepoBusy
+	}
+	defer s.syncing.Delete(repo.ID)
+
+	localPath := s.cloneDir(repo)
+	err := s.freeSpace(ctx, repo.UserID, repo.ID, 1)
+	if err == nil {
+		_ = s.store.UpdateRepoStatus(ctx, repo.ID, domain.RepoStatusCloning, "")
+		slog.Info("cloning repository", "repo_id", repo.ID, "url", repo.URL)
+		err = s.clone(ctx, repo, localPath)
+	}
+	if err != nil {
 		slog.Error("clone failed", "repo_id", repo.ID, "error", err)
-		_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, "error", "")
+		_ = s.store.UpdateRepoStatus(ctx, repo.ID, domain.RepoStatusError, "")
+		repo.Status, repo.LocalPath = domain.RepoStatusError, ""
 		return fmt.Errorf("clone repo: %w", err)
 	}
 
-	_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, "ready", localPath)
-	slog.Info("clone complete", "repo_id", repo.ID)
+	_ = s.store.UpdateRepoStatus(ctx, repo.ID, domain.RepoStatusReady, localPath)
+	repo.Status, repo.LocalPath = domain.RepoStatusReady, localPath
+	s.measure(ctx, repo)
+	_ = s.touch(ctx, repo)
+	slog.Info("clone complete", "repo_id", repo.ID, "bytes", repo.DiskBytes)
+
+	// The new clone may push its owner over the quota
+	if err := s.freeSpace(ctx, repo.UserID, repo.ID, 0); err != nil {
+		slog.Warn("clones over quota", "user_id", repo.UserID, "error", err)
+	}
+	return nil
+}
+
+// clone clones the repo into localPath with its credentials. Leftovers of an earlier
+// attempt are removed first: the directory belongs to this repo alone.
+func (s *RepoService) clone(ctx context.Context, repo *domain.Repo, localPath string) error {
+	auth, err := s.creds.GitAuth(ctx, repo)
+	if err != nil {
+		return err
+	}
+	if err := os.RemoveAll(localPath); err != nil {
+		return fmt.Errorf("remove stale clone: %w", err)
+	}
+	opts := repo.CloneOptions
+	opts.Branch = repo.DefaultBranch
+	if err := s.vcs.Clone(ctx, repo.URL, localPath, &opts, auth); err != nil {
+		return err
+	}
+
+	// The fresh HEAD is the tracked HEAD until the next sync
+	if commits, err := s.vcs.Log(ctx, localPath, 1, auth); err == nil && len(commits) > 0 {
+		if err := s.store.UpdateRepoSync(ctx, repo.ID, commits[0].Hash, ""); err != nil {
+			slog.Warn("record cloned HEAD failed", "repo_id", repo.ID, "error", err)
+		}
+		repo.HeadCommit = commits[0].Hash
+	}
 	return nil
 }
 
-// CloneRepoWithURL clones using a specific URL (e.g. with embedded auth token).
-func (s *RepoService) CloneRepoWithURL(repo *domain.Repo, authURL string) error {
-	localPath := filepath.Join(s.basePath, repo.UserID, repo.Name)
+// maxSparsePaths caps the sparse-checkout directories of a repo.
+const maxSparsePaths = 50
 
-	slog.Info("cloning repository", "repo_id", repo.ID, "name", repo.Name)
-	if err := s.vcs.Clone(context.Background(), authURL, localPath); err != nil {
-		slog.Error("clone failed", "repo_id", repo.ID, "error", err)
-		_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, "error", "")
-		return fmt.Errorf("clone repo: %w", err)
+// PrepareCloneOptions validates clone options and normalizes their sparse paths to
+// clean, unique directories relative to the repository root.
+func (s *RepoService) PrepareCloneOptions(opts *domain.CloneOptions) error {
+	if opts.Depth < 0 {
+		return errors.New("depth must be a non-negative integer")
+	}
+	if len(opts.SparsePaths) > maxSparsePaths {
+		return fmt.Errorf("at most %d sparse paths are allowed", maxSparsePaths)
+	}
+
+	paths := []string{}
+	for _, p := range opts.SparsePaths {
+		p = strings.Trim(strings.TrimSpace(p), "/")
+		if p == "" {
+			continue
+		}
+		p = path.Clean(p)
+		if p == "." || p == ".." || strings.HasPrefix(p, "../") || strings.HasPrefix(p, "-") || strings.ContainsAny(p, "*?[\\\n") {
+			return fmt.Errorf("invalid sparse path %q: use directories relative to the repository root", p)
+		}
+		if !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	opts.SparsePaths = paths
+	return nil
+}
+
+// GitAuth returns the credentials git uses to reach a repo's remote, or nil.
+func (s *RepoService) GitAuth(ctx context.Context, repo *domain.Repo) (*domain.GitAuth, error) {
+	return s.creds.GitAuth(ctx, repo)
+}
+
+// EnsureHistory deepens a shallow clone until it has at least commits commits of
+// history, or its full history. Full clones are left untouched.
+func (s *RepoService) EnsureHistory(ctx context.Context, repo *domain.Repo, commits int) error {
+	if repo.CloneOptions.Depth == 0 || repo.LocalPath == "" {
+		return nil
+	}
+	have, err := s.vcs.CountCommits(ctx, repo.LocalPath)
+	if err != nil {
+		return err
+	}
+	if have >= commits {
+		return nil
 	}
 
-	_ = s.store.UpdateRepoStatus(context.Background(), repo.ID, "ready", localPath)
-	slog.Info("clone complete", "repo_id", repo.ID)
+	auth, err := s.creds.GitAuth(ctx, repo)
+	if err != nil {
+		return err
+	}
+	deepened, err := s.vcs.Deepen(ctx, repo.LocalPath, commits-have, auth)
+	if err != nil {
+		return err
+	}
+	if deepened {
+		slog.Info("deepened shallow clone", "repo_id", repo.ID, "commits", commits-have)
+	}
 	return nil
 }
+
+// ScrubRemotes removes credentials from the origin URL of clones made before tokens were
+// kept out of remote URLs. Failures are logged and retried at the next start.
+func (s *RepoService) ScrubRemotes(ctx context.Context) {
+	repos, err := s.store.ListUnscrubbedRepos(ctx)
+	if err != nil {
+		slog.Error("list repos to scrub failed", "error", err)
+		return
+	}
+	for _, repo := range repos {
+		if repo.LocalPath != "" {
+			if _, err := os.Stat(repo.LocalPath); err == nil {
+				scrubbed, err := s.vcs.ScrubRemote(ctx, repo.LocalPath)
+				if err != nil {
+					slog.Warn("scrub clone remote failed", "repo_id", repo.ID, "error", err)
+					continue
+				}
+				if scrubbed {
+					slog.Info("removed credentials from clone remote", "repo_id", repo.ID)
+				}
+			}
+		}
+		if err := s.store.MarkRepoScrubbed(ctx, repo.ID); err != nil {
+			slog.Error("mark repo scrubbed failed", "repo_id", repo.ID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,376 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// ClonePolicy bounds the disk space taken by clones. Evicted clones keep their repo's
+// snapshots, index and reports, and are cloned again when next used.
+type ClonePolicy struct {
+	QuotaBytes int64         // per user; 0 = unlimited
+	IdleAfter  time.Duration // clones unused for this long are evicted; 0 keeps them
+	Interval   time.Duration // time between janitor rounds; 0 disables the janitor
+}
+
+// evictGrace is how long a clone is safe from quota eviction after its last use, so
+// that the clones of running analyses and index jobs stay on disk.
+const evictGrace = 15 * time.Minute
+
+// maxDirSegment caps the length of the repo name part of a clone directory.
+const maxDirSegment = 64
+
+// cloneDir returns the directory a repo is cloned into. The repo name is reduced to
+// safe characters and suffixed with the repo ID, so that names can neither escape the
+// base path nor collide.
+func (s *RepoService) cloneDir(repo *domain.Repo) string {
+	return filepath.Join(s.basePath, safePathSegment(repo.UserID), safePathSegment(repo.Name)+"-"+shortHash(repo.ID))
+}
+
+// safePathSegment maps s to a single path segment made of ASCII letters, digits, '.',
+// '-' and '_', without leading dots or dashes.
+func safePathSegment(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9', r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteByte('-')
+		}
+	}
+	seg := b.String()
+	if len(seg) > maxDirSegment {
+		seg = seg[:maxDirSegment]
+	}
+	if seg = strings.TrimLeft(seg, ".-"); seg == "" {
+		return "repo"
+	}
+	return seg
+}
+
+// CloneUsage returns the disk space taken by a user's clones and the quota (0 = unlimited).
+func (s *RepoService) CloneUsage(ctx context.Context, userID string) (used, quota int64, err error) {
+	used, err = s.clones.UserCloneUsage(ctx, userID)
+	return used, s.policy.QuotaBytes, err
+}
+
+// CheckCloneQuota makes room for a new clone of the user, evicting idle clones as
+// needed. It returns port.ErrCloneQuota when the quota is still used up.
+func (s *RepoService) CheckCloneQuota(ctx context.Context, userID string) error {
+	return s.freeSpace(ctx, userID, "", 1)
+}
+
+// UseClone records the use of a repo's clone by a request. An evicted clone is cloned
+// again in the background and port.ErrCloneRestoring returned.
+func (s *RepoService) UseClone(ctx context.Context, repo *domain.Repo) error {
+	if repo.Status == domain.RepoStatusEvicted {
+		restore := *repo
+		go func() {
+			if err := s.cloneRepo(context.Background(), &restore); err != nil {
+				slog.Warn("restore evicted clone failed", "repo_id", repo.ID, "error", err)
+			}
+		}()
+		return port.ErrCloneRestoring
+	}
+	return s.touch(ctx, repo)
+}
+
+// EnsureClone records the use of a repo's clone by background work, cloning an
+// evicted clone again first. repo is updated in place.
+func (s *RepoService) EnsureClone(ctx context.Context, repo *domain.Repo) error {
+	if repo.Status == domain.RepoStatusEvicted {
+		return s.cloneRepo(ctx, repo)
+	}
+	return s.touch(ctx, repo)
+}
+
+// touch records the use of a ready clone.
+func (s *RepoService) touch(ctx context.Context, repo *domain.Repo) error {
+	if repo.Status != domain.RepoStatusReady || repo.LocalPath == "" {
+		return port.ErrRepoNotReady
+	}
+	if err := s.clones.TouchRepo(ctx, repo.ID); err != nil {
+		slog.Warn("record clone use failed", "repo_id", repo.ID, "error", err)
+	}
+	return nil
+}
+
+// HoldClone keeps a repo's clone on disk and its working copy in place for a running
+// job until release is called: the clone is neither synced, evicted nor deleted
+// meanwhile. A clone being synced, evicted or deleted returns port.ErrRepoBusy.
+func (s *RepoService) HoldClone(repoID string) (release func(), err error) {
+	s.holdMu.Lock()
+	defer s.holdMu.Unlock()
+	if s.exclusive[repoID] {
+		return nil, port.ErrRepoBusy
+	}
+	if s.holds == nil {
+		s.holds = map[string]int{}
+	}
+	s.holds[repoID]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.holdMu.Lock()
+			defer s.holdMu.Unlock()
+			if s.holds[repoID]--; s.holds[repoID] <= 0 {
+				delete(s.holds, repoID)
+			}
+		})
+	}, nil
+}
+
+// lockRemoval reserves a repo for the removal of its clone. A repo being cloned,
+// synced, evicted or deleted, or whose clone is held by a job, returns
+// port.ErrRepoBusy; otherwise unlock must be called once the removal is done.
+func (s *RepoService) lockRemoval(repoID string) (unlock func(), err error) {
+	if _, busy := s.syncing.LoadOrStore(repoID, struct{}{}); busy {
+		return nil, port.ErrRepoBusy
+	}
+	release, err := s.excludeHolds(repoID)
+	if err != nil {
+		s.syncing.Delete(repoID)
+		return nil, err
+	}
+	return func() {
+		release()
+		s.syncing.Delete(repoID)
+	}, nil
+}
+
+// excludeHolds refuses new holds on a repo's clone until release is called. A clone
+// already held by a job returns port.ErrRepoBusy.
+func (s *RepoService) excludeHolds(repoID string) (release func(), err error) {
+	s.holdMu.Lock()
+	defer s.holdMu.Unlock()
+	if s.holds[repoID] > 0 {
+		return nil, port.ErrRepoBusy
+	}
+	if s.exclusive == nil {
+		s.exclusive = map[string]bool{}
+	}
+	s.exclusive[repoID] = true
+
+	return func() {
+		s.holdMu.Lock()
+		delete(s.exclusive, repoID)
+		s.holdMu.Unlock()
+	}, nil
+}
+
+// DeleteRepo deletes a repo with everything recorded about it, then its clone.
+// A repo being cloned, synced or evicted, or used by a running job, returns
+// port.ErrRepoBusy.
+func (s *RepoService) DeleteRepo(ctx context.Context, repo *domain.Repo) error {
+	unlock, err := s.lockRemoval(repo.ID)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	if err := s.clones.DeleteRepo(ctx, repo.ID); err != nil {
+		return err
+	}
+	if err := s.removeClone(ctx, repo); err != nil {
+		slog.Warn("remove clone of deleted repo failed", "repo_id", repo.ID, "path", repo.LocalPath, "error", err)
+	}
+	slog.Info("repo deleted", "repo_id", repo.ID, "name", repo.Name)
+	return nil
+}
+
+// evict removes an idle clone and marks its repo evicted. It reports false when the
+// repo is no longer ready, and returns port.ErrRepoBusy when it is busy or used by a
+// running job.
+func (s *RepoService) evict(ctx context.Context, repo *domain.Repo) (bool, error) {
+	unlock, err := s.lockRemoval(repo.ID)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	evicted, err := s.clones.EvictRepo(ctx, repo.ID)
+	if err != nil || !evicted {
+		return false, err
+	}
+	if err := s.removeClone(ctx, repo); err != nil {
+		slog.Warn("remove evicted clone failed", "repo_id", repo.ID, "path", repo.LocalPath, "error", err)
+	}
+	slog.Info("evicted idle clone", "repo_id", repo.ID, "bytes", repo.DiskBytes, "last_used_at", repo.LastUsedAt)
+	return true, nil
+}
+
+// freeSpace evicts the user's idle clones, least recently used first, until need more
+// bytes fit in the quota. keepID (optional) is never evicted. It returns
+// port.ErrCloneQuota when not enough could be evicted.
+func (s *RepoService) freeSpace(ctx context.Context, userID, keepID string, need int64) error {
+	quota := s.policy.QuotaBytes
+	if quota <= 0 {
+		return nil
+	}
+	used, err := s.clones.UserCloneUsage(ctx, userID)
+	if err != nil || used+need <= quota {
+		return err
+	}
+
+	idle, err := s.clones.ListIdleRepos(ctx, userID, time.Now().Add(-evictGrace))
+	if err != nil {
+		return err
+	}
+	for i := range idle {
+		if used+need <= quota {
+			break
+		}
+		if idle[i].ID == keepID {
+			continue
+		}
+		evicted, err := s.evict(ctx, &idle[i])
+		if err != nil && !errors.Is(err, port.ErrRepoBusy) {
+			return err
+		}
+		if evicted {
+			used -= idle[i].DiskBytes
+		}
+	}
+	if used+need > quota {
+		return port.ErrCloneQuota
+	}
+	return nil
+}
+
+// removeClone deletes a repo's clone directory. Paths outside the base path, and
+// paths still used by another repo, are left alone.
+func (s *RepoService) removeClone(ctx context.Context, repo *domain.Repo) error {
+	if repo.LocalPath == "" {
+		return nil
+	}
+	rel, err := filepath.Rel(s.basePath, repo.LocalPath)
+	if err != nil || rel == "." || !filepath.IsLocal(rel) {
+		return fmt.Errorf("refusing to remove %s: not a clone under %s", repo.LocalPath, s.basePath)
+	}
+	shared, err := s.clones.RepoPathShared(ctx, repo.LocalPath, repo.ID)
+	if err != nil || shared {
+		return err
+	}
+	if err := os.RemoveAll(repo.LocalPath); err != nil {
+		return fmt.Errorf("remove clone: %w", err)
+	}
+	return nil
+}
+
+// measure records the disk space taken by a repo's clone.
+func (s *RepoService) measure(ctx context.Context, repo *domain.Repo) {
+	size, err := dirSize(repo.LocalPath)
+	if err != nil {
+		slog.Warn("measure clone failed", "repo_id", repo.ID, "error", err)
+		return
+	}
+	if err := s.clones.SetRepoDiskUsage(ctx, repo.ID, size); err != nil {
+		slog.Warn("record clone size failed", "repo_id", repo.ID, "error", err)
+		return
+	}
+	repo.DiskBytes = size
+}
+
+// MeasureClones records the size of the ready clones never measured, such as those
+// made before clone sizes were recorded, so that they count against quotas.
+func (s *RepoService) MeasureClones(ctx context.Context) error {
+	repos, err := s.clones.ListUnmeasuredClones(ctx)
+	if err != nil {
+		return err
+	}
+	for i := range repos {
+		s.measure(ctx, &repos[i])
+	}
+	if len(repos) > 0 {
+		slog.Info("measured clones", "count", len(repos))
+	}
+	return nil
+}
+
+// dirSize returns the total size of the regular files under dir.
+func dirSize(dir string) (int64, error) {
+	var size int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil // removed while walking
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return nil
+			}
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// CollectClones evicts the clones idle for longer than the policy allows, then the
+// least recently used clones of users over their quota.
+func (s *RepoService) CollectClones(ctx context.Context) error {
+	if s.policy.IdleAfter > 0 {
+		idle, err := s.clones.ListIdleRepos(ctx, "", time.Now().Add(-s.policy.IdleAfter))
+		if err != nil {
+			return err
+		}
+		for i := range idle {
+			if _, err := s.evict(ctx, &idle[i]); err != nil && !errors.Is(err, port.ErrRepoBusy) {
+				return err
+			}
+		}
+	}
+
+	if s.policy.QuotaBytes > 0 {
+		users, err := s.clones.ListUsersOverCloneQuota(ctx, s.policy.QuotaBytes)
+		if err != nil {
+			return err
+		}
+		for _, userID := range users {
+			if err := s.freeSpace(ctx, userID, "", 0); err != nil {
+				slog.Warn("clones over quota", "user_id", userID, "error", err)
+			}
+		}
+	}
+	return nil
+}
+
+// RunCloneJanitor measures the clones never measured, then collects clones every
+// policy interval until ctx is cancelled.
+func (s *RepoService) RunCloneJanitor(ctx context.Context) {
+	if err := s.MeasureClones(ctx); err != nil {
+		slog.Error("clone measurement failed", "error", err)
+	}
+	if s.policy.Interval <= 0 || (s.policy.IdleAfter <= 0 && s.policy.QuotaBytes <= 0) {
+		return
+	}
+	ticker := time.NewTicker(s.policy.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.CollectClones(ctx); err != nil {
+			slog.Error("clone collection failed", "error", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// fakeCloneStore keeps repos in memory for the clone storage tests.
+type fakeCloneStore struct {
+	repos   map[string]*domain.Repo
+	evicted []string
+	deleted []string
+}
+
+func (f *fakeCloneStore) DeleteRepo(_ context.Context, id string) error {
+	delete(f.repos, id)
+	f.deleted = append(f.deleted, id)
+	return nil
+}
+
+func (f *fakeCloneStore) TouchRepo(_ context.Context, id string) error {
+	f.repos[id].LastUsedAt = time.Now()
+	return nil
+}
+
+func (f *fakeCloneStore) SetRepoDiskUsage(_ context.Context, id string, bytes int64) error {
+	f.repos[id].DiskBytes = bytes
+	return nil
+}
+
+func (f *fakeCloneStore) EvictRepo(_ context.Context, id string) (bool, error) {
+	repo := f.repos[id]
+	if repo == nil || repo.Status != domain.RepoStatusReady {
+		return false, nil
+	}
+	repo.Status, repo.LocalPath = domain.RepoStatusEvicted, ""
+	f.evicted = append(f.evicted, id)
+	return true, nil
+}
+
+func (f *fakeCloneStore) RepoPathShared(_ context.Context, localPath, id string) (bool, error) {
+	for _, r := range f.repos {
+		if r.ID != id && r.LocalPath == localPath {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (f *fakeCloneStore) UserCloneUsage(_ context.Context, userID string) (int64, error) {
+	var used int64
+	for _, r := range f.repos {
+		if r.UserID == userID && r.Status == domain.RepoStatusReady {
+			used += r.DiskBytes
+		}
+	}
+	return used, nil
+}
+
+func (f *fakeCloneStore) ListUsersOverCloneQuota(ctx context.Context, quota int64) ([]string, error) {
+	var users []string
+	for _, r := range f.repos {
+		if used, _ := f.UserCloneUsage(ctx, r.UserID); used > quota && !slices.Contains(users, r.UserID) {
+			users = append(users, r.UserID)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeCloneStore) ListIdleRepos(_ context.Context, userID string, before time.Time) ([]domain.Repo, error) {
+	var idle []domain.Repo
+	for _, r := range f.repos {
+		if r.Status == domain.RepoStatusReady && r.LastUsedAt.Before(before) && (userID == "" || r.UserID == userID) {
+			idle = append(idle, *r)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool { return idle[i].LastUsedAt.Before(idle[j].LastUsedAt) })
+	return idle, nil
+}
+
+func (f *fakeCloneStore) ListUnmeasuredClones(context.Context) ([]domain.Repo, error) {
+	var repos []domain.Repo
+	for _, r := range f.repos {
+		if r.Status == domain.RepoStatusReady && r.DiskBytes == 0 && r.LocalPath != "" {
+			repos = append(repos, *r)
+		}
+	}
+	return repos, nil
+}
+
+var _ port.CloneStore = (*fakeCloneStore)(nil)
+
+// testClone is a ready clone of a user, of size bytes, last used hours ago.
+type testClone struct {
+	user  string
+	size  int64
+	hours int
+}
+
+// newStorageFixture returns a repo service over an in-memory store holding one ready
+// clone per entry, keyed by repo ID.
+func newStorageFixture(t *testing.T, quota int64, clones map[string]testClone) (*RepoService, *fakeCloneStore) {
+	t.Helper()
+	base := t.TempDir()
+	store := &fakeCloneStore{repos: map[string]*domain.Repo{}}
+	for id, c := range clones {
+		dir := filepath.Join(base, c.user, id)
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "file"), make([]byte, c.size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		store.repos[id] = &domain.Repo{
+			ID:         id,
+			UserID:     c.user,
+			Status:     domain.RepoStatusReady,
+			LocalPath:  dir,
+			DiskBytes:  c.size,
+			LastUsedAt: time.Now().Add(-time.Duration(c.hours) * time.Hour),
+		}
+	}
+	return &RepoService{clones: store, basePath: base, policy: ClonePolicy{QuotaBytes: quota}}, store
+}
+
+func TestFreeSpaceEvictsLeastRecentlyUsed(t *testing.T) {
+	s, store := newStorageFixture(t, 100, map[string]testClone{
+		"old":    {"u1", 40, 48},
+		"older":  {"u1", 30, 72},
+		"recent": {"u1", 30, 24},
+		"fresh":  {"u1", 10, 0}, // within evictGrace
+		"other":  {"u2", 90, 96},
+	})
+
+	// 110 used: 60 more bytes need the two least recently used clones of u1 gone
+	if err := s.freeSpace(context.Background(), "u1", "", 60); err != nil {
+		t.Fatalf("freeSpace: %v", err)
+	}
+	if want := []string{"older", "old"}; !slices.Equal(store.evicted, want) {
+		t.Fatalf("evicted %v, want %v", store.evicted, want)
+	}
+	for _, id := range []string{"older", "old"} {
+		if _, err := os.Stat(filepath.Join(s.basePath, "u1", id)); !os.IsNotExist(err) {
+			t.Errorf("clone %s still on disk", id)
+		}
+	}
+	if store.repos["other"].Status != domain.RepoStatusReady {
+		t.Error("clone of another user evicted")
+	}
+}
+
+func TestFreeSpaceSparesKeptAndHeldClones(t *testing.T) {
+	s, store := newStorageFixture(t, 100, map[string]testClone{
+		"kept": {"u1", 50, 72},
+		"held": {"u1", 50, 48},
+		"idle": {"u1", 20, 24},
+	})
+	release, err := s.HoldClone("held")
+	if err != nil {
+		t.Fatalf("HoldClone: %v", err)
+	}
+
+	err = s.freeSpace(context.Background(), "u1", "kept", 50)
+	if !errors.Is(err, port.ErrCloneQuota) {
+		t.Fatalf("freeSpace = %v, want ErrCloneQuota", err)
+	}
+	if want := []string{"idle"}; !slices.Equal(store.evicted, want) {
+		t.Fatalf("evicted %v, want %v", store.evicted, want)
+	}
+
+	// Once released, the clone can go
+	release()
+	release() // releasing twice is harmless
+	if err := s.freeSpace(context.Background(), "u1", "kept", 50); err != nil {
+		t.Fatalf("freeSpace after release: %v", err)
+	}
+	if want := []string{"idle", "held"}; !slices.Equal(store.evicted, want) {
+		t.Fatalf("evicted %v, want %v", store.evicted, want)
+	}
+}
+
+func TestFreeSpaceUnlimited(t *testing.T) {
+	s, store := newStorageFixture(t, 0, map[string]testClone{"a": {"u1", 500, 72}})
+	if err := s.freeSpace(context.Background(), "u1", "", 1000); err != nil {
+		t.Fatalf("freeSpace: %v", err)
+	}
+	if len(store.evicted) != 0 {
+		t.Fatalf("evicted %v without a quota", store.evicted)
+	}
+}
+
+func TestCollectClonesEvictsIdleAndOverQuota(t *testing.T) {
+	s, store := newStorageFixture(t, 50, map[string]testClone{
+		"stale": {"u1", 10, 24 * 30},
+		"big1":  {"u2", 40, 48},
+		"big2":  {"u2", 40, 24},
+		"small": {"u3", 10, 24},
+	})
+	s.policy.IdleAfter = 7 * 24 * time.Hour
+
+	if err := s.CollectClones(context.Background()); err != nil {
+		t.Fatalf("CollectClones: %v", err)
+	}
+	if want := []string{"stale", "big1"}; !slices.Equal(store.evicted, want) {
+		t.Fatalf("evicted %v, want %v", store.evicted, want)
+	}
+}
+
+func TestDeleteRepoBusyWhileHeld(t *testing.T) {
+	s, store := newStorageFixture(t, 0, map[string]testClone{"r": {"u1", 10, 1}})
+	repo := *store.repos["r"]
+
+	release, err := s.HoldClone("r")
+	if err != nil {
+		t.Fatalf("HoldClone: %v", err)
+	}
+	if err := s.DeleteRepo(context.Background(), &repo); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("DeleteRepo while held = %v, want ErrRepoBusy", err)
+	}
+	if _, err := s.evict(context.Background(), &repo); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("evict while held = %v, want ErrRepoBusy", err)
+	}
+	release()
+
+	if err := s.DeleteRepo(context.Background(), &repo); err != nil {
+		t.Fatalf("DeleteRepo: %v", err)
+	}
+	if _, err := os.Stat(repo.LocalPath); !os.IsNotExist(err) {
+		t.Error("clone of deleted repo still on disk")
+	}
+	if _, err := s.HoldClone("r"); err != nil {
+		t.Errorf("HoldClone after removal: %v", err)
+	}
+}
+
+func TestHoldCloneBusyWhileRemoving(t *testing.T) {
+	s := &RepoService{}
+	unlock, err := s.lockRemoval("r")
+	if err != nil {
+		t.Fatalf("lockRemoval: %v", err)
+	}
+	if _, err := s.HoldClone("r"); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("HoldClone while removing = %v, want ErrRepoBusy", err)
+	}
+	if _, err := s.lockRemoval("r"); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("second lockRemoval = %v, want ErrRepoBusy", err)
+	}
+	unlock()
+	if _, err := s.HoldClone("r"); err != nil {
+		t.Fatalf("HoldClone after removal: %v", err)
+	}
+}
+
+func TestSyncBusyWhileHeld(t *testing.T) {
+	s := &RepoService{}
+	repo := &domain.Repo{ID: "r", Status: domain.RepoStatusReady, LocalPath: t.TempDir()}
+
+	release, err := s.HoldClone("r")
+	if err != nil {
+		t.Fatalf("HoldClone: %v", err)
+	}
+	if _, err := s.Sync(context.Background(), repo); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("Sync while held = %v, want ErrRepoBusy", err)
+	}
+	release()
+
+	// A sync in progress keeps jobs off the working copy it moves
+	unhold, err := s.excludeHolds("r")
+	if err != nil {
+		t.Fatalf("excludeHolds: %v", err)
+	}
+	if _, err := s.HoldClone("r"); !errors.Is(err, port.ErrRepoBusy) {
+		t.Fatalf("HoldClone while syncing = %v, want ErrRepoBusy", err)
+	}
+	unhold()
+	if _, err := s.HoldClone("r"); err != nil {
+		t.Fatalf("HoldClone after sync: %v", err)
+	}
+}
+
+func TestMeasureClones(t *testing.T) {
+	s, store := newStorageFixture(t, 0, map[string]testClone{
+		"premigration": {"u1", 123, 1},
+		"measured":     {"u1", 10, 1},
+	})
+	store.repos["premigration"].DiskBytes = 0
+
+	if err := s.MeasureClones(context.Background()); err != nil {
+		t.Fatalf("MeasureClones: %v", err)
+	}
+	if got := store.repos["premigration"].DiskBytes; got != 123 {
+		t.Errorf("premigration clone measured %d bytes, want 123", got)
+	}
+	if got := store.repos["measured"].DiskBytes; got != 10 {
+		t.Errorf("measured clone changed to %d bytes", got)
+	}
+}
+
+func TestRemoveCloneRefusesPathsOutsideBase(t *testing.T) {
+	s, store := newStorageFixture(t, 0, nil)
+	outside := t.TempDir()
+	store.repos["r"] = &domain.Repo{ID: "r", LocalPath: outside}
+
+	if err := s.removeClone(context.Background(), store.repos["r"]); err == nil {
+		t.Fatal("removeClone accepted a path outside the base path")
+	}
+	if _, err := os.Stat(outside); err != nil {
+		t.Fatalf("directory outside the base path removed: %v", err)
+	}
+}
+
+func TestSafePathSegment(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"my-repo", "my-repo"},
+		{"My_Repo.v2", "My_Repo.v2"},
+		{"../../etc", "etc"},
+		{"a/b", "a-b"},
+		{"..", "repo"},
+		{"", "repo"},
+		{"-rf", "rf"},
+		{"ñandú", "and-"},
+		{"name with spaces", "name-with-spaces"},
+		{strings.Repeat("x", 100), strings.Repeat("x", maxDirSegment)},
+	}
+	for _, tt := range tests {
+		if got := safePathSegment(tt.in); got != tt.want {
+			t.Errorf("safePathSegment(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCloneDirStaysUnderBase(t *testing.T) {
+	s := &RepoService{basePath: "/data/repos"}
+	a := s.cloneDir(&domain.Repo{ID: "11111111-aaaa", UserID: "../../root", Name: "../../etc"})
+	b := s.cloneDir(&domain.Repo{ID: "22222222-bbbb", UserID: "../../root", Name: "../../etc"})
+
+	if a == b {
+		t.Fatalf("repos with the same name share clone directory %s", a)
+	}
+	for _, dir := range []string{a, b} {
+		rel, err := filepath.Rel(s.basePath, dir)
+		if err != nil || !filepath.IsLocal(rel) || strings.Count(rel, string(filepath.Separator)) != 1 {
+			t.Errorf("clone directory %s escapes %s", dir, s.basePath)
+		}
+	}
+}
+
+func TestPrepareCloneOptions(t *testing.T) {
+	s := &RepoService{}
+
+	opts := &domain.CloneOptions{Depth: 10, SparsePaths: []string{" /src/ ", "./src", "docs//api", "", "a/../b"}}
+	if err := s.PrepareCloneOptions(opts); err != nil {
+		t.Fatalf("PrepareCloneOptions: %v", err)
+	}
+	if want := []string{"src", "docs/api", "b"}; !slices.Equal(opts.SparsePaths, want) {
+		t.Errorf("sparse paths = %q, want %q", opts.SparsePaths, want)
+	}
+
+	invalid := []domain.CloneOptions{
+		{Depth: -1},
+		{SparsePaths: []string{".."}},
+		{SparsePaths: []string{"a/../../b"}},
+		{SparsePaths: []string{"-x"}},
+		{SparsePaths: []string{"src/*.go"}},
+		{SparsePaths: []string{"."}},
+		{SparsePaths: make([]string, maxSparsePaths+1)},
+	}
+	for _, o := range invalid {
+		if err := s.PrepareCloneOptions(&o); err == nil {
+			t.Errorf("PrepareCloneOptions(%+v) accepted invalid options", o)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// SyncResult describes the outcome of syncing a repository with its remote.
+type SyncResult struct {
+	RepoID   string            `json:"repo_id"`
+	Branch   string            `json:"branch"`
+	Previous string            `json:"previous_commit"` // HEAD before the sync
+	Head     domain.CommitInfo `json:"head"`
+	Snapshot *domain.Snapshot  `json:"snapshot"` // snapshot recorded for the new HEAD
+	Updated  bool              `json:"updated"`  // HEAD moved
+}
+
+// SyncFunc is notified after every sync attempt; err is set when the sync failed.
+type SyncFunc func(repo *domain.Repo, result *SyncResult, err error)
+
+// Sync fetches the repo's tracked branch, moves the working copy to it and records
+// the new HEAD as a pending snapshot. Only one sync per repo runs at a time;
+// a concurrent call returns port.ErrSyncInProgress. The working copy is not moved under
+// running jobs that read it: a repo whose clone is held returns port.ErrRepoBusy.
+func (s *RepoService) Sync(ctx context.Context, repo *domain.Repo) (*SyncResult, error) {
+	if repo.Status != domain.RepoStatusReady || repo.LocalPath == "" {
+		return nil, port.ErrRepoNotReady
+	}
+	if _, busy := s.syncing.LoadOrStore(repo.ID, struct{}{}); busy {
+		return nil, port.ErrSyncInProgress
+	}
+	defer s.syncing.Delete(repo.ID)
+	release, err := s.excludeHolds(repo.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	result, err := s.sync(ctx, repo)
+	head, syncErr := "", ""
+	if err != nil {
+		syncErr = err.Error()
+	} else {
+		head = result.Head.Hash
+	}
+	if updErr := s.store.UpdateRepoSync(ctx, repo.ID, head, syncErr); updErr != nil {
+		slog.Error("record repo sync failed", "repo_id", repo.ID, "error", updErr)
+	}
+	return result, err
+}
+
+// sync pulls the tracked branch and snapshots the resulting HEAD.
+func (s *RepoService) sync(ctx context.Context, repo *domain.Repo) (*SyncResult, error) {
+	result := &SyncResult{RepoID: repo.ID, Branch: repo.DefaultBranch}
+	auth, err := s.creds.GitAuth(ctx, repo)
+	if err != nil {
+		return nil, err
+	}
+	if before, err := s.vcs.Log(ctx, repo.LocalPath, 1, auth); err == nil && len(before) > 0 {
+		result.Previous = before[0].Hash
+	}
+
+	if err := s.vcs.Pull(ctx, repo.LocalPath, repo.DefaultBranch, auth); err != nil {
+		return nil, fmt.Errorf("pull: %w", err)
+	}
+
+	after, err := s.vcs.Log(ctx, repo.LocalPath, 1, auth)
+	if err != nil {
+		return nil, fmt.Errorf("read HEAD: %w", err)
+	}
+	if len(after) == 0 {
+		return nil, fmt.Errorf("read HEAD: branch %q has no commits", repo.DefaultBranch)
+	}
+	result.Head = after[0]
+	result.Updated = result.Head.Hash != result.Previous
+	if result.Updated {
+		s.measure(ctx, repo)
+	}
+
+	// One snapshot per commit: an unchanged HEAD returns the existing record
+	result.Snapshot, err = s.store.CreateSnapshot(ctx, &domain.Snapshot{
+		RepoID:     repo.ID,
+		CommitHash: result.Head.Hash,
+		Branch:     repo.DefaultBranch,
+		Message:    result.Head.Message,
+		Author:     result.Head.Author,
+		Status:     domain.SnapshotStatusPending,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("repo synced", "repo_id", repo.ID, "branch", repo.DefaultBranch,
+		"from", shortHash(result.Previous), "to", shortHash(result.Head.Hash), "updated", result.Updated)
+	return result, nil
+}
+
+// RunSyncer syncs every repo whose sync interval has elapsed, checking every
+// checkInterval until ctx is cancelled. Repos are synced one at a time so that
+// a burst of due repos does not hammer the remotes; onSync (optional) is notified
+// after each attempt.
+func (s *RepoService) RunSyncer(ctx context.Context, checkInterval time.Duration, onSync SyncFunc) {
+	if checkInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		repos, err := s.store.ListReposDueForSync(ctx)
+		if err != nil {
+			slog.Error("list repos due for sync failed", "error", err)
+			continue
+		}
+		for i := range repos {
+			if ctx.Err() != nil {
+				return
+			}
+			repo := &repos[i]
+			result, err := s.Sync(ctx, repo)
+			if errors.Is(err, port.ErrSyncInProgress) || errors.Is(err, port.ErrRepoBusy) {
+				continue // retried on the next tick
+			}
+			if err != nil {
+				slog.Warn("scheduled repo sync failed", "repo_id", repo.ID, "error", err)
+			}
+			if onSync != nil {
+				onSync(repo, result, err)
+			}
+		}
+	}
+}
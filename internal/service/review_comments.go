@@ -0,0 +1,230 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// Comments posted to pull requests carry a hidden marker naming what they are about,
+// so that posting again updates them instead of adding new ones.
+const (
+	summaryMarker  = "<!-- codelens:summary -->"
+	resolvedNotice = "No longer reported"
+
+	// maxSummaryFindings caps the findings listed in the summary comment.
+	maxSummaryFindings = 50
+)
+
+var commentMarker = regexp.MustCompile(`<!-- codelens:(summary|finding:[0-9a-f]+) -->`)
+
+var severityIcons = map[string]string{
+	domain.SeverityCritical: "🔴",
+	domain.SeverityHigh:     "🟠",
+	domain.SeverityMedium:   "🟡",
+	domain.SeverityLow:      "🔵",
+	domain.SeverityInfo:     "⚪",
+}
+
+// PostComments publishes a completed review to its pull request as a summary comment
+// plus an inline comment per finding on a changed line, using the forge token of a
+// repo owner who logged in with the pull request's forge. Comments from earlier posts
+// are updated in place, and inline comments of findings that are no longer reported
+// are marked as such. The outcome is recorded on the review.
+func (s *ReviewService) PostComments(ctx context.Context, repo *domain.Repo, review *domain.Review) error {
+	err := s.postComments(ctx, repo, review)
+	postErr := ""
+	if err != nil {
+		postErr = err.Error()
+	}
+	if recErr := s.store.RecordReviewComments(ctx, review.ID, postErr); recErr != nil {
+		slog.Error("record review comments failed", "review_id", review.ID, "error", recErr)
+	}
+	return err
+}
+
+func (s *ReviewService) postComments(ctx context.Context, repo *domain.Repo, review *domain.Review) error {
+	if review.Status != domain.ReviewStatusComplete {
+		return fmt.Errorf("review is %s, not complete", review.Status)
+	}
+	client, ok := s.forges[review.Provider]
+	if !ok {
+		return fmt.Errorf("posting comments to %s is not supported", review.Provider)
+	}
+	token, err := s.creds.ForgeToken(ctx, repo.UserID, review.Provider, repo.URL)
+	if err != nil {
+		return err
+	}
+	findings, err := s.store.ListFindings(ctx, review.ID)
+	if err != nil {
+		return err
+	}
+	pr := &domain.ForgePullRequest{RepoURL: repo.URL, Number: review.Number, BaseSHA: review.BaseSHA, HeadSHA: review.HeadSHA}
+	return publishReview(ctx, client, token, pr, review, findings)
+}
+
+// publishReview posts the comments of a review to its pull request with token. Only
+// comments written by the token's user are taken as earlier posts: markers in anybody
+// else's comments are ignored, so they cannot hide or take over CodeLens comments.
+func publishReview(ctx context.Context, client port.ForgeClient, token string, pr *domain.ForgePullRequest, review *domain.Review, findings []domain.Finding) error {
+	login, err := client.CurrentUser(ctx, token, pr.RepoURL)
+	if err != nil {
+		return fmt.Errorf("identify forge user: %w", err)
+	}
+	if login == "" {
+		return fmt.Errorf("identify forge user: empty login")
+	}
+	existing, err := client.Comments(ctx, token, pr)
+	if err != nil {
+		return fmt.Errorf("list comments: %w", err)
+	}
+	posted := map[string]domain.ForgeComment{}
+	for _, c := range existing {
+		if !strings.EqualFold(c.Author, login) {
+			continue
+		}
+		if m := commentMarker.FindString(c.Body); m != "" {
+			if _, dup := posted[m]; !dup {
+				posted[m] = c
+			}
+		}
+	}
+
+	upsert := func(c domain.ForgeComment) error {
+		marker := commentMarker.FindString(c.Body)
+		if old, ok := posted[marker]; ok {
+			delete(posted, marker)
+			if old.Body == c.Body {
+				return nil
+			}
+			old.Body = c.Body
+			return client.UpdateComment(ctx, token, pr, &old)
+		}
+		return client.CreateComment(ctx, token, pr, &c)
+	}
+
+	// Inline comments first, so that the summary can list the ones the forge rejected
+	var notInline []domain.Finding
+	for _, f := range findings {
+		if f.Line <= 0 {
+			notInline = append(notInline, f)
+			continue
+		}
+		c := domain.ForgeComment{Path: f.FilePath, Line: f.Line, Body: findingComment(f, findingKey(f, findings))}
+		if err := upsert(c); err != nil {
+			slog.Warn("inline review comment failed", "review_id", review.ID, "path", f.FilePath, "line", f.Line, "error", err)
+			notInline = append(notInline, f)
+		}
+	}
+
+	// Whatever is left from earlier posts was not reported this time
+	for marker, c := range posted {
+		if marker == summaryMarker || strings.Contains(c.Body, resolvedNotice) {
+			continue
+		}
+		c.Body = resolvedComment(marker, c.Body, review.HeadSHA)
+		if err := client.UpdateComment(ctx, token, pr, &c); err != nil {
+			slog.Warn("resolve review comment failed", "review_id", review.ID, "path", c.Path, "error", err)
+		}
+	}
+
+	if err := upsert(domain.ForgeComment{Body: summaryComment(review, findings, notInline)}); err != nil {
+		return fmt.Errorf("post summary comment: %w", err)
+	}
+	slog.Info("review comments posted", "review_id", review.ID, "number", review.Number,
+		"inline", len(findings)-len(notInline), "summary_only", len(notInline))
+	return nil
+}
+
+// findingKey identifies a finding across revisions of a pull request by file and
+// title, numbering repeats so that two findings never share a comment. Lines are left
+// out: they move as the pull request changes.
+func findingKey(f domain.Finding, all []domain.Finding) string {
+	n := 0
+	for _, other := range all {
+		if other.ID == f.ID {
+			break
+		}
+		if other.FilePath == f.FilePath && other.Title == f.Title {
+			n++
+		}
+	}
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s\x00%s\x00%d", f.FilePath, f.Title, n))
+	return hex.EncodeToString(sum[:8])
+}
+
+// findingComment renders the inline comment of a finding.
+func findingComment(f domain.Finding, key string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "<!-- codelens:finding:%s -->\n", key)
+	fmt.Fprintf(&b, "%s **%s: %s**\n", severityIcons[f.Severity], f.Severity, f.Title)
+	if f.Body != "" {
+		b.WriteString("\n" + f.Body + "\n")
+	}
+	return b.String()
+}
+
+// resolvedComment rewrites the inline comment of a finding that is no longer reported,
+// keeping the original text folded away.
+func resolvedComment(marker, body, headSHA string) string {
+	old := strings.TrimSpace(strings.Replace(body, marker, "", 1))
+	return fmt.Sprintf("%s\n✅ %s as of `%s`.\n\n<details><summary>Original comment</summary>\n\n%s\n</details>\n",
+		marker, resolvedNotice, shortHash(headSHA), old)
+}
+
+// summaryComment renders the summary comment of a review: overall assessment, counts
+// per severity and a table of the findings, flagging those without an inline comment.
+func summaryComment(review *domain.Review, findings, notInline []domain.Finding) string {
+	var b strings.Builder
+	b.WriteString(summaryMarker + "\n")
+	b.WriteString("## CodeLens review\n\n")
+
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	var parts []string
+	for _, sev := range domain.Severities {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	fmt.Fprintf(&b, "Reviewed `%s` against `%s`: ", shortHash(review.HeadSHA), shortHash(review.BaseSHA))
+	if len(findings) == 0 {
+		b.WriteString("no findings.\n")
+	} else {
+		fmt.Fprintf(&b, "**%d findings** (%s).\n", len(findings), strings.Join(parts, ", "))
+	}
+	if review.Summary != "" {
+		b.WriteString("\n" + review.Summary + "\n")
+	}
+	if len(findings) == 0 {
+		return b.String()
+	}
+
+	summaryOnly := map[string]bool{}
+	for _, f := range notInline {
+		summaryOnly[f.ID] = true
+	}
+	b.WriteString("\n| Severity | Finding | Location |\n|---|---|---|\n")
+	for i, f := range findings {
+		if i == maxSummaryFindings {
+			fmt.Fprintf(&b, "\n…and %d more.\n", len(findings)-i)
+			break
+		}
+		loc := "`" + findingLocation(&f) + "`"
+		title := strings.ReplaceAll(f.Title, "|", "\\|")
+		if summaryOnly[f.ID] && f.Body != "" {
+			title += "<br>" + strings.ReplaceAll(strings.ReplaceAll(truncateRunes(f.Body, 300), "|", "\\|"), "\n", " ")
+		}
+		fmt.Fprintf(&b, "| %s %s | %s | %s |\n", severityIcons[f.Severity], f.Severity, title, loc)
+	}
+	return b.String()
+}
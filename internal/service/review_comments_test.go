@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/forge"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// gitlabMR serves the recorded discussions of merge request acme/app!7 from
+// testdata/gitlab_mr and records the comments posted and edited. The server stands in
+// for the configured GitLab instance.
+type gitlabMR struct {
+	mu     sync.Mutex
+	writes map[string]string // "METHOD path" -> comment body
+}
+
+func (g *gitlabMR) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	fixtures := map[string]string{
+		"/api/v4/user": "user.json",
+		"/api/v4/projects/acme/app/merge_requests/7":             "merge_request.json",
+		"/api/v4/projects/acme/app/merge_requests/7/discussions": "discussions.json",
+	}
+	if r.Method == http.MethodGet {
+		data, err := os.ReadFile(filepath.Join("testdata", "gitlab_mr", fixtures[r.URL.Path]))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+		return
+	}
+
+	var in struct {
+		Body string `json:"body"`
+	}
+	data, _ := io.ReadAll(r.Body)
+	_ = json.Unmarshal(data, &in)
+	g.mu.Lock()
+	g.writes[r.Method+" "+r.URL.Path] = in.Body
+	g.mu.Unlock()
+	w.WriteHeader(http.StatusCreated)
+	_, _ = w.Write([]byte("{}"))
+}
+
+func TestPublishReviewUpdatesOnlyOwnComments(t *testing.T) {
+	mr := &gitlabMR{writes: map[string]string{}}
+	srv := httptest.NewServer(mr)
+	defer srv.Close()
+
+	review := &domain.Review{ID: "rev-1", Provider: "gitlab", Number: 7, BaseSHA: "0000000aaaa", HeadSHA: "2222222bbbb", Status: domain.ReviewStatusComplete}
+	findings := []domain.Finding{
+		{ID: "f1", FilePath: "main.go", Line: 10, Severity: domain.SeverityHigh, Title: "SQL injection", Body: "Query built with fmt.Sprintf."},
+	}
+	target := &domain.ForgePullRequest{RepoURL: srv.URL + "/acme/app", Number: 7, BaseSHA: review.BaseSHA, HeadSHA: review.HeadSHA}
+	if err := publishReview(context.Background(), forge.NewGitLabClient(srv.URL), "gl-token", target, review, findings); err != nil {
+		t.Fatalf("publishReview: %v", err)
+	}
+
+	api := "/api/v4/projects/acme/app/merge_requests/7"
+	// mallory's comment 201 carries the finding's marker, but a new discussion is started
+	if body := mr.writes["POST "+api+"/discussions"]; !strings.HasPrefix(body, "<!-- codelens:finding:039f39127465d154 -->") {
+		t.Errorf("inline comment = %q", body)
+	}
+	// Our stale finding comment 202 is resolved, mallory's 203 is left alone
+	if body := mr.writes["PUT "+api+"/discussions/d202/notes/202"]; !strings.Contains(body, resolvedNotice) {
+		t.Errorf("resolved comment = %q", body)
+	}
+	// Our summary 101 is updated; mallory's summary 102 is not
+	if body := mr.writes["PUT "+api+"/discussions/d101/notes/101"]; !strings.Contains(body, "**1 findings**") {
+		t.Errorf("summary = %q", body)
+	}
+	if len(mr.writes) != 3 {
+		t.Errorf("writes = %v, want exactly the three above", mr.writes)
+	}
+}
+
+func TestPublishReviewFailsWithoutIdentity(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v4/user" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	}))
+	defer srv.Close()
+
+	review := &domain.Review{ID: "rev-1", Number: 7, Status: domain.ReviewStatusComplete}
+	target := &domain.ForgePullRequest{RepoURL: srv.URL + "/acme/app", Number: 7}
+	if err := publishReview(context.Background(), forge.NewGitLabClient(srv.URL), "bad-token", target, review, nil); err == nil {
+		t.Fatal("publishReview posted without knowing its own login")
+	}
+}
+
+func TestOwnerToken(t *testing.T) {
+	forges := port.ForgeProviderRegistry{
+		"github": forge.NewGitHubClient(),
+		"gitlab": forge.NewGitLabClient("https://gitlab.example.com"),
+	}
+	githubUser := &domain.User{Provider: "github", AccessToken: "gho_secret"}
+	tests := []struct {
+		name     string
+		user     *domain.User
+		provider string
+		repoURL  string
+		ok       bool
+	}{
+		{"own forge", githubUser, "github", "https://github.com/acme/app", true},
+		{"SSH remote on own forge", githubUser, "github", "git@github.com:acme/app.git", true},
+		{"other host", githubUser, "github", "https://attacker.example/acme/app", false},
+		{"lookalike host", githubUser, "github", "https://github.com.attacker.example/acme/app", false},
+		{"other provider", githubUser, "gitea", "https://github.com/acme/app", false},
+		{"gitlab user on github", &domain.User{Provider: "gitlab", AccessToken: "glpat"}, "github", "https://github.com/acme/app", false},
+		{"gitlab instance", &domain.User{Provider: "gitlab", AccessToken: "glpat"}, "gitlab", "https://gitlab.example.com/g/app", true},
+		{"no token", &domain.User{Provider: "github"}, "github", "https://github.com/acme/app", false},
+		{"unknown login provider", &domain.User{Provider: "google", AccessToken: "ya29"}, "google", "https://github.com/acme/app", false},
+	}
+	for _, tt := range tests {
+		token, err := ownerToken(forges, tt.user, tt.provider, tt.repoURL)
+		if tt.ok && (err != nil || token != tt.user.AccessToken) {
+			t.Errorf("%s: ownerToken = %q, %v", tt.name, token, err)
+		}
+		if !tt.ok && (err == nil || token != "") {
+			t.Errorf("%s: ownerToken handed out %q", tt.name, token)
+		}
+	}
+}
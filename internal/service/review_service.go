@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+const (
+	// reviewBatchChars is how much annotated diff is reviewed in a single chat call.
+	reviewBatchChars = 24000
+
+	// reviewMaxChars caps the diff reviewed per pull request; files beyond it are skipped.
+	reviewMaxChars = 200000
+)
+
+// ReviewService reviews the changes of pull requests with an LLM and stores the findings.
+type ReviewService struct {
+	store         *store.PostgresStore
+	vcs           port.VCSProvider
+	ai            port.AIProvider
+	notifications *NotificationService
+	forges        port.ForgeClientRegistry
+	creds         *CredentialService
+	autoPost      bool
+}
+
+// NewReviewService creates a new review service. Reviews with critical findings are
+// published to notifications (optional). forges post reviews to their pull requests:
+// on demand, and after every review when autoPost is set. Pull requests are fetched
+// with the credentials from creds.
+func NewReviewService(s *store.PostgresStore, vcs port.VCSProvider, ai port.AIProvider, notifications *NotificationService, forges port.ForgeClientRegistry, creds *CredentialService, autoPost bool) *ReviewService {
+	return &ReviewService{store: s, vcs: vcs, ai: ai, notifications: notifications, forges: forges, creds: creds, autoPost: autoPost}
+}
+
+// ReviewPullRequest reviews the diff between a pull request's head and its merge base
+// with the target branch. Each revision is reviewed once: if it already was, nil is
+// returned without doing any work.
+func (s *ReviewService) ReviewPullRequest(ctx context.Context, repo *domain.Repo, provider string, pr *domain.PullRequest) (*domain.Review, error) {
+	review, err := s.store.StartReview(ctx, &domain.Review{
+		RepoID:   repo.ID,
+		Provider: provider,
+		Number:   pr.Number,
+		Title:    pr.Title,
+		URL:      pr.URL,
+		HeadSHA:  pr.HeadSHA,
+	})
+	if err != nil || review == nil {
+		return nil, err
+	}
+
+	slog.Info("reviewing pull request", "repo_id", repo.ID, "number", pr.Number, "head", shortHash(pr.HeadSHA))
+	base, findings, summary, err := s.review(ctx, repo, review.ID, pr)
+	if err != nil {
+		if failErr := s.store.FailReview(ctx, review.ID, err.Error()); failErr != nil {
+			slog.Error("record review failure failed", "review_id", review.ID, "error", failErr)
+		}
+		return nil, err
+	}
+	if err := s.store.CompleteReview(ctx, review.ID, base, summary, findings); err != nil {
+		return nil, err
+	}
+
+	review.Status, review.BaseSHA, review.Summary, review.FindingCount = domain.ReviewStatusComplete, base, summary, len(findings)
+	slog.Info("pull request review complete", "repo_id", repo.ID, "number", pr.Number, "findings", len(findings))
+	s.notifyCritical(ctx, repo, review, findings)
+	if s.autoPost {
+		if err := s.PostComments(ctx, repo, review); err != nil {
+			slog.Warn("posting review comments failed", "review_id", review.ID, "error", err)
+		}
+	}
+	return review, nil
+}
+
+// notifyCritical publishes a finding.critical event when a review raised critical findings.
+func (s *ReviewService) notifyCritical(ctx context.Context, repo *domain.Repo, review *domain.Review, findings []domain.Finding) {
+	var titles []string
+	var lines []string
+	for _, f := range findings {
+		if f.Severity != domain.SeverityCritical {
+			continue
+		}
+		titles = append(titles, f.Title)
+		lines = append(lines, fmt.Sprintf("• %s (%s)", f.Title, findingLocation(&f)))
+	}
+	if len(titles) == 0 {
+		return
+	}
+	s.notifications.Publish(ctx, &domain.NotificationEvent{
+		Type:     domain.EventCriticalFinding,
+		RepoID:   repo.ID,
+		RepoName: repo.Name,
+		UserID:   repo.UserID,
+		Title:    fmt.Sprintf("%d critical finding(s) in #%d %s", len(titles), review.Number, review.Title),
+		Message:  strings.Join(lines, "\n"),
+		URL:      review.URL,
+		Data:     map[string]any{"review_id": review.ID, "number": review.Number, "findings": titles},
+	})
+}
+
+// mergeBaseDeepen is how many more commits a shallow clone fetches each time the merge
+// base of a pull request is not found in it; the amount doubles on every round.
+var mergeBaseDeepen = []int{50, 100, 200, 400, 800}
+
+// mergeBase returns the merge base of a pull request, deepening a shallow clone until
+// the base is part of its history.
+func (s *ReviewService) mergeBase(ctx context.Context, repo *domain.Repo, auth *domain.GitAuth, baseRef, head string) (string, error) {
+	base, err := s.vcs.MergeBase(ctx, repo.LocalPath, baseRef, head)
+	for _, commits := range mergeBaseDeepen {
+		if err == nil {
+			break
+		}
+		deepened, deepenErr := s.vcs.Deepen(ctx, repo.LocalPath, commits, auth)
+		if deepenErr != nil {
+			return "", deepenErr
+		}
+		if !deepened {
+			break // full history: there is no merge base
+		}
+		slog.Info("deepened shallow clone for review", "repo_id", repo.ID, "commits", commits)
+		base, err = s.vcs.MergeBase(ctx, repo.LocalPath, baseRef, head)
+	}
+	return base, err
+}
+
+// review fetches the pull request, diffs it against its merge base and asks the model
+// for findings, one batch of files at a time.
+func (s *ReviewService) review(ctx context.Context, repo *domain.Repo, reviewID string, pr *domain.PullRequest) (string, []domain.Finding, string, error) {
+	if repo.Status != domain.RepoStatusReady || repo.LocalPath == "" {
+		return "", nil, "", port.ErrRepoNotReady
+	}
+
+	// The head is fetched from the base repo, where forges publish it even for forks
+	headRef := "refs/codelens/" + strings.TrimPrefix(pr.HeadRef, "refs/")
+	baseRef := "refs/remotes/origin/" + pr.BaseBranch
+	auth, err := s.creds.GitAuth(ctx, repo)
+	if err != nil {
+		return "", nil, "", err
+	}
+	if err := s.vcs.Fetch(ctx, repo.LocalPath, auth, "+"+pr.HeadRef+":"+headRef, "+refs/heads/"+pr.BaseBranch+":"+baseRef); err != nil {
+		return "", nil, "", err
+	}
+
+	head := pr.HeadSHA
+	if head == "" {
+		head = headRef
+	}
+	headCommit, err := s.vcs.ResolveRef(ctx, repo.LocalPath, head)
+	if err != nil {
+		return "", nil, "", err
+	}
+	base, err := s.mergeBase(ctx, repo, auth, baseRef, headCommit.Hash)
+	if err != nil {
+		return "", nil, "", err
+	}
+	diff, err := s.vcs.Diff(ctx, repo.LocalPath, base, headCommit.Hash, auth)
+	if err != nil {
+		return "", nil, "", err
+	}
+
+	files := splitDiff(diff)
+	reviewed, skipped := files, 0
+	total := 0
+	for i, f := range files {
+		total += len(f.patch)
+		if total > reviewMaxChars {
+			reviewed, skipped = files[:i], len(files)-i
+			break
+		}
+	}
+
+	batches := batchFileDiffs(reviewed, reviewBatchChars)
+	var findings []domain.Finding
+	failedBatches := 0
+	for _, batch := range batches {
+		found, err := s.reviewBatch(ctx, pr, batch)
+		if err != nil {
+			if ctx.Err() != nil {
+				return "", nil, "", ctx.Err()
+			}
+			slog.Warn("review batch failed", "review_id", reviewID, "files", len(batch), "error", err)
+			failedBatches++
+			continue
+		}
+		for i := range found {
+			found[i].ReviewID, found[i].RepoID = reviewID, repo.ID
+		}
+		findings = append(findings, found...)
+	}
+	if len(batches) > 0 && failedBatches == len(batches) {
+		return "", nil, "", fmt.Errorf("review: every batch failed")
+	}
+
+	return base, findings, summarizeReview(len(reviewed), skipped, failedBatches, findings), nil
+}
+
+// reviewBatch asks the model for findings on a batch of file diffs.
+func (s *ReviewService) reviewBatch(ctx context.Context, pr *domain.PullRequest, files []fileDiff) ([]domain.Finding, error) {
+	systemPrompt := `You are an expert code reviewer. Review ONLY the changes in the diff of a pull request.
+Each diff line is prefixed with its line number in the new file ("+" added, " " context); removed lines have no number.
+Report real problems: bugs, security issues, data races, error handling mistakes, performance traps, and clear maintainability issues. Do not comment on style or formatting, and do not praise.
+Respond with ONLY a JSON object:
+{"findings": [{"file": "path/as/in/diff", "line": 42, "severity": "critical|high|medium|low|info", "title": "one-line summary", "body": "explanation and suggested fix"}]}
+Use the new-file line number of the added line the finding is about, or 0 for the whole file. Return {"findings": []} if there is nothing to report.`
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Pull request #%d: %s\n\n", pr.Number, pr.Title)
+	for _, f := range files {
+		fmt.Fprintf(&sb, "--- File: %s ---\n%s\n", f.path, f.patch)
+	}
+
+	response, err := s.ai.Chat(ctx, systemPrompt, sb.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("review: %w", err)
+	}
+	raw, ok := extractJSONObject(response)
+	if !ok {
+		return nil, fmt.Errorf("review: no JSON object in response")
+	}
+
+	var parsed struct {
+		Findings []struct {
+			File     string          `json:"file"`
+			Line     json.RawMessage `json:"line"` // models emit numbers and numeric strings alike
+			Severity string          `json:"severity"`
+			Title    string          `json:"title"`
+			Body     string          `json:"body"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(raw), &parsed); err != nil {
+		return nil, fmt.Errorf("review decode: %w", err)
+	}
+
+	byPath := make(map[string]*fileDiff, len(files))
+	for i := range files {
+		byPath[files[i].path] = &files[i]
+	}
+	seen := map[string]bool{}
+	var findings []domain.Finding
+	for _, pf := range parsed.Findings {
+		file, ok := byPath[strings.TrimPrefix(strings.TrimPrefix(pf.File, "b/"), "./")]
+		title := strings.TrimSpace(pf.Title)
+		if !ok || title == "" {
+			continue
+		}
+		// Only lines present in the diff can be anchored; anything else applies to the file
+		line, _ := strconv.Atoi(strings.Trim(string(pf.Line), `"`))
+		if !file.lines[line] {
+			line = 0
+		}
+		severity := strings.ToLower(strings.TrimSpace(pf.Severity))
+		if !slices.Contains(domain.Severities, severity) {
+			severity = domain.SeverityMedium
+		}
+
+		key := fmt.Sprintf("%s:%d:%s", file.path, line, strings.ToLower(title))
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		findings = append(findings, domain.Finding{
+			FilePath: file.path,
+			Line:     line,
+			Severity: severity,
+			Title:    truncateRunes(title, 200),
+			Body:     strings.TrimSpace(pf.Body),
+		})
+	}
+	return findings, nil
+}
+
+// fileDiff is the diff of one file, annotated with new-file line numbers.
+type fileDiff struct {
+	path  string
+	patch string
+	lines map[int]bool // new-file lines shown in the diff (added or context)
+}
+
+// splitDiff splits a unified diff into per-file diffs. Deleted and binary files are dropped,
+// since there is no new version to review.
+func splitDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var cur *fileDiff
+	var sb strings.Builder
+	newLine := 0
+
+	flush := func() {
+		if cur != nil && cur.path != "" && len(cur.lines) > 0 {
+			cur.patch = sb.String()
+			files = append(files, *cur)
+		}
+		cur = nil
+		sb.Reset()
+	}
+
+	for _, line := range strings.Split(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flush()
+			cur, newLine = &fileDiff{lines: map[int]bool{}}, 0
+		case cur == nil:
+		case strings.HasPrefix(line, "@@"):
+			newLine = hunkNewStart(line)
+			sb.WriteString(line + "\n")
+		case newLine == 0:
+			// file header (index, mode, rename, binary, ---/+++) before the first hunk
+			if path, ok := strings.CutPrefix(line, "+++ "); ok && path != "/dev/null" {
+				cur.path = strings.TrimPrefix(path, "b/")
+			}
+		case strings.HasPrefix(line, "+"), strings.HasPrefix(line, " "):
+			cur.lines[newLine] = true
+			fmt.Fprintf(&sb, "%5d %s\n", newLine, line)
+			newLine++
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprintf(&sb, "      %s\n", line)
+		}
+	}
+	flush()
+	return files
+}
+
+// hunkNewStart returns the new-file start line of a hunk header "@@ -a,b +c,d @@".
+func hunkNewStart(header string) int {
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[2], "+") {
+		return 0
+	}
+	start, _, _ := strings.Cut(strings.TrimPrefix(fields[2], "+"), ",")
+	n, err := strconv.Atoi(start)
+	if err != nil {
+		return 0
+	}
+	return max(n, 1)
+}
+
+// batchFileDiffs groups file diffs into batches of about maxChars. A file larger than
+// maxChars gets a batch of its own, truncated.
+func batchFileDiffs(files []fileDiff, maxChars int) [][]fileDiff {
+	var batches [][]fileDiff
+	var batch []fileDiff
+	size := 0
+	for _, f := range files {
+		if len(f.patch) > maxChars {
+			f.patch = f.patch[:maxChars] + "\n... (diff truncated)\n"
+		}
+		if size+len(f.patch) > maxChars && len(batch) > 0 {
+			batches = append(batches, batch)
+			batch, size = nil, 0
+		}
+		batch = append(batch, f)
+		size += len(f.patch)
+	}
+	if len(batch) > 0 {
+		batches = append(batches, batch)
+	}
+	return batches
+}
+
+// summarizeReview describes what a review covered and found, as Markdown.
+func summarizeReview(files, skipped, failedBatches int, findings []domain.Finding) string {
+	counts := map[string]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Reviewed %d changed file(s): **%d finding(s)**", files, len(findings))
+	var parts []string
+	for _, sev := range domain.Severities {
+		if counts[sev] > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", counts[sev], sev))
+		}
+	}
+	if len(parts) > 0 {
+		sb.WriteString(" (" + strings.Join(parts, ", ") + ")")
+	}
+	sb.WriteString(".")
+	if skipped > 0 {
+		fmt.Fprintf(&sb, "\n\n%d file(s) were not reviewed because the diff exceeds the review size limit.", skipped)
+	}
+	if failedBatches > 0 {
+		fmt.Fprintf(&sb, "\n\n%d batch(es) of files could not be reviewed; their findings are missing.", failedBatches)
+	}
+	return sb.String()
+}
+
+// truncateRunes shortens s to at most n runes.
+func truncateRunes(s string, n int) string {
+	if r := []rune(s); len(r) > n {
+		return string(r[:n])
+	}
+	return s
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/cron"
+)
+
+// AnalysisStarter starts an analysis job for a repo and returns its job ID.
+// An empty strategies list runs every strategy.
+type AnalysisStarter func(repoID string, strategies []string) (string, error)
+
+// ScheduleService validates schedules and runs the ones that are due.
+type ScheduleService struct {
+	store    *store.PostgresStore
+	repos    *RepoService
+	vcs      port.VCSProvider
+	analysis *AnalysisService
+	starter  AnalysisStarter
+	onSync   SyncFunc
+}
+
+// NewScheduleService creates a new schedule service. Analyses are started through
+// starter, so scheduled runs go through the same job path as API requests; onSync
+// (optional) is notified of syncs done before a run.
+func NewScheduleService(s *store.PostgresStore, repos *RepoService, vcs port.VCSProvider, analysis *AnalysisService, starter AnalysisStarter, onSync SyncFunc) *ScheduleService {
+	return &ScheduleService{store: s, repos: repos, vcs: vcs, analysis: analysis, starter: starter, onSync: onSync}
+}
+
+// Prepare validates a schedule's cron expression, timezone and strategies, and sets
+// its next run time (nil when disabled).
+func (s *ScheduleService) Prepare(sch *domain.Schedule) error {
+	if sch.Timezone == "" {
+		sch.Timezone = "UTC"
+	}
+	if sch.Strategies == nil {
+		sch.Strategies = []string{}
+	}
+	available := s.analysis.ListStrategies()
+	for _, name := range sch.Strategies {
+		if !slices.Contains(available, name) {
+			return fmt.Errorf("%w: %s", port.ErrStrategyNotFound, name)
+		}
+	}
+
+	next, err := nextRun(sch, time.Now())
+	if err != nil {
+		return err
+	}
+	sch.NextRunAt = next
+	return nil
+}
+
+// nextRun returns the first run of sch after t, or nil if it is disabled or never runs again.
+func nextRun(sch *domain.Schedule, t time.Time) (*time.Time, error) {
+	expr, err := cron.Parse(sch.Cron)
+	if err != nil {
+		return nil, err
+	}
+	loc, err := time.LoadLocation(sch.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q", sch.Timezone)
+	}
+	if !sch.Enabled {
+		return nil, nil
+	}
+	next := expr.Next(t.In(loc))
+	if next.IsZero() {
+		return nil, nil
+	}
+	next = next.UTC()
+	return &next, nil
+}
+
+// Run starts the analyses of due schedules, checking every checkInterval until ctx
+// is cancelled.
+func (s *ScheduleService) Run(ctx context.Context, checkInterval time.Duration) {
+	if checkInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		due, err := s.store.ListDueSchedules(ctx, time.Now())
+		if err != nil {
+			slog.Error("list due schedules failed", "error", err)
+			continue
+		}
+		for i := range due {
+			if ctx.Err() != nil {
+				return
+			}
+			s.runSchedule(ctx, &due[i])
+		}
+	}
+}
+
+// runSchedule runs one due schedule and records the outcome and the next run time.
+func (s *ScheduleService) runSchedule(ctx context.Context, sch *domain.Schedule) {
+	status, commit, jobID, runErr := domain.ScheduleRunStarted, "", "", ""
+
+	head, err := s.prepareHead(ctx, sch)
+	switch {
+	case err != nil:
+		status, runErr = domain.ScheduleRunError, err.Error()
+		slog.Warn("scheduled analysis failed", "schedule_id", sch.ID, "repo_id", sch.RepoID, "error", err)
+	case head == sch.LastCommit:
+		// Only a completed run sets LastCommit, so failed runs are retried
+		status = domain.ScheduleRunSkipped
+		slog.Info("scheduled analysis skipped, HEAD unchanged", "schedule_id", sch.ID, "repo_id", sch.RepoID, "commit", shortHash(head))
+	default:
+		if jobID, err = s.starter(sch.RepoID, sch.Strategies); err != nil {
+			status, runErr = domain.ScheduleRunError, err.Error()
+			slog.Warn("scheduled analysis failed", "schedule_id", sch.ID, "repo_id", sch.RepoID, "error", err)
+		} else {
+			commit = head
+			slog.Info("scheduled analysis started", "schedule_id", sch.ID, "repo_id", sch.RepoID, "job_id", jobID, "commit", shortHash(head))
+		}
+	}
+
+	next, err := nextRun(sch, time.Now())
+	if err != nil {
+		// The expression was valid when saved; stop running rather than retrying every tick
+		slog.Error("compute next schedule run failed", "schedule_id", sch.ID, "error", err)
+		next = nil
+	}
+	if err := s.store.RecordScheduleRun(ctx, sch.ID, status, commit, jobID, runErr, next); err != nil {
+		slog.Error("record schedule run failed", "schedule_id", sch.ID, "error", err)
+	}
+}
+
+// FinishRun records the end of an analysis job: when a schedule started it, a
+// completed job (runErr empty) makes the run's HEAD the last analyzed one, and a failed
+// job marks the run failed.
+func (s *ScheduleService) FinishRun(ctx context.Context, jobID, runErr string) {
+	status := domain.ScheduleRunComplete
+	if runErr != "" {
+		status = domain.ScheduleRunError
+	}
+	if err := s.store.FinishScheduleRun(ctx, jobID, status, runErr); err != nil {
+		slog.Error("record schedule run outcome failed", "job_id", jobID, "error", err)
+	}
+}
+
+// prepareHead syncs the repo if the schedule asks for it and returns its HEAD commit.
+func (s *ScheduleService) prepareHead(ctx context.Context, sch *domain.Schedule) (string, error) {
+	repo, err := s.store.GetRepoByID(sch.RepoID)
+	if err != nil {
+		return "", err
+	}
+	if err := s.repos.EnsureClone(ctx, repo); err != nil {
+		return "", err
+	}
+
+	if sch.SyncBeforeRun {
+		result, err := s.repos.Sync(ctx, repo)
+		// A sync already in progress (manual or periodic) updates the working copy anyway
+		if !errors.Is(err, port.ErrSyncInProgress) {
+			if s.onSync != nil {
+				s.onSync(repo, result, err)
+			}
+			if err != nil {
+				return "", fmt.Errorf("sync: %w", err)
+			}
+			return result.Head.Hash, nil
+		}
+	}
+
+	auth, err := s.repos.GitAuth(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+	commits, err := s.vcs.Log(ctx, repo.LocalPath, 1, auth)
+	if err != nil {
+		return "", fmt.Errorf("read HEAD: %w", err)
+	}
+	if len(commits) == 0 {
+		return "", errors.New("read HEAD: no commits")
+	}
+	return commits[0].Hash, nil
+}
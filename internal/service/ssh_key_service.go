@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"database/sql"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/pkg/secretbox"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHKeyService generates SSH key pairs for cloning over SSH and hands their private
+// halves, decrypted, to git operations.
+type SSHKeyService struct {
+	store *store.PostgresStore
+	box   *secretbox.Box // encrypts private keys at rest
+}
+
+// NewSSHKeyService creates a new SSH key service.
+func NewSSHKeyService(s *store.PostgresStore, box *secretbox.Box) *SSHKeyService {
+	return &SSHKeyService{store: s, box: box}
+}
+
+// Generate creates an Ed25519 key pair for a user, as a deploy key of repoID or, when
+// repoID is empty, as a user key. The returned public key is to be added to the forge.
+func (s *SSHKeyService) Generate(ctx context.Context, userID, repoID, name string) (*domain.SSHKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate key: %w", err)
+	}
+	sshPub, err := ssh.NewPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("encode public key: %w", err)
+	}
+
+	comment := "codelens"
+	if name != "" {
+		comment += "-" + strings.Join(strings.Fields(name), "-")
+	}
+	block, err := ssh.MarshalPrivateKey(priv, comment)
+	if err != nil {
+		return nil, fmt.Errorf("encode private key: %w", err)
+	}
+	sealed, err := s.box.Seal(pem.EncodeToMemory(block))
+	if err != nil {
+		return nil, fmt.Errorf("encrypt private key: %w", err)
+	}
+
+	return s.store.CreateSSHKey(ctx, &domain.SSHKey{
+		UserID:      userID,
+		RepoID:      repoID,
+		Name:        name,
+		KeyType:     sshPub.Type(),
+		PublicKey:   strings.TrimSpace(string(ssh.MarshalAuthorizedKey(sshPub))) + " " + comment,
+		Fingerprint: ssh.FingerprintSHA256(sshPub),
+		PrivateKey:  sealed,
+	})
+}
+
+// GitAuth returns the credentials to reach a repo's remote with: its deploy key, else
+// its owner's user key. It returns nil when there is no key, or no key service.
+func (s *SSHKeyService) GitAuth(ctx context.Context, repo *domain.Repo) (*domain.GitAuth, error) {
+	if s == nil {
+		return nil, nil
+	}
+	key, err := s.store.GetSSHKeyForRepo(ctx, repo)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	private, err := s.box.Open(key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt ssh key %s: %w", key.ID, err)
+	}
+	if err := s.store.TouchSSHKey(ctx, key.ID); err != nil {
+		slog.Warn("record ssh key use failed", "key_id", key.ID, "error", err)
+	}
+	return &domain.GitAuth{SSHKey: private}, nil
+}
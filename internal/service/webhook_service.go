@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/adapter/store"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/domain"
+	"github.com/arturoeanton/go-git-analyzer-ollama/internal/port"
+)
+
+// WebhookService acts on forge webhook events: a push to a repo's tracked branch syncs
+// it and runs the push strategies, and an opened or updated pull request is reviewed.
+type WebhookService struct {
+	store          *store.PostgresStore
+	repos          *RepoService
+	reviews        *ReviewService
+	starter        AnalysisStarter
+	onSync         SyncFunc
+	pushStrategies []string
+}
+
+// NewWebhookService creates a new webhook service. pushStrategies are the strategies
+// run after a push (none = sync only); onSync (optional) is notified of those syncs.
+func NewWebhookService(s *store.PostgresStore, repos *RepoService, reviews *ReviewService, starter AnalysisStarter, onSync SyncFunc, pushStrategies []string) *WebhookService {
+	return &WebhookService{store: s, repos: repos, reviews: reviews, starter: starter, onSync: onSync, pushStrategies: pushStrategies}
+}
+
+// Authorize returns the repos tracking the repository at urls whose own webhook secret
+// verifies a delivery. Every repo is checked against its secret only, so a delivery
+// signed for one user's repo never acts on another user's copy of the repository. It
+// returns port.ErrWebhookSignature when no repo verifies the delivery.
+func (s *WebhookService) Authorize(ctx context.Context, urls []string, verify func(secret string) error) ([]domain.Repo, error) {
+	repos, err := s.MatchRepos(ctx, urls)
+	if err != nil {
+		return nil, err
+	}
+	return verifiedRepos(repos, verify)
+}
+
+// verifiedRepos returns the repos whose webhook secret verifies a delivery.
+func verifiedRepos(repos []domain.Repo, verify func(secret string) error) ([]domain.Repo, error) {
+	var verified []domain.Repo
+	for _, r := range repos {
+		if r.WebhookSecret != "" && verify(r.WebhookSecret) == nil {
+			verified = append(verified, r)
+		}
+	}
+	if len(verified) == 0 {
+		return nil, port.ErrWebhookSignature
+	}
+	return verified, nil
+}
+
+// Handle starts the work for an event on the repos returned by Authorize in the
+// background, and returns the IDs of the repos it applies to. Every user whose repo
+// verified the delivery gets its own sync or review.
+func (s *WebhookService) Handle(evt *domain.WebhookEvent, repos []domain.Repo) []string {
+	ids := []string{}
+	if evt.Kind != domain.WebhookEventPush && evt.Kind != domain.WebhookEventPullRequest {
+		return ids
+	}
+	for i := range repos {
+		repo := &repos[i]
+		// Evicted clones are restored for reviews; a push just finds them up to date later
+		if repo.Status != domain.RepoStatusReady && (repo.Status != domain.RepoStatusEvicted || evt.Kind != domain.WebhookEventPullRequest) {
+			continue
+		}
+		switch evt.Kind {
+		case domain.WebhookEventPush:
+			if evt.Ref != "refs/heads/"+repo.DefaultBranch {
+				continue
+			}
+			go s.onPush(repo, evt)
+		case domain.WebhookEventPullRequest:
+			go s.onPullRequest(repo, evt)
+		}
+		ids = append(ids, repo.ID)
+	}
+	return ids
+}
+
+// onPush syncs the repo and, if HEAD moved, runs the push strategies.
+func (s *WebhookService) onPush(repo *domain.Repo, evt *domain.WebhookEvent) {
+	ctx := context.Background()
+	result, err := s.repos.Sync(ctx, repo)
+	if errors.Is(err, port.ErrSyncInProgress) {
+		slog.Info("webhook push ignored, sync already in progress", "repo_id", repo.ID, "commit", shortHash(evt.After))
+		return
+	}
+	if s.onSync != nil {
+		s.onSync(repo, result, err)
+	}
+	if err != nil {
+		slog.Warn("webhook sync failed", "repo_id", repo.ID, "provider", evt.Provider, "error", err)
+		return
+	}
+	if !result.Updated || len(s.pushStrategies) == 0 {
+		return
+	}
+
+	jobID, err := s.starter(repo.ID, s.pushStrategies)
+	if err != nil {
+		slog.Warn("webhook analysis failed to start", "repo_id", repo.ID, "error", err)
+		return
+	}
+	slog.Info("webhook analysis started", "repo_id", repo.ID, "job_id", jobID, "commit", shortHash(result.Head.Hash))
+}
+
+// onPullRequest reviews the pull request's current revision.
+func (s *WebhookService) onPullRequest(repo *domain.Repo, evt *domain.WebhookEvent) {
+	if err := s.repos.EnsureClone(context.Background(), repo); err != nil {
+		slog.Warn("pull request review failed", "repo_id", repo.ID, "number", evt.PullRequest.Number, "error", err)
+		return
+	}
+	release, err := s.repos.HoldClone(repo.ID)
+	if err != nil {
+		slog.Warn("pull request review failed", "repo_id", repo.ID, "number", evt.PullRequest.Number, "error", err)
+		return
+	}
+	defer release()
+
+	if _, err := s.reviews.ReviewPullRequest(context.Background(), repo, evt.Provider, evt.PullRequest); err != nil {
+		slog.Warn("pull request review failed", "repo_id", repo.ID, "number", evt.PullRequest.Number, "error", err)
+	}
+}
+
+// MatchRepos returns the repos whose URL points at the same repository as any of urls.
+func (s *WebhookService) MatchRepos(ctx context.Context, urls []string) ([]domain.Repo, error) {
+	keys := map[string]bool{}
+	paths := map[string]bool{}
+	for _, u := range urls {
+		if key := remoteKey(u); key != "" {
+			keys[key] = true
+			if _, path, ok := strings.Cut(key, "/"); ok {
+				paths[path] = true
+			}
+		}
+	}
+
+	var matched []domain.Repo
+	seen := map[string]bool{}
+	for path := range paths {
+		candidates, err := s.store.ListReposByURLPath(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range candidates {
+			if keys[remoteKey(r.URL)] && !seen[r.ID] {
+				seen[r.ID] = true
+				matched = append(matched, r)
+			}
+		}
+	}
+	return matched, nil
+}
+
+// remoteKey normalizes a Git remote URL to "host/path" so that the HTTPS, SSH and
+// web URLs of a repository compare equal: scheme, credentials, port, ".git" suffix
+// and trailing slashes are dropped, and the result is lowercased.
+func remoteKey(raw string) string {
+	s := strings.TrimSpace(raw)
+	if _, rest, ok := strings.Cut(s, "://"); ok {
+		s = rest
+	} else if colon, slash := strings.Index(s, ":"), strings.Index(s, "/"); colon > 0 && (slash < 0 || colon < slash) {
+		s = s[:colon] + "/" + s[colon+1:] // scp-like: git@host:owner/name
+	}
+
+	host, path, ok := strings.Cut(s, "/")
+	if !ok {
+		return ""
+	}
+	if at := strings.LastIndex(host, "@"); at >= 0 {
+		host = host[at+1:]
+	}
+	host, _, _ = strings.Cut(host, ":")
+	path = strings.TrimSuffix(strings.TrimRight(path, "/"), ".git")
+	if host == "" || path == "" {
+		return ""
+	}
+	return strings.ToLower(host + "/" + path)
+}
@@ -0,0 +1,8 @@
+-- CodeLens AI: Full-text index over embedding content for hybrid (lexical + vector) retrieval
+-- Punctuation is stripped before tokenizing so identifiers like h.buildAnalysisRequest(...)
+-- index as plain words instead of being swallowed by the host/file token rules.
+
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS content_tsv tsvector
+    GENERATED ALWAYS AS (to_tsvector('simple', regexp_replace(content, '[^A-Za-z0-9_]+', ' ', 'g'))) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_content_tsv ON embeddings USING gin (content_tsv);
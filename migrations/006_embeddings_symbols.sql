@@ -0,0 +1,10 @@
+-- CodeLens AI: Structural metadata for syntax-aware chunks
+-- Each embedding chunk now maps to a symbol (function, method, type...) and a line range.
+
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS symbol_name   TEXT DEFAULT '';
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS symbol_kind   VARCHAR(20) DEFAULT '';
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS parent_symbol TEXT DEFAULT '';
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS start_line    INTEGER DEFAULT 0;
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS end_line      INTEGER DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_symbol ON embeddings(repo_id, symbol_name);
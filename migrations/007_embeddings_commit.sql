@@ -0,0 +1,4 @@
+-- CodeLens AI: Record the commit each embedding chunk was read from
+-- Together with start_line/end_line this lets RAG answers cite exact source lines.
+
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS commit_hash VARCHAR(64) DEFAULT '';
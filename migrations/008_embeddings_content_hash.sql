@@ -0,0 +1,7 @@
+-- CodeLens AI: Content hashes for incremental re-indexing
+-- A new snapshot reuses the chunk vectors of files whose sha256 is unchanged since
+-- the previous vectorized snapshot; only changed files are embedded again.
+
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64) DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_snapshot_file ON embeddings(snapshot_id, file_path);
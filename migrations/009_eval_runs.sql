@@ -0,0 +1,36 @@
+-- CodeLens AI: RAG evaluation runs
+-- Each run executes a golden question set against a repository and stores
+-- aggregate and per-question retrieval/answer metrics for comparison across runs.
+
+CREATE TABLE IF NOT EXISTS eval_runs (
+    id           UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    repo_id      UUID NOT NULL REFERENCES repos(id) ON DELETE CASCADE,
+    set_name     VARCHAR(255) NOT NULL,
+    label        VARCHAR(255) DEFAULT '',
+    config       JSONB NOT NULL DEFAULT '{}',
+    k            INTEGER NOT NULL,
+    case_count   INTEGER NOT NULL DEFAULT 0,
+    recall_at_k  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    mrr          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    faithfulness DOUBLE PRECISION NOT NULL DEFAULT 0,
+    fact_recall  DOUBLE PRECISION NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_runs_repo_set ON eval_runs(repo_id, set_name, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS eval_results (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    run_id          UUID NOT NULL REFERENCES eval_runs(id) ON DELETE CASCADE,
+    case_id         VARCHAR(255) NOT NULL,
+    question        TEXT NOT NULL,
+    recall          DOUBLE PRECISION NOT NULL DEFAULT 0,
+    reciprocal_rank DOUBLE PRECISION NOT NULL DEFAULT 0,
+    faithfulness    DOUBLE PRECISION NOT NULL DEFAULT 0,
+    fact_recall     DOUBLE PRECISION NOT NULL DEFAULT 0,
+    retrieved_files TEXT[] NOT NULL DEFAULT '{}',
+    answer          TEXT DEFAULT '',
+    error           TEXT DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_eval_results_run ON eval_results(run_id);
@@ -0,0 +1,31 @@
+-- CodeLens AI: Embedding model/dimension tracking
+-- Every embedding row belongs to an embedding index: the set of vectors produced by
+-- one model at one dimension. Exactly one index is active and served by searches;
+-- a re-embedding migration builds a new index in the background and switches atomically.
+-- The vector column becomes dimension-agnostic; ANN indexes are partial, one per dimension.
+
+CREATE TABLE IF NOT EXISTS embedding_indexes (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    model           VARCHAR(255) NOT NULL,
+    dimension       INTEGER NOT NULL,
+    status          VARCHAR(50) NOT NULL DEFAULT 'building', -- building, active, retired, failed
+    total_chunks    INTEGER NOT NULL DEFAULT 0,
+    embedded_chunks INTEGER NOT NULL DEFAULT 0,
+    error           TEXT DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    activated_at    TIMESTAMPTZ
+);
+
+-- At most one active index
+CREATE UNIQUE INDEX IF NOT EXISTS idx_embedding_indexes_active ON embedding_indexes(status) WHERE status = 'active';
+
+-- Existing rows are adopted by the active index the server creates on first start
+ALTER TABLE embeddings ADD COLUMN IF NOT EXISTS index_id UUID REFERENCES embedding_indexes(id) ON DELETE CASCADE;
+CREATE INDEX IF NOT EXISTS idx_embeddings_index_snapshot ON embeddings(index_id, snapshot_id);
+
+DROP INDEX IF EXISTS idx_embeddings_vector;
+ALTER TABLE embeddings ALTER COLUMN vector TYPE vector;
+
+CREATE INDEX IF NOT EXISTS idx_embeddings_vector_1024 ON embeddings
+    USING ivfflat ((vector::vector(1024)) vector_cosine_ops) WITH (lists = 100)
+    WHERE vector_dims(vector) = 1024;
@@ -0,0 +1,12 @@
+-- CodeLens AI: ANN index lifecycle
+-- Records how each per-dimension vector index was last built (HNSW or IVFFlat, its
+-- parameters and the row count at build time) so the server can rebuild it when the
+-- configuration changes or, for IVFFlat, when the data has outgrown its lists.
+
+CREATE TABLE IF NOT EXISTS vector_index_builds (
+    dimension     INTEGER PRIMARY KEY,
+    index_type    VARCHAR(20) NOT NULL,          -- hnsw, ivfflat
+    params        JSONB NOT NULL DEFAULT '{}',   -- m/ef_construction or lists
+    rows_at_build BIGINT NOT NULL DEFAULT 0,
+    built_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,12 @@
+-- CodeLens AI: Content-addressed embedding cache
+-- Vectors keyed by sha256(model, text), so unchanged chunks are never sent to the
+-- embedding model twice, whichever snapshot or repository they come from.
+
+CREATE TABLE IF NOT EXISTS embedding_cache (
+    key        CHAR(64) PRIMARY KEY,          -- hex sha256 of model + NUL + text
+    model      VARCHAR(255) NOT NULL,
+    vector     REAL[] NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_embedding_cache_model ON embedding_cache(model);
@@ -0,0 +1,14 @@
+-- CodeLens AI: Per-file indexing failures
+-- Files that could not be chunked, embedded or written during snapshot indexing,
+-- so partial indexes are visible instead of only logged.
+
+CREATE TABLE IF NOT EXISTS index_failures (
+    id          UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    snapshot_id UUID NOT NULL REFERENCES snapshots(id) ON DELETE CASCADE,
+    file_path   TEXT NOT NULL,
+    stage       VARCHAR(20) NOT NULL,   -- chunk, embed, write
+    error       TEXT NOT NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_index_failures_snapshot ON index_failures(snapshot_id);
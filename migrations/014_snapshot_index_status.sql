@@ -0,0 +1,17 @@
+-- CodeLens AI: Snapshot indexing status
+-- Snapshots move pending → vectorized (or failed) as their RAG index is built;
+-- the counts and error of the last indexing run are kept on the snapshot.
+
+ALTER TABLE snapshots
+    ADD COLUMN IF NOT EXISTS chunk_count  INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS failed_files INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS index_error  TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS indexed_at   TIMESTAMPTZ;
+
+-- Snapshots indexed before statuses were tracked stayed pending although they have embeddings
+UPDATE snapshots s
+SET status      = 'vectorized',
+    chunk_count = (SELECT COUNT(*) FROM embeddings e WHERE e.snapshot_id = s.id),
+    indexed_at  = s.created_at
+WHERE s.status = 'pending'
+  AND EXISTS (SELECT 1 FROM embeddings e WHERE e.snapshot_id = s.id);
@@ -0,0 +1,11 @@
+-- CodeLens AI: Repository sync
+-- Cloned repos are fetched on demand (POST /repos/:id/sync) or every
+-- sync_interval_minutes by the background syncer (0 = manual only).
+
+ALTER TABLE repos
+    ADD COLUMN IF NOT EXISTS sync_interval_minutes INTEGER NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS head_commit           VARCHAR(64) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS last_synced_at        TIMESTAMPTZ,
+    ADD COLUMN IF NOT EXISTS sync_error            TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_repos_sync ON repos(sync_interval_minutes) WHERE sync_interval_minutes > 0;
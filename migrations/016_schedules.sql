@@ -0,0 +1,24 @@
+-- CodeLens AI: Scheduled analyses
+-- Recurring analysis runs per repo on a cron expression evaluated in a timezone.
+-- A run is skipped when the repo's HEAD has not changed since the last one.
+
+CREATE TABLE IF NOT EXISTS schedules (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    repo_id         UUID NOT NULL REFERENCES repos(id) ON DELETE CASCADE,
+    strategies      TEXT[] NOT NULL DEFAULT '{}',   -- empty = every strategy
+    cron            VARCHAR(100) NOT NULL,
+    timezone        VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    sync_before_run BOOLEAN NOT NULL DEFAULT TRUE,
+    enabled         BOOLEAN NOT NULL DEFAULT TRUE,
+    next_run_at     TIMESTAMPTZ,
+    last_run_at     TIMESTAMPTZ,
+    last_commit     VARCHAR(64) NOT NULL DEFAULT '',  -- HEAD analyzed by the last run
+    last_job_id     VARCHAR(64) NOT NULL DEFAULT '',
+    last_status     VARCHAR(20) NOT NULL DEFAULT '',  -- started, skipped, error
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_schedules_repo ON schedules(repo_id);
+CREATE INDEX IF NOT EXISTS idx_schedules_due ON schedules(next_run_at) WHERE enabled;
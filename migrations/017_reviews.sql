@@ -0,0 +1,44 @@
+-- CodeLens AI: Pull request reviews and webhook secrets
+-- A review covers one revision (head commit) of a pull/merge request; its findings are
+-- anchored to a file and, when part of the diff, a line of the new file.
+
+CREATE TABLE IF NOT EXISTS reviews (
+    id            UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    repo_id       UUID NOT NULL REFERENCES repos(id) ON DELETE CASCADE,
+    provider      VARCHAR(20) NOT NULL,             -- github, gitlab, gitea
+    number        INTEGER NOT NULL,                 -- pull request number (GitLab: MR iid)
+    title         TEXT NOT NULL DEFAULT '',
+    url           TEXT NOT NULL DEFAULT '',
+    base_sha      VARCHAR(64) NOT NULL DEFAULT '',
+    head_sha      VARCHAR(64) NOT NULL,
+    status        VARCHAR(20) NOT NULL DEFAULT 'running',  -- running, complete, failed
+    summary       TEXT NOT NULL DEFAULT '',
+    error         TEXT NOT NULL DEFAULT '',
+    finding_count INTEGER NOT NULL DEFAULT 0,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at  TIMESTAMPTZ
+);
+
+-- Redelivered webhooks for the same revision do not start a second review
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_revision ON reviews(repo_id, provider, number, head_sha);
+CREATE INDEX IF NOT EXISTS idx_reviews_repo ON reviews(repo_id, created_at DESC);
+
+CREATE TABLE IF NOT EXISTS review_findings (
+    id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    review_id  UUID NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    repo_id    UUID NOT NULL REFERENCES repos(id) ON DELETE CASCADE,
+    file_path  TEXT NOT NULL,
+    line       INTEGER NOT NULL DEFAULT 0,          -- 0 = whole file
+    severity   VARCHAR(20) NOT NULL,                -- critical, high, medium, low, info
+    title      TEXT NOT NULL,
+    body       TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_review_findings_review ON review_findings(review_id);
+
+-- Webhook deliveries are verified against the secret of each repo they match. Existing
+-- and new repos get 32 random bytes, hex-encoded; the secret can be rotated through the API.
+CREATE EXTENSION IF NOT EXISTS pgcrypto;
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS webhook_secret TEXT NOT NULL
+    DEFAULT encode(gen_random_bytes(32), 'hex');
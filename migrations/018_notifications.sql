@@ -0,0 +1,35 @@
+-- CodeLens AI: Outbound notifications
+-- Users subscribe a channel (generic webhook, Slack-compatible webhook, email) to events
+-- for all of their repos or a single one. Every notification is written to the delivery
+-- log first and sent from there, so failed sends are retried with backoff.
+
+CREATE TABLE IF NOT EXISTS notification_subscriptions (
+    id         UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    repo_id    UUID REFERENCES repos(id) ON DELETE CASCADE,  -- NULL = every repo of the user
+    channel    VARCHAR(20) NOT NULL,                         -- webhook, slack, email
+    target     TEXT NOT NULL,                                -- URL or email address
+    secret     TEXT NOT NULL DEFAULT '',                     -- webhook: HMAC key for X-CodeLens-Signature
+    events     TEXT[] NOT NULL DEFAULT '{}',                 -- empty = every event
+    enabled    BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_subscriptions_user ON notification_subscriptions(user_id);
+
+CREATE TABLE IF NOT EXISTS notification_deliveries (
+    id              UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    subscription_id UUID NOT NULL REFERENCES notification_subscriptions(id) ON DELETE CASCADE,
+    event_type      VARCHAR(40) NOT NULL,
+    payload         JSONB NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'pending',  -- pending, delivered, failed
+    attempts        INTEGER NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_error      TEXT NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    delivered_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_due ON notification_deliveries(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_notification_deliveries_subscription ON notification_deliveries(subscription_id, created_at DESC);
@@ -0,0 +1,6 @@
+-- CodeLens AI: Review comments posted back to the forge
+-- Comments are deduplicated on the forge itself through hidden markers; the review
+-- only records whether its latest post succeeded.
+
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS comments_posted_at TIMESTAMPTZ;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS comments_error TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,26 @@
+-- CodeLens AI: Tracker issues opened from review findings
+-- Each repo can have one issue tracker; findings opened as issues keep a link to them
+-- whose state is synced back periodically.
+
+CREATE TABLE IF NOT EXISTS issue_trackers (
+    repo_id        UUID PRIMARY KEY REFERENCES repos(id) ON DELETE CASCADE,
+    kind           VARCHAR(20) NOT NULL,            -- github, gitlab, jira
+    base_url       TEXT NOT NULL DEFAULT '',        -- Jira site, or forge host when not the repo's own
+    project        TEXT NOT NULL DEFAULT '',        -- owner/name, group/project or Jira project key; '' = the repo itself
+    username       TEXT NOT NULL DEFAULT '',        -- Jira Cloud account email (Basic auth)
+    token          TEXT NOT NULL DEFAULT '',        -- '' = the repo owner's OAuth token
+    issue_type     TEXT NOT NULL DEFAULT '',        -- Jira issue type
+    labels         TEXT[] NOT NULL DEFAULT '{}',    -- added to every issue, next to severity:<level>
+    title_template TEXT NOT NULL DEFAULT '',
+    body_template  TEXT NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+ALTER TABLE review_findings ADD COLUMN IF NOT EXISTS issue_tracker VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE review_findings ADD COLUMN IF NOT EXISTS issue_key TEXT NOT NULL DEFAULT '';
+ALTER TABLE review_findings ADD COLUMN IF NOT EXISTS issue_url TEXT NOT NULL DEFAULT '';
+ALTER TABLE review_findings ADD COLUMN IF NOT EXISTS issue_state VARCHAR(20) NOT NULL DEFAULT '';  -- open, closed
+ALTER TABLE review_findings ADD COLUMN IF NOT EXISTS issue_synced_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_review_findings_open_issues ON review_findings(issue_synced_at) WHERE issue_state = 'open';
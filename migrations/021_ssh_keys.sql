@@ -0,0 +1,20 @@
+-- CodeLens AI: SSH keys for cloning over SSH
+-- Key pairs are generated server-side; the public half is added to the forge as a
+-- deploy key (repo_id set) or as a user key (repo_id NULL, used for every repo of the
+-- user without a deploy key). Private keys are stored encrypted with AES-GCM.
+
+CREATE TABLE IF NOT EXISTS ssh_keys (
+    id                    UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id               UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    repo_id               UUID REFERENCES repos(id) ON DELETE CASCADE,  -- NULL = user key
+    name                  VARCHAR(100) NOT NULL DEFAULT '',
+    key_type              VARCHAR(20) NOT NULL,                         -- ssh-ed25519
+    public_key            TEXT NOT NULL,                                -- authorized_keys format
+    fingerprint           VARCHAR(100) NOT NULL,                        -- SHA256:...
+    private_key_encrypted BYTEA NOT NULL,                               -- nonce || AES-GCM sealed PEM
+    last_used_at          TIMESTAMPTZ,
+    created_at            TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_ssh_keys_user ON ssh_keys(user_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_ssh_keys_repo ON ssh_keys(repo_id, created_at DESC) WHERE repo_id IS NOT NULL;
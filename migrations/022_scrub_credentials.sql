@@ -0,0 +1,12 @@
+-- CodeLens AI: Keep OAuth tokens out of repo URLs and clones
+-- Clones used to be made from URLs with an embedded token, which git kept in the clone's
+-- .git/config. Tokens now reach git through its environment only. Credentials are
+-- stripped from stored URLs here; clones of existing repos (remote_scrubbed = FALSE)
+-- have their origin URL scrubbed by the server at startup.
+
+UPDATE repos
+SET url = regexp_replace(url, '^(https?://)[^/@]+@', '\1', 'i')
+WHERE url ~* '^https?://[^/@]+@';
+
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS remote_scrubbed BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE repos ALTER COLUMN remote_scrubbed SET DEFAULT TRUE;
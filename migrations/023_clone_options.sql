@@ -0,0 +1,9 @@
+-- CodeLens AI: Clone modes for large repositories
+-- Repos can be cloned shallow (clone_depth commits, deepened on demand), blobless
+-- (file contents fetched when needed), with the tracked branch only, and with a sparse
+-- checkout of some directories, which also limits what is indexed and analyzed.
+
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS clone_depth INTEGER NOT NULL DEFAULT 0;                 -- 0 = full history
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS clone_blobless BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS clone_single_branch BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS clone_sparse_paths TEXT[] NOT NULL DEFAULT '{}';       -- empty = whole tree
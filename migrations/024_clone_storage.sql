@@ -0,0 +1,9 @@
+-- CodeLens AI: Clone disk usage
+-- disk_bytes is the size of a repo's clone, measured after cloning and syncing, and counts
+-- against its owner's quota. last_used_at orders idle clones for eviction: evicted repos
+-- (status 'evicted') lose their working copy but keep their data, and are cloned again on use.
+
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS disk_bytes BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE repos ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMPTZ NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_repos_lru ON repos(user_id, last_used_at) WHERE status = 'ready';
@@ -0,0 +1,6 @@
+-- CodeLens AI: Issue tracker tokens encrypted at rest
+-- Tokens are sealed with AES-GCM under the SECRET_KEY key, like SSH private keys.
+-- Plaintext tokens saved earlier are sealed into token_encrypted at startup, which
+-- clears token.
+
+ALTER TABLE issue_trackers ADD COLUMN IF NOT EXISTS token_encrypted BYTEA NOT NULL DEFAULT ''::bytea;  -- nonce || AES-GCM sealed token
@@ -0,0 +1,6 @@
+-- CodeLens AI: Scheduled runs count once their analysis completes
+-- run_commit is the HEAD the last started run analyzes. It becomes last_commit, which
+-- later runs compare against to skip an unchanged HEAD, only when the run's job
+-- completes, so a failed or interrupted run is retried.
+
+ALTER TABLE schedules ADD COLUMN IF NOT EXISTS run_commit VARCHAR(64) NOT NULL DEFAULT '';
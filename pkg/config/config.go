@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"slices"
 	"strconv"
+	"strings"
 )
 
 // Config holds all application configuration loaded from environment variables.
@@ -24,6 +27,22 @@ type Config struct {
 	GitHubClientSecret string
 	GitHubRedirectURL  string
 
+	// OAuth2 — GitLab (gitlab.com or self-hosted)
+	GitLabURL          string
+	GitLabClientID     string
+	GitLabClientSecret string
+	GitLabRedirectURL  string
+
+	// OAuth2 — Gitea / Forgejo
+	GiteaURL          string
+	GiteaClientID     string
+	GiteaClientSecret string
+	GiteaRedirectURL  string
+
+	// OAuth2 — Bitbucket Cloud (the callback URL is set on the OAuth consumer)
+	BitbucketClientID     string
+	BitbucketClientSecret string
+
 	// JWT
 	JWTSecret     string
 	JWTIssuer     string
@@ -46,11 +65,69 @@ type Config struct {
 	ModelDevOps        string
 	ModelSecurity      string
 	ModelChat          string // for interactive chat
+	ModelRerank        string // for the RAG reranking stage
+	ModelJudge         string // for grading answers in RAG evaluation runs
+	ModelReview        string // for pull request reviews
 
 	EmbeddingDimension int
+	EmbeddingCache     bool // content-addressed cache of embedding vectors in Postgres
+
+	// Vector ANN index (pgvector)
+	VectorIndexType    string // hnsw or ivfflat
+	HNSWM              int
+	HNSWEfConstruction int
+	HNSWEfSearch       int
+	IVFFlatLists       int // 0 = sized from the row count at build time
+	IVFFlatProbes      int
+
+	// RAG indexing pipeline
+	IndexWorkers    int // concurrent embedding requests
+	IndexBatchSize  int // chunks per embedding request
+	IndexBatchChars int // characters per embedding request
+	IndexWriteBatch int // rows per COPY into the vector store
+
+	// RAG index retention: superseded snapshots beyond these limits lose their embeddings
+	IndexGCKeepSnapshots int // newest vectorized snapshots kept per repo
+	IndexGCMinAgeHours   int // snapshots younger than this are always kept
+	IndexGCInterval      int // minutes between collections; 0 disables the collector
+
+	// RAG reranking (optional second stage after hybrid retrieval)
+	RerankEnabled    bool
+	RerankCandidates int // chunks over-fetched and scored by the reranker
+	RerankTopN       int // chunks kept after reranking
 
 	// Repos
-	CloneBasePath string
+	CloneBasePath      string
+	RepoSyncCheckSecs  int // how often the syncer looks for repos whose sync interval has elapsed; 0 disables it
+	SchedulerCheckSecs int // how often the scheduler looks for due analysis schedules; 0 disables it
+
+	// Clone disk usage: idle clones are evicted (and cloned again when next used)
+	CloneQuotaMB         int // disk space per user for clones; 0 = unlimited
+	CloneIdleHours       int // clones unused for this long are evicted; 0 keeps them
+	CloneJanitorInterval int // minutes between eviction rounds; 0 disables the janitor
+
+	// Secrets at rest
+	SecretKey string // encrypts stored SSH private keys and tracker tokens; empty disables SSH key management and tracker tokens
+
+	// SSH cloning
+	SSHKnownHostsFile string // empty = ssh's default, ~/.ssh/known_hosts
+	SSHHostKeyPolicy  string // strict, accept-new or off
+
+	// Forge webhooks
+	WebhookPushStrategies []string // strategies run after a push to a tracked branch (empty = sync only)
+	ReviewPostComments    bool     // post every pull request review back to the pull request as comments
+
+	// Tracker issues opened from findings
+	IssueSyncMinutes int // how often the state of open issues is synced back; 0 disables it
+
+	// Outbound notifications
+	SMTPHost           string // empty disables the email channel
+	SMTPPort           int
+	SMTPUsername       string
+	SMTPPassword       string
+	SMTPFrom           string
+	NotifyDispatchSecs int     // how often due deliveries are sent and retried; 0 disables delivery
+	NotifyScoreDrop    float64 // minimum drop of a strategy score reported as a regression; 0 disables it
 
 	// MCP
 	MCPEnabled bool
@@ -76,6 +153,19 @@ func Load() *Config {
 		GitHubClientSecret: os.Getenv("GITHUB_CLIENT_SECRET"),
 		GitHubRedirectURL:  envOrDefault("GITHUB_REDIRECT_URL", "http://localhost:8080/auth/callback"),
 
+		GitLabURL:          envOrDefault("GITLAB_URL", "https://gitlab.com"),
+		GitLabClientID:     os.Getenv("GITLAB_CLIENT_ID"),
+		GitLabClientSecret: os.Getenv("GITLAB_CLIENT_SECRET"),
+		GitLabRedirectURL:  envOrDefault("GITLAB_REDIRECT_URL", "http://localhost:8080/auth/callback"),
+
+		GiteaURL:          envOrDefault("GITEA_URL", "https://gitea.com"),
+		GiteaClientID:     os.Getenv("GITEA_CLIENT_ID"),
+		GiteaClientSecret: os.Getenv("GITEA_CLIENT_SECRET"),
+		GiteaRedirectURL:  envOrDefault("GITEA_REDIRECT_URL", "http://localhost:8080/auth/callback"),
+
+		BitbucketClientID:     os.Getenv("BITBUCKET_CLIENT_ID"),
+		BitbucketClientSecret: os.Getenv("BITBUCKET_CLIENT_SECRET"),
+
 		JWTSecret:     envOrDefault("JWT_SECRET", "change-me-in-production"),
 		JWTIssuer:     envOrDefault("JWT_ISSUER", "codelens-ai"),
 		JWTExpiration: envOrDefaultInt("JWT_EXPIRATION_HOURS", 24),
@@ -94,10 +184,58 @@ func Load() *Config {
 		ModelDevOps:        os.Getenv("OLLAMA_MODEL_DEVOPS"),
 		ModelSecurity:      os.Getenv("OLLAMA_MODEL_SECURITY"),
 		ModelChat:          os.Getenv("OLLAMA_MODEL_CHAT"),
+		ModelRerank:        os.Getenv("OLLAMA_MODEL_RERANK"),
+		ModelJudge:         os.Getenv("OLLAMA_MODEL_JUDGE"),
+		ModelReview:        os.Getenv("OLLAMA_MODEL_REVIEW"),
 
 		EmbeddingDimension: envOrDefaultInt("EMBEDDING_DIMENSION", 1024),
+		EmbeddingCache:     envOrDefaultBool("EMBEDDING_CACHE_ENABLED", true),
+
+		VectorIndexType:    envOrDefault("VECTOR_INDEX_TYPE", "hnsw"),
+		HNSWM:              envOrDefaultInt("HNSW_M", 16),
+		HNSWEfConstruction: envOrDefaultInt("HNSW_EF_CONSTRUCTION", 64),
+		HNSWEfSearch:       envOrDefaultInt("HNSW_EF_SEARCH", 100),
+		IVFFlatLists:       envOrDefaultInt("IVFFLAT_LISTS", 0),
+		IVFFlatProbes:      envOrDefaultInt("IVFFLAT_PROBES", 10),
+
+		IndexWorkers:    envOrDefaultInt("INDEX_EMBED_WORKERS", 4),
+		IndexBatchSize:  envOrDefaultInt("INDEX_BATCH_SIZE", 64),
+		IndexBatchChars: envOrDefaultInt("INDEX_BATCH_CHARS", 64000),
+		IndexWriteBatch: envOrDefaultInt("INDEX_WRITE_BATCH", 500),
+
+		IndexGCKeepSnapshots: envOrDefaultInt("INDEX_GC_KEEP_SNAPSHOTS", 3),
+		IndexGCMinAgeHours:   envOrDefaultInt("INDEX_GC_MIN_AGE_HOURS", 24),
+		IndexGCInterval:      envOrDefaultInt("INDEX_GC_INTERVAL_MINUTES", 60),
+
+		RerankEnabled:    envOrDefaultBool("RAG_RERANK_ENABLED", false),
+		RerankCandidates: envOrDefaultInt("RAG_RERANK_CANDIDATES", 50),
+		RerankTopN:       envOrDefaultInt("RAG_RERANK_TOP_N", 10),
+
+		CloneBasePath:      envOrDefault("CLONE_BASE_PATH", "/tmp/codelens-repos"),
+		RepoSyncCheckSecs:  envOrDefaultInt("REPO_SYNC_CHECK_SECONDS", 60),
+		SchedulerCheckSecs: envOrDefaultInt("SCHEDULER_CHECK_SECONDS", 30),
 
-		CloneBasePath: envOrDefault("CLONE_BASE_PATH", "/tmp/codelens-repos"),
+		CloneQuotaMB:         envOrDefaultInt("CLONE_QUOTA_MB", 0),
+		CloneIdleHours:       envOrDefaultInt("CLONE_IDLE_HOURS", 0),
+		CloneJanitorInterval: envOrDefaultInt("CLONE_JANITOR_INTERVAL_MINUTES", 60),
+
+		SecretKey: envOrDefault("SECRET_KEY", os.Getenv("SSH_KEY_SECRET")), // SSH_KEY_SECRET is its former name
+
+		SSHKnownHostsFile: os.Getenv("SSH_KNOWN_HOSTS_FILE"),
+		SSHHostKeyPolicy:  envOrDefault("SSH_HOST_KEY_POLICY", "accept-new"),
+
+		WebhookPushStrategies: envList("WEBHOOK_PUSH_STRATEGIES", "code_quality,security"),
+		ReviewPostComments:    envOrDefaultBool("REVIEW_POST_COMMENTS", true),
+
+		IssueSyncMinutes: envOrDefaultInt("ISSUE_SYNC_MINUTES", 30),
+
+		SMTPHost:           os.Getenv("SMTP_HOST"),
+		SMTPPort:           envOrDefaultInt("SMTP_PORT", 587),
+		SMTPUsername:       os.Getenv("SMTP_USERNAME"),
+		SMTPPassword:       os.Getenv("SMTP_PASSWORD"),
+		SMTPFrom:           os.Getenv("SMTP_FROM"),
+		NotifyDispatchSecs: envOrDefaultInt("NOTIFY_DISPATCH_SECONDS", 15),
+		NotifyScoreDrop:    envOrDefaultFloat("NOTIFY_SCORE_DROP", 1.0),
 
 		MCPEnabled: envOrDefaultBool("MCP_ENABLED", true),
 		MCPPort:    envOrDefault("MCP_PORT", "3002"),
@@ -106,6 +244,17 @@ func Load() *Config {
 	}
 }
 
+// sshHostKeyPolicies are the accepted values of SSH_HOST_KEY_POLICY.
+var sshHostKeyPolicies = []string{"strict", "accept-new", "off"}
+
+// Validate rejects settings that would otherwise fall back silently to a default.
+func (c *Config) Validate() error {
+	if !slices.Contains(sshHostKeyPolicies, c.SSHHostKeyPolicy) {
+		return fmt.Errorf("SSH_HOST_KEY_POLICY %q: must be one of %s", c.SSHHostKeyPolicy, strings.Join(sshHostKeyPolicies, ", "))
+	}
+	return nil
+}
+
 // DSN returns a formatted connection string for logging (password masked).
 func (c *Config) DSN() string {
 	return "postgres://***@***/codelens (from DATABASE_URL)"
@@ -128,6 +277,12 @@ func (c *Config) ModelForStrategy(strategy string) string {
 		m = c.ModelSecurity
 	case "chat":
 		m = c.ModelChat
+	case "rerank":
+		m = c.ModelRerank
+	case "judge":
+		m = c.ModelJudge
+	case "review":
+		m = c.ModelReview
 	}
 	if m != "" {
 		return m
@@ -152,6 +307,16 @@ func envOrDefaultInt(key string, fallback int) int {
 	return fallback
 }
 
+func envOrDefaultFloat(key string, fallback float64) float64 {
+	if v := os.Getenv(key); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			return f
+		}
+	}
+	return fallback
+}
+
 func envOrDefaultBool(key string, fallback bool) bool {
 	if v := os.Getenv(key); v != "" {
 		b, err := strconv.ParseBool(v)
@@ -161,3 +326,19 @@ func envOrDefaultBool(key string, fallback bool) bool {
 	}
 	return fallback
 }
+
+// envList reads a comma-separated list, dropping empty entries. An unset variable
+// yields fallback; a variable set to "" yields an empty list.
+func envList(key, fallback string) []string {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		v = fallback
+	}
+	var out []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
@@ -0,0 +1,104 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	invalid := []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"*/x * * * *",
+		"* * * foo *",
+		"@every",
+	}
+	for _, expr := range invalid {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) accepted", expr)
+		}
+	}
+}
+
+func TestNext(t *testing.T) {
+	// 2026-01-01 is a Thursday
+	from := time.Date(2026, 1, 1, 10, 7, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"step", "*/15 * * * *", from, time.Date(2026, 1, 1, 10, 15, 0, 0, time.UTC)},
+		{"strictly after", "7 10 * * *", from, time.Date(2026, 1, 2, 10, 7, 0, 0, time.UTC)},
+		{"seconds truncated", "8 10 * * *", from.Add(59 * time.Second), time.Date(2026, 1, 1, 10, 8, 0, 0, time.UTC)},
+		{"list and range", "0 9-17/4,22 * * *", from, time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC)},
+		{"month name", "0 0 1 mar *", from, time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)},
+		{"macro", "@monthly", from, time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"macro case", "@Hourly", from, time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC)},
+		{"sunday as 0", "0 0 * * 0", from, time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", from, time.Date(2026, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{"weekday range to 7", "0 0 * * 6-7", from, time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"dow only", "0 0 * * mon", from, time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC)},
+		{"dom only", "0 0 13 * *", from, time.Date(2026, 1, 13, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow", "0 0 13 * fri", from, time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{"dom or dow, dom first", "0 0 3 * mon", from, time.Date(2026, 1, 3, 0, 0, 0, 0, time.UTC)},
+		{"dom and month", "0 0 31 * *", time.Date(2026, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2026, 3, 31, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", from, time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", from, time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			if got := s.Next(tt.from); !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	ny, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("no tz data: %v", err)
+	}
+	// 2026-03-08 02:00 EST jumps to 03:00 EDT; 2026-11-01 02:00 EDT falls back to 01:00 EST
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time // UTC
+	}{
+		{"hourly across spring forward", "0 * * * *", time.Date(2026, 3, 8, 1, 30, 0, 0, ny), time.Date(2026, 3, 8, 7, 0, 0, 0, time.UTC)},
+		{"missing hour skipped", "30 2 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 9, 6, 30, 0, 0, time.UTC)},
+		{"daily after spring forward", "0 9 * * *", time.Date(2026, 3, 7, 12, 0, 0, 0, ny), time.Date(2026, 3, 8, 13, 0, 0, 0, time.UTC)},
+		{"repeated hour runs again", "0 * * * *", time.Date(2026, 11, 1, 5, 0, 0, 0, time.UTC).In(ny), time.Date(2026, 11, 1, 6, 0, 0, 0, time.UTC)},
+		{"daily after fall back", "0 9 * * *", time.Date(2026, 10, 31, 12, 0, 0, 0, ny), time.Date(2026, 11, 1, 14, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.expr, err)
+			}
+			got := s.Next(tt.from)
+			if !got.Equal(tt.want) {
+				t.Errorf("Next(%s) = %s, want %s", tt.from, got.UTC(), tt.want)
+			}
+			if got.Location() != ny {
+				t.Errorf("Next returned location %s, want %s", got.Location(), ny)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+// Package publicnet reaches user-supplied URLs without exposing the server's network.
+//
+// Clients only connect to public unicast addresses, checked on the resolved address at
+// dial time, so DNS names and redirects pointing at internal hosts are refused too.
+package publicnet
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivate is returned for targets on loopback, private, link-local and other
+// non-public addresses, which users must not reach through the server.
+var ErrPrivate = errors.New("target address is not public")
+
+// cgnatPrefix is the carrier-grade NAT range, private in practice.
+var cgnatPrefix = netip.MustParsePrefix("100.64.0.0/10")
+
+// IsPublic reports whether ip is a public unicast address.
+func IsPublic(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsGlobalUnicast() && !ip.IsPrivate() && !cgnatPrefix.Contains(ip)
+}
+
+// ValidateURL checks that target is an absolute http(s) URL whose host is not a
+// private address literal or localhost. Names are checked again when dialed.
+func ValidateURL(target string) error {
+	u, err := url.Parse(target)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("target must be an http(s) URL")
+	}
+	host := strings.ToLower(strings.TrimSuffix(u.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivate
+	}
+	if ip, err := netip.ParseAddr(host); err == nil && !IsPublic(ip) {
+		return ErrPrivate
+	}
+	return nil
+}
+
+// refusePrivate is a net.Dialer Control hook that refuses connections to non-public
+// addresses.
+func refusePrivate(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil || !IsPublic(ip) {
+		return ErrPrivate
+	}
+	return nil
+}
+
+// NewClient returns an HTTP client for user-supplied targets: it only connects to
+// public addresses, and never through a proxy, which would dial on its behalf.
+func NewClient(timeout time.Duration) *http.Client {
+	dialer := &net.Dialer{Timeout: 10 * time.Second, Control: refusePrivate}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+	return &http.Client{Timeout: timeout, Transport: transport}
+}
@@ -0,0 +1,83 @@
+package publicnet
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestValidateURL(t *testing.T) {
+	valid := []string{"https://hooks.example.com/x", "http://93.184.216.34:8080/hook"}
+	for _, target := range valid {
+		if err := ValidateURL(target); err != nil {
+			t.Errorf("ValidateURL(%q) = %v", target, err)
+		}
+	}
+
+	invalid := []string{
+		"ftp://example.com/x",
+		"/relative",
+		"https://",
+		"http://localhost:8080/x",
+		"http://api.localhost/x",
+		"http://127.0.0.1/x",
+		"http://10.1.2.3/x",
+		"http://192.168.0.10/x",
+		"http://172.16.5.4/x",
+		"http://169.254.169.254/latest/meta-data",
+		"http://[::1]/x",
+		"http://[fe80::1]/x",
+		"http://[fd00::1]/x",
+		"http://[::ffff:10.0.0.1]/x",
+		"http://0.0.0.0/x",
+		"http://100.64.0.1/x",
+	}
+	for _, target := range invalid {
+		if err := ValidateURL(target); err == nil {
+			t.Errorf("ValidateURL(%q) accepted", target)
+		}
+	}
+}
+
+func TestIsPublic(t *testing.T) {
+	tests := map[string]bool{
+		"8.8.8.8":              true,
+		"2606:4700:4700::1111": true,
+		"127.0.0.1":            false,
+		"10.0.0.1":             false,
+		"172.31.255.255":       false,
+		"192.168.1.1":          false,
+		"169.254.169.254":      false,
+		"100.100.100.200":      false,
+		"0.0.0.0":              false,
+		"224.0.0.1":            false,
+		"255.255.255.255":      false,
+		"::1":                  false,
+		"::":                   false,
+		"fe80::1":              false,
+		"fc00::1":              false,
+		"::ffff:127.0.0.1":     false,
+	}
+	for addr, want := range tests {
+		if got := IsPublic(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("IsPublic(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+func TestClientRefusesLoopback(t *testing.T) {
+	reached := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { reached = true }))
+	defer srv.Close()
+
+	_, err := NewClient(5 * time.Second).Get(srv.URL)
+	if !errors.Is(err, ErrPrivate) {
+		t.Errorf("Get %s = %v, want ErrPrivate", srv.URL, err)
+	}
+	if reached {
+		t.Error("request reached the loopback server")
+	}
+}
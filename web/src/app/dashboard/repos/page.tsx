@@ -72,7 +72,7 @@ export default function ReposPage() {
         setLoading(true);
         try {
             const [ghData, localData] = await Promise.all([
-                api<{ repos: GitHubRepo[] }>("/api/v1/repos/github", { token }).catch(() => ({ repos: [] })),
+                api<{ repos: GitHubRepo[] }>("/api/v1/repos/forge", { token }).catch(() => ({ repos: [] })),
                 api<{ repos: LocalRepo[] }>("/api/v1/repos", { token }).catch(() => ({ repos: [] })),
             ]);
             setGithubRepos(ghData.repos || []);
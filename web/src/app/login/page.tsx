@@ -63,6 +63,22 @@ export default function LoginPage() {
                             </svg>
                             {isLoading === "github" ? "Connecting..." : "Continue with GitHub"}
                         </button>
+
+                        {/* Further forges; their login only works once the OAuth app is configured */}
+                        {[
+                            { id: "gitlab", label: "GitLab" },
+                            { id: "gitea", label: "Gitea" },
+                            { id: "bitbucket", label: "Bitbucket" },
+                        ].map((forge) => (
+                            <button
+                                key={forge.id}
+                                className="btn-social hover-lift"
+                                onClick={() => handleSocialLogin(forge.id)}
+                                disabled={isLoading !== null}
+                            >
+                                {isLoading === forge.id ? "Connecting..." : `Continue with ${forge.label}`}
+                            </button>
+                        ))}
                     </div>
 
                     <div className="login-divider">or continue with email</div>